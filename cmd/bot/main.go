@@ -12,8 +12,10 @@ import (
 	"github.com/Sentinaut/AuraBot/modules/counting"
 	"github.com/Sentinaut/AuraBot/modules/levelling"
 	"github.com/Sentinaut/AuraBot/modules/logging"
+	"github.com/Sentinaut/AuraBot/modules/members"
 	"github.com/Sentinaut/AuraBot/modules/starboard"
 	"github.com/Sentinaut/AuraBot/modules/votingthreads"
+	"github.com/Sentinaut/AuraBot/modules/web"
 	"github.com/Sentinaut/AuraBot/modules/welcoming"
 )
 
@@ -91,15 +93,36 @@ const (
 
 // 🔢 COUNTING CONFIG
 const (
+	// Default milestone reactions (seeded once; manage afterwards with /countingmilestone)
 	CountingEmoji200  = "200:1474445480468418684"
 	CountingEmoji500  = "500:1474446309321609370"
 	CountingEmoji1000 = "1000:1474445538937278596"
 
+	// Same number from a different user within this window of the accepted one is ignored (lag race)
+	CountingGraceWindow = 500 * time.Millisecond
+
+	// Anti-cheat: what editing/deleting an accepted count does ("ignore", "strike" or "ruin")
+	CountingEditAction   = counting.TamperIgnore
+	CountingDeleteAction = counting.TamperIgnore // "strike"/"ruin" need the bot to have View Audit Log
+
+	// Strikes within the window before the ruined role is given
+	CountingStrikeLimit  = 3
+	CountingStrikeWindow = 7 * 24 * time.Hour
+
+	// Who may count; their messages are deleted otherwise. Both off until staff opt in.
+	// Accounts younger than this can't count (0 = off), e.g. 7 * 24 * time.Hour
+	CountingMinAccountAge = 0
+	// Role needed to count ("" = anyone), e.g. MemberRoleID
+	CountingRequiredRoleID = ""
+
+	// Ruins found while catching up after downtime also give the ruined role
+	CountingCatchUpPunish = false
+
 	CountingCustomRuinerUserID = "614628933337350149"
 	CountingCustomRuinerGIFURL = "https://tenor.com/view/sydney-trains-scrapping-s-set-sad-double-decker-gif-16016618"
 )
 
-// 🎖️ Levelling milestone roles (stack roles)
+// 🎖️ Levelling milestone roles
 var LevelRoles = map[int]string{
 	3:  "1474150309310759054",
 	5:  "1474150347164614757",
@@ -108,6 +131,66 @@ var LevelRoles = map[int]string{
 	20: "1474150395348779250",
 }
 
+// stack = keep every milestone role reached, replace = only the highest,
+// strict = stack, but roles above someone's level are removed when they lose XP
+const LevelRolesMode = levelling.MilestoneStack
+
+// 🎉 Level-up announcements: here (where the XP was earned), channel (ChannelID), dm or silent.
+// Members can opt out of their own with /levelnotify.
+var LevelUpNotify = levelling.LevelUpNotify{
+	Mode:           levelling.LevelUpHere,
+	ChannelID:      "",
+	MilestonesOnly: false,
+	Mention:        false,
+}
+
+// 📈 XP curve (XP to go from level L to L+1). /xpcurve set overrides this at runtime.
+var XPCurve = levelling.Curve{
+	Kind:   levelling.CurvePolynomial,
+	Coeffs: []float64{100, 60, 7}, // 7L² + 60L + 100
+}
+
+// ✖️ XP multipliers (missing = 1x, 0 = no XP). Only a member's highest role multiplier applies.
+// /xpboost start adds a timed global boost on top.
+var XPMultipliers = levelling.Multipliers{
+	Channels: map[string]float64{
+		// e.g. half XP in #off-topic, 1.5x in #vip-chat:
+		// "<#off-topic channel ID>": 0.5,
+		// "<#vip-chat channel ID>":  1.5,
+	},
+	Roles: map[string]float64{
+		// "<booster role ID>": 1.25,
+	},
+}
+
+// 🛡️ Which messages earn XP. /xpblock adds users/roles at runtime.
+// Every filter ships off (0 / empty); suggested values are in the comments.
+var XPRules = levelling.XPRules{
+	BlockedRoles: []string{}, // e.g. UnverifiedRoleID
+	BlockedUsers: []string{},
+
+	// e.g. 3 chars, 2 words (one-word replies and image-only messages then earn nothing)
+	MinChars: 0,
+	MinWords: 0,
+
+	// e.g. 10 * time.Minute
+	DuplicateWindow: 0,
+
+	// e.g. window 10 * time.Minute, free 15, decay 0.85, floor 0.25:
+	// more than 15 messages in 10 minutes and each extra one is worth 15% less, down to 25%
+	RapidWindow: 0,
+	RapidFree:   0,
+	RapidDecay:  0,
+	RapidFloor:  0,
+}
+
+// 🎙️ Voice XP: per minute unmuted with at least one other person (AFK channel never counts)
+var XPVoice = levelling.VoiceXP{
+	Enabled:          false, // opt in once staff have picked a rate
+	XPPerMinute:      4,
+	ExcludedChannels: []string{},
+}
+
 // ⭐ Channels that count toward starboard (manual stars)
 var StarChannels = []string{
 	"1474003503809564676", // #hotel-chat
@@ -124,6 +207,13 @@ var XPChannels = []string{
 	"1474154178355138736", // #staff-chat
 }
 
+// 🌐 Read-only web leaderboard (XP, counting, starboard) + JSON API under /api/. Off by default.
+var WebLeaderboard = web.Config{
+	Enabled: false,
+	Addr:    "127.0.0.1:8080",
+	Title:   "PlayAura Leaderboards",
+}
+
 func main() {
 	cfg, err := bot.LoadConfig()
 	if err != nil {
@@ -166,7 +256,51 @@ func main() {
 		}
 	}
 
+	// Shared member list (members table), used by levelling, counting, welcoming and the web leaderboard
+	tracker := members.New(GuildID, database.DB)
+
+	// Shared with the web leaderboard
+	topStars := starboard.NewTopStars(database.DB, GuildID)
+	levels := levelling.New(levelling.Config{
+		Channels:       XPChannels,
+		LevelRoles:     LevelRoles,
+		LevelRolesMode: LevelRolesMode,
+		LevelUpNotify:  LevelUpNotify,
+		Curve:          XPCurve,
+		Multipliers:    XPMultipliers,
+		Rules:          XPRules,
+		Voice:          XPVoice,
+	}, GuildID, tracker, database.DB)
+	countingMod := counting.New(
+		GuildID,
+		ChannelCounting,
+		ChannelCountingTrios,
+		CountingRuinedRoleID,
+		16*time.Hour,
+		CountingGraceWindow,
+		counting.AntiCheat{
+			EditAction:     CountingEditAction,
+			DeleteAction:   CountingDeleteAction,
+			StrikeLimit:    CountingStrikeLimit,
+			StrikeWindow:   CountingStrikeWindow,
+			RepostTampered: true,
+			MinAccountAge:  CountingMinAccountAge,
+			RequiredRoleID: CountingRequiredRoleID,
+		},
+		CountingCatchUpPunish,
+		CountingEmoji200,
+		CountingEmoji500,
+		CountingEmoji1000,
+		CountingCustomRuinerUserID,
+		CountingCustomRuinerGIFURL,
+		tracker,
+		database.DB,
+	)
+
 	r, err := bot.NewRunner(cfg.Token, []bot.Module{
+		// 👥 Member tracker (first, so it loads the saved member list before anything needs it)
+		tracker,
+
 		// 🧾 Log reposting (trade/store/command logs)
 		logging.New(
 			GuildID,
@@ -185,25 +319,13 @@ func main() {
 		),
 
 		// ⭐ Starboard leaderboard command
-		starboard.NewTopStars(database.DB, GuildID),
+		topStars,
 
 		// ⭐ Levelling / XP system
-		levelling.New(XPChannels, GuildID, LevelRoles, database.DB),
+		levels,
 
 		// 🔢 Counting (normal + trios) + ruined role for 16 hours
-		counting.New(
-			GuildID,
-			ChannelCounting,
-			ChannelCountingTrios,
-			CountingRuinedRoleID,
-			16*time.Hour,
-			CountingEmoji200,
-			CountingEmoji500,
-			CountingEmoji1000,
-			CountingCustomRuinerUserID,
-			CountingCustomRuinerGIFURL,
-			database.DB,
-		),
+		countingMod,
 
 		// ✅ Autoroles (reaction roles)
 		autoroles.New(database.DB, GuildID),
@@ -225,8 +347,12 @@ func main() {
 			UnverifiedRoleID,
 			JoinRoleID,
 			StaffRoleID,
+			tracker,
 		),
 
+		// 🌐 Web leaderboard (does nothing unless WebLeaderboard.Enabled)
+		web.New(WebLeaderboard, GuildID, tracker, levels, countingMod, topStars),
+
 		// If you want texttalk enabled from main.go, uncomment this and add the import:
 		// texttalk.New(TextTalkChannelID),
 	})
@@ -2,7 +2,11 @@ module github.com/Sentinaut/AuraBot
 
 go 1.25.5
 
-require github.com/bwmarrin/discordgo v0.29.0
+require (
+	github.com/bwmarrin/discordgo v0.29.0
+	golang.org/x/image v0.25.0
+	modernc.org/sqlite v1.42.2
+)
 
 require (
 	github.com/dustin/go-humanize v1.0.1 // indirect
@@ -11,10 +15,10 @@ require (
 	github.com/ncruces/go-strftime v0.1.9 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
 	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b // indirect
+	golang.org/x/text v0.23.0 // indirect
 	modernc.org/libc v1.66.10 // indirect
 	modernc.org/mathutil v1.7.1 // indirect
 	modernc.org/memory v1.11.0 // indirect
-	modernc.org/sqlite v1.42.2 // indirect
 )
 
 require (
@@ -37,6 +37,8 @@ func NewRunner(token string, modules []Module) (*Runner, error) {
 		discordgo.IntentsGuildMembers | // 👈 THIS LINE
 		discordgo.IntentsGuildMessages |
 		discordgo.IntentsGuildMessageReactions |
+		discordgo.IntentsGuildVoiceStates | // voice XP (levelling)
+		discordgo.IntentsGuildInvites | // join sources for /invites (levelling)
 		discordgo.IntentsMessageContent
 
 	return &Runner{Session: s, Modules: modules}, nil
@@ -71,6 +71,126 @@ func Migrate(d *sql.DB) error {
 			total_counted INTEGER NOT NULL DEFAULT 0
 		);`,
 
+		// Counting history (every accepted count, ruin and staff restore; used for rollback)
+		`CREATE TABLE IF NOT EXISTS counting_history (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			channel_id TEXT NOT NULL,
+			count      INTEGER NOT NULL,
+			user_id    TEXT NOT NULL DEFAULT '',
+			username   TEXT NOT NULL DEFAULT '',
+			message_id TEXT NOT NULL DEFAULT '',
+			event      TEXT NOT NULL DEFAULT 'count',
+			note       TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_counting_history_channel
+			ON counting_history(channel_id, id DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_counting_history_count
+			ON counting_history(channel_id, count);`,
+		`CREATE INDEX IF NOT EXISTS idx_counting_history_created
+			ON counting_history(channel_id, created_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_counting_history_message
+			ON counting_history(message_id);`,
+
+		// Counting anti-cheat log (edit/delete tampering, blocked accounts; reviewed with /countingviolations)
+		`CREATE TABLE IF NOT EXISTS counting_violations (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			channel_id TEXT NOT NULL,
+			user_id    TEXT NOT NULL,
+			username   TEXT NOT NULL DEFAULT '',
+			message_id TEXT NOT NULL DEFAULT '',
+			kind       TEXT NOT NULL,
+			action     TEXT NOT NULL,
+			count      INTEGER NOT NULL DEFAULT 0,
+			detail     TEXT NOT NULL DEFAULT '',
+			pardoned   INTEGER NOT NULL DEFAULT 0,
+			created_at INTEGER NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_counting_violations_user
+			ON counting_violations(user_id, created_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_counting_violations_created
+			ON counting_violations(created_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_counting_violations_message
+			ON counting_violations(message_id);`,
+
+		// Counting recaps already posted (one row per channel + period; restart-safe)
+		`CREATE TABLE IF NOT EXISTS counting_recaps (
+			channel_id   TEXT NOT NULL,
+			period       TEXT NOT NULL,
+			period_start INTEGER NOT NULL,
+			posted_at    INTEGER NOT NULL,
+			PRIMARY KEY (channel_id, period, period_start)
+		);`,
+
+		// Counting goals shown in recaps ("reach 5,000 by Friday")
+		`CREATE TABLE IF NOT EXISTS counting_goals (
+			channel_id TEXT PRIMARY KEY,
+			target     INTEGER NOT NULL,
+			deadline   INTEGER NOT NULL DEFAULT 0,
+			set_by     TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);`,
+
+		// Counting milestones (per channel; exact number OR every multiple of N)
+		`CREATE TABLE IF NOT EXISTS counting_milestones (
+			id              INTEGER PRIMARY KEY AUTOINCREMENT,
+			channel_id      TEXT NOT NULL,
+			number          INTEGER NOT NULL DEFAULT 0,
+			every           INTEGER NOT NULL DEFAULT 0,
+			emoji           TEXT NOT NULL DEFAULT '',
+			announce        INTEGER NOT NULL DEFAULT 0,
+			role_id         TEXT NOT NULL DEFAULT '',
+			ping_channel_id TEXT NOT NULL DEFAULT '',
+			created_at      INTEGER NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_counting_milestones_channel ON counting_milestones(channel_id);`,
+
+		// Counting one-off flags (e.g. milestones_seeded, so removed defaults stay removed)
+		`CREATE TABLE IF NOT EXISTS counting_settings (
+			key        TEXT PRIMARY KEY,
+			value      TEXT NOT NULL,
+			updated_at INTEGER NOT NULL
+		);`,
+
+		// Counting team competitions (timeboxed; one live event per channel)
+		`CREATE TABLE IF NOT EXISTS counting_events (
+			id               INTEGER PRIMARY KEY AUTOINCREMENT,
+			channel_id       TEXT NOT NULL,
+			name             TEXT NOT NULL,
+			starts_at        INTEGER NOT NULL,
+			ends_at          INTEGER NOT NULL,
+			ruin_penalty     INTEGER NOT NULL DEFAULT 0,
+			board_channel_id TEXT NOT NULL DEFAULT '',
+			board_message_id TEXT NOT NULL DEFAULT '',
+			ended            INTEGER NOT NULL DEFAULT 0,
+			created_by       TEXT NOT NULL DEFAULT '',
+			created_at       INTEGER NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_counting_events_channel ON counting_events(channel_id, ended);`,
+
+		`CREATE TABLE IF NOT EXISTS counting_event_teams (
+			id       INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_id INTEGER NOT NULL,
+			name     TEXT NOT NULL,
+			emoji    TEXT NOT NULL DEFAULT '',
+			role_id  TEXT NOT NULL DEFAULT ''
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_counting_event_teams_event ON counting_event_teams(event_id);`,
+
+		// Team membership + per-user event score (team totals are summed from here)
+		`CREATE TABLE IF NOT EXISTS counting_event_members (
+			event_id  INTEGER NOT NULL,
+			user_id   TEXT NOT NULL,
+			team_id   INTEGER NOT NULL,
+			username  TEXT NOT NULL DEFAULT '',
+			counts    INTEGER NOT NULL DEFAULT 0,
+			ruins     INTEGER NOT NULL DEFAULT 0,
+			points    INTEGER NOT NULL DEFAULT 0,
+			joined_at INTEGER NOT NULL,
+			PRIMARY KEY (event_id, user_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_counting_event_members_team ON counting_event_members(event_id, team_id);`,
+
 		// Counting punishments (temporary role on mess-up)
 		`CREATE TABLE IF NOT EXISTS counting_punishments (
 			guild_id   TEXT NOT NULL,
@@ -113,6 +233,168 @@ func Migrate(d *sql.DB) error {
 			joined_at INTEGER NOT NULL
 		);`,
 		`CREATE INDEX IF NOT EXISTS idx_user_joins_joined_at ON user_joins(joined_at);`,
+
+		// Levelling runtime settings set by slash commands (e.g. xp_curve JSON); overrides main.go defaults
+		`CREATE TABLE IF NOT EXISTS levelling_settings (
+			key        TEXT PRIMARY KEY,
+			value      TEXT NOT NULL,
+			updated_at INTEGER NOT NULL
+		);`,
+
+		// Timed global XP boosts (/xpboost); ended_at = 0 while running
+		`CREATE TABLE IF NOT EXISTS xp_boosts (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			multiplier  REAL NOT NULL,
+			reason      TEXT NOT NULL DEFAULT '',
+			started_by  TEXT NOT NULL DEFAULT '',
+			channel_id  TEXT NOT NULL DEFAULT '',
+			started_at  INTEGER NOT NULL,
+			ends_at     INTEGER NOT NULL,
+			ended_at    INTEGER NOT NULL DEFAULT 0
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_xp_boosts_open ON xp_boosts(ended_at, ends_at);`,
+
+		// Users/roles that never earn XP (/xpblock); adds to the main.go list
+		`CREATE TABLE IF NOT EXISTS xp_blocklist (
+			kind       TEXT NOT NULL, -- user | role
+			target_id  TEXT NOT NULL,
+			added_by   TEXT NOT NULL DEFAULT '',
+			added_at   INTEGER NOT NULL,
+			PRIMARY KEY (kind, target_id)
+		);`,
+
+		// Voice XP: open sessions (survive restarts) + lifetime totals per user
+		`CREATE TABLE IF NOT EXISTS voice_sessions (
+			user_id       TEXT PRIMARY KEY,
+			channel_id    TEXT NOT NULL,
+			joined_at     INTEGER NOT NULL,
+			last_tick_at  INTEGER NOT NULL,
+			carry_seconds INTEGER NOT NULL DEFAULT 0
+		);`,
+		`CREATE TABLE IF NOT EXISTS user_voice (
+			user_id    TEXT PRIMARY KEY,
+			minutes    INTEGER NOT NULL DEFAULT 0,
+			xp         INTEGER NOT NULL DEFAULT 0,
+			updated_at INTEGER NOT NULL
+		);`,
+
+		// Admin XP changes (/xp give|take|set|reset)
+		`CREATE TABLE IF NOT EXISTS xp_history (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id    TEXT NOT NULL,
+			action     TEXT NOT NULL, -- give | take | set | reset | season | import | import-rollback
+			delta      INTEGER NOT NULL,
+			xp_before  INTEGER NOT NULL,
+			xp_after   INTEGER NOT NULL,
+			actor_id   TEXT NOT NULL DEFAULT '',
+			reason     TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_xp_history_user ON xp_history(user_id, id);`,
+
+		// Every XP change (text/voice/admin) for weekly/monthly/custom leaderboards.
+		// Rows older than a few weeks are folded into xp_daily (day = UK midnight, unix).
+		`CREATE TABLE IF NOT EXISTS xp_events (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id    TEXT NOT NULL,
+			amount     INTEGER NOT NULL,
+			channel_id TEXT NOT NULL DEFAULT '',
+			source     TEXT NOT NULL, -- text | voice | admin
+			created_at INTEGER NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_xp_events_created ON xp_events(created_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_xp_events_user_created ON xp_events(user_id, created_at);`,
+		`CREATE TABLE IF NOT EXISTS xp_daily (
+			day     INTEGER NOT NULL,
+			user_id TEXT NOT NULL,
+			xp      INTEGER NOT NULL,
+			PRIMARY KEY (day, user_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_xp_daily_user ON xp_daily(user_id, day);`,
+
+		// XP seasons (/season); ended_at = 0 for the running one. Standings are archived at the end.
+		`CREATE TABLE IF NOT EXISTS xp_seasons (
+			id             INTEGER PRIMARY KEY AUTOINCREMENT,
+			name           TEXT NOT NULL DEFAULT '',
+			started_at     INTEGER NOT NULL,
+			ends_at        INTEGER NOT NULL,
+			ended_at       INTEGER NOT NULL DEFAULT 0,
+			channel_id     TEXT NOT NULL DEFAULT '',
+			top_n          INTEGER NOT NULL DEFAULT 0,
+			reward_role_id TEXT NOT NULL DEFAULT '',
+			keep_percent   INTEGER NOT NULL DEFAULT 0,
+			renew_days     INTEGER NOT NULL DEFAULT 0
+		);`,
+		`CREATE TABLE IF NOT EXISTS xp_season_standings (
+			season_id INTEGER NOT NULL,
+			user_id   TEXT NOT NULL,
+			position  INTEGER NOT NULL,
+			xp        INTEGER NOT NULL,
+			level     INTEGER NOT NULL,
+			PRIMARY KEY (season_id, user_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_xp_season_standings_pos ON xp_season_standings(season_id, position);`,
+
+		// /xpimport runs; each keeps the user_xp rows it touched as they were before, for /xpimport rollback
+		`CREATE TABLE IF NOT EXISTS xp_import_snapshots (
+			id             INTEGER PRIMARY KEY AUTOINCREMENT,
+			created_at     INTEGER NOT NULL,
+			actor_id       TEXT NOT NULL DEFAULT '',
+			source         TEXT NOT NULL DEFAULT '',
+			mode           TEXT NOT NULL DEFAULT '',
+			file_name      TEXT NOT NULL DEFAULT '',
+			users          INTEGER NOT NULL DEFAULT 0,
+			rolled_back_at INTEGER NOT NULL DEFAULT 0
+		);`,
+		`CREATE TABLE IF NOT EXISTS xp_import_snapshot_rows (
+			snapshot_id INTEGER NOT NULL,
+			user_id     TEXT NOT NULL,
+			had_row     INTEGER NOT NULL,
+			username    TEXT NOT NULL DEFAULT '',
+			xp          INTEGER NOT NULL DEFAULT 0,
+			last_xp_at  INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (snapshot_id, user_id)
+		);`,
+
+		// Members who turned off their level-up announcements (/levelnotify)
+		`CREATE TABLE IF NOT EXISTS levelup_notify_optout (
+			user_id    TEXT PRIMARY KEY,
+			created_at INTEGER NOT NULL
+		);`,
+
+		// Per-user /rankcard theme; colours are #RRGGBB ('' = default), bg_image is a PNG pre-cropped to the card size
+		`CREATE TABLE IF NOT EXISTS rank_card_themes (
+			user_id      TEXT PRIMARY KEY,
+			bg_color     TEXT NOT NULL DEFAULT '',
+			accent_color TEXT NOT NULL DEFAULT '',
+			text_color   TEXT NOT NULL DEFAULT '',
+			bg_image     BLOB,
+			updated_at   INTEGER NOT NULL
+		);`,
+
+		// Guild members kept by the member tracker (seeded from member chunks, then gateway events).
+		// roles is comma-separated role IDs; left_at = 0 while they're still in the server
+		`CREATE TABLE IF NOT EXISTS members (
+			id        TEXT PRIMARY KEY,
+			username  TEXT NOT NULL DEFAULT '',
+			nick      TEXT NOT NULL DEFAULT '',
+			roles     TEXT NOT NULL DEFAULT '',
+			joined_at INTEGER NOT NULL DEFAULT 0,
+			left_at   INTEGER NOT NULL DEFAULT 0
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_members_left ON members(left_at);`,
+
+		// One row per join seen by the member tracker (rejoins add rows). left_at/verified_at = 0 until
+		// they leave / finish onboarding (welcoming)
+		`CREATE TABLE IF NOT EXISTS member_joins (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id     TEXT NOT NULL,
+			joined_at   INTEGER NOT NULL,
+			left_at     INTEGER NOT NULL DEFAULT 0,
+			verified_at INTEGER NOT NULL DEFAULT 0
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_member_joins_user ON member_joins(user_id, left_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_member_joins_joined ON member_joins(joined_at);`,
 	}
 
 	for _, q := range stmts {
@@ -138,6 +420,13 @@ func Migrate(d *sql.DB) error {
 	if err := ensureColumn(d, "autoroles", "emoji_api", `ALTER TABLE autoroles ADD COLUMN emoji_api TEXT NOT NULL DEFAULT ''`); err != nil {
 		return err
 	}
+	// Which invite a join came through ('' = unknown: vanity URL, widget, or joined while the bot was offline)
+	if err := ensureColumn(d, "user_joins", "invite_code", `ALTER TABLE user_joins ADD COLUMN invite_code TEXT NOT NULL DEFAULT ''`); err != nil {
+		return err
+	}
+	if err := ensureColumn(d, "user_joins", "inviter_id", `ALTER TABLE user_joins ADD COLUMN inviter_id TEXT NOT NULL DEFAULT ''`); err != nil {
+		return err
+	}
 
 	// Rebuild old experimental schemas that included guild_id
 	if err := migrateUserXPToSingleServer(d); err != nil {
@@ -146,11 +435,15 @@ func Migrate(d *sql.DB) error {
 	if err := migrateLevelUpMessagesToSingleServer(d); err != nil {
 		return err
 	}
+	if err := ensureLevelUpMessagesFTS(d); err != nil {
+		return err
+	}
 
 	// Ensure indexes exist even after rebuilds
 	_, _ = d.Exec(`CREATE INDEX IF NOT EXISTS idx_user_xp_xp ON user_xp(xp DESC);`)
 	_, _ = d.Exec(`CREATE INDEX IF NOT EXISTS idx_level_up_messages_user ON level_up_messages(user_id);`)
 	_, _ = d.Exec(`CREATE INDEX IF NOT EXISTS idx_user_joins_joined_at ON user_joins(joined_at);`)
+	_, _ = d.Exec(`CREATE INDEX IF NOT EXISTS idx_user_joins_inviter ON user_joins(inviter_id, joined_at);`)
 	_, _ = d.Exec(`CREATE INDEX IF NOT EXISTS idx_counting_punishments_expires ON counting_punishments(expires_at);`)
 	_, _ = d.Exec(`CREATE INDEX IF NOT EXISTS idx_counting_user_stats_v2_counts ON counting_user_stats_v2(channel_id, counts DESC);`)
 	_, _ = d.Exec(`CREATE INDEX IF NOT EXISTS idx_counting_user_stats_v2_user ON counting_user_stats_v2(user_id);`)
@@ -259,6 +552,56 @@ func migrateUserXPToSingleServer(d *sql.DB) error {
 	return nil
 }
 
+// ensureLevelUpMessagesFTS sets up full-text search over saved level-up messages (/levelupmsgsearch).
+// It's a standalone FTS5 table kept in step by triggers rather than an external-content one:
+// saves use INSERT OR REPLACE, whose implicit delete doesn't fire delete triggers.
+func ensureLevelUpMessagesFTS(d *sql.DB) error {
+	stmts := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS level_up_messages_fts USING fts5(
+			user_id UNINDEXED,
+			level UNINDEXED,
+			content,
+			tokenize = 'unicode61 remove_diacritics 2'
+		);`,
+		`CREATE TRIGGER IF NOT EXISTS level_up_messages_fts_bi BEFORE INSERT ON level_up_messages BEGIN
+			DELETE FROM level_up_messages_fts WHERE user_id = NEW.user_id AND level = NEW.level;
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS level_up_messages_fts_ai AFTER INSERT ON level_up_messages BEGIN
+			INSERT INTO level_up_messages_fts(user_id, level, content) VALUES (NEW.user_id, NEW.level, NEW.content);
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS level_up_messages_fts_ad AFTER DELETE ON level_up_messages BEGIN
+			DELETE FROM level_up_messages_fts WHERE user_id = OLD.user_id AND level = OLD.level;
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS level_up_messages_fts_au AFTER UPDATE ON level_up_messages BEGIN
+			DELETE FROM level_up_messages_fts WHERE user_id = OLD.user_id AND level = OLD.level;
+			INSERT INTO level_up_messages_fts(user_id, level, content) VALUES (NEW.user_id, NEW.level, NEW.content);
+		END;`,
+	}
+	for _, q := range stmts {
+		if _, err := d.Exec(q); err != nil {
+			return err
+		}
+	}
+
+	// First run (or the table was rebuilt without its triggers): index everything again
+	var saved, indexed int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM level_up_messages`).Scan(&saved); err != nil {
+		return err
+	}
+	if err := d.QueryRow(`SELECT COUNT(*) FROM level_up_messages_fts`).Scan(&indexed); err != nil {
+		return err
+	}
+	if saved == indexed {
+		return nil
+	}
+	if _, err := d.Exec(`DELETE FROM level_up_messages_fts`); err != nil {
+		return err
+	}
+	_, err := d.Exec(`INSERT INTO level_up_messages_fts(user_id, level, content)
+		SELECT user_id, level, content FROM level_up_messages`)
+	return err
+}
+
 func migrateLevelUpMessagesToSingleServer(d *sql.DB) error {
 	hasGuild, err := hasColumn(d, "level_up_messages", "guild_id")
 	if err != nil {
@@ -0,0 +1,645 @@
+package counting
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// What editing/deleting a counted message does.
+const (
+	TamperIgnore = "ignore" // announce only (old behaviour)
+	TamperStrike = "strike" // record a strike; StrikeLimit strikes within StrikeWindow gives the ruined role
+	TamperRuin   = "ruin"   // ruin the count (only if the message is part of the current run, otherwise a strike)
+)
+
+const (
+	violationEdit        = "edit"
+	violationDelete      = "delete"
+	violationYoung       = "young_account"
+	violationMissingRole = "missing_role"
+
+	actionAnnounce = "announce"
+	actionStrike   = "strike"
+	actionRuin     = "ruin"
+	actionBlocked  = "blocked"
+
+	violationsPageSize  = 15
+	countingWebhookName = "AuraBot Counting"
+
+	// Don't DM a blocked user more than once per hour.
+	blockedDMCooldown = time.Hour
+)
+
+// AntiCheat configures counting tamper enforcement and who is allowed to count.
+// Passed in from cmd/bot/main.go; zero values disable each check.
+type AntiCheat struct {
+	EditAction string // TamperIgnore / TamperStrike / TamperRuin
+
+	// Deletes are checked against the audit log first: a moderator's delete, or one the log
+	// can't pin on the author, is only announced.
+	DeleteAction string
+
+	StrikeLimit  int
+	StrikeWindow time.Duration
+
+	// Repost the original number (as the original author, via webhook) after an edit/delete.
+	RepostTampered bool
+
+	MinAccountAge  time.Duration
+	RequiredRoleID string
+}
+
+type violation struct {
+	ID        int64
+	ChannelID string
+	UserID    string
+	Username  string
+	MessageID string
+	Kind      string
+	Action    string
+	Count     int64
+	Detail    string
+	Pardoned  bool
+	CreatedAt int64
+}
+
+/* =========================
+   DB helpers
+   ========================= */
+
+func (m *Module) recordViolation(v violation) {
+	if m.db == nil {
+		return
+	}
+	if v.CreatedAt == 0 {
+		v.CreatedAt = time.Now().Unix()
+	}
+	_, err := m.db.Exec(
+		`INSERT INTO counting_violations (channel_id, user_id, username, message_id, kind, action, count, detail, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		v.ChannelID, v.UserID, strings.TrimSpace(v.Username), v.MessageID, v.Kind, v.Action, v.Count, v.Detail, v.CreatedAt,
+	)
+	if err != nil {
+		log.Printf("[counting] record violation failed: %v", err)
+	}
+}
+
+// alreadyPunished reports whether messageID already cost its author a strike or a ruin.
+func (m *Module) alreadyPunished(messageID string) (bool, error) {
+	var found bool
+	err := m.db.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM counting_violations WHERE message_id = ? AND action IN (?, ?));`,
+		messageID, actionStrike, actionRuin,
+	).Scan(&found)
+	return found, err
+}
+
+// claimTamper reserves the one punishment a counted message can earn. False means it was
+// already punished (or is being right now), so re-edits and edit-then-delete are only announced.
+func (m *Module) claimTamper(messageID string) bool {
+	m.tamperMu.Lock()
+	defer m.tamperMu.Unlock()
+	if m.tamperInFlight[messageID] {
+		return false
+	}
+	done, err := m.alreadyPunished(messageID)
+	if err != nil {
+		log.Printf("[counting] tamper lookup failed: %v", err)
+		return false
+	}
+	if done {
+		return false
+	}
+	m.tamperInFlight[messageID] = true
+	return true
+}
+
+func (m *Module) releaseTamper(messageID string) {
+	m.tamperMu.Lock()
+	delete(m.tamperInFlight, messageID)
+	m.tamperMu.Unlock()
+}
+
+// activeStrikes counts un-pardoned strikes for a user inside the strike window.
+func (m *Module) activeStrikes(userID string) (int, error) {
+	since := int64(0)
+	if m.antiCheat.StrikeWindow > 0 {
+		since = time.Now().Add(-m.antiCheat.StrikeWindow).Unix()
+	}
+	var n int
+	err := m.db.QueryRow(
+		`SELECT COUNT(*) FROM counting_violations
+		 WHERE user_id = ? AND action = ? AND pardoned = 0 AND created_at >= ?;`,
+		userID, actionStrike, since,
+	).Scan(&n)
+	return n, err
+}
+
+func (m *Module) pardonStrikes(userID string) (int64, error) {
+	res, err := m.db.Exec(
+		`UPDATE counting_violations SET pardoned = 1
+		 WHERE user_id = ? AND action = ? AND pardoned = 0;`,
+		userID, actionStrike,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (m *Module) queryViolations(userID string, limit int) ([]violation, error) {
+	q := `SELECT id, channel_id, user_id, username, message_id, kind, action, count, detail, pardoned, created_at
+	      FROM counting_violations`
+	var args []any
+	if userID != "" {
+		q += ` WHERE user_id = ?`
+		args = append(args, userID)
+	}
+	q += ` ORDER BY id DESC LIMIT ?;`
+	args = append(args, limit)
+
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []violation
+	for rows.Next() {
+		var v violation
+		var pardoned int
+		if err := rows.Scan(&v.ID, &v.ChannelID, &v.UserID, &v.Username, &v.MessageID, &v.Kind, &v.Action, &v.Count, &v.Detail, &pardoned, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		v.Pardoned = pardoned != 0
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+// countedMessage looks up the accepted count stored for a message, if any.
+func (m *Module) countedMessage(channelID, messageID string) (historyRow, bool, error) {
+	var r historyRow
+	err := m.db.QueryRow(
+		`SELECT id, channel_id, count, user_id, username, message_id, event, note, created_at
+		 FROM counting_history
+		 WHERE channel_id = ? AND message_id = ? AND event = ?
+		 ORDER BY id DESC LIMIT 1;`,
+		channelID, messageID, historyEventCount,
+	).Scan(&r.ID, &r.ChannelID, &r.Count, &r.UserID, &r.Username, &r.MessageID, &r.Event, &r.Note, &r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return historyRow{}, false, nil
+	}
+	if err != nil {
+		return historyRow{}, false, err
+	}
+	return r, true, nil
+}
+
+// inCurrentRun reports whether a history row came after the channel's latest ruin.
+func (m *Module) inCurrentRun(r historyRow) (bool, error) {
+	var n int
+	err := m.db.QueryRow(
+		`SELECT COUNT(*) FROM counting_history
+		 WHERE channel_id = ? AND id > ? AND event = ?;`,
+		r.ChannelID, r.ID, historyEventRuin,
+	).Scan(&n)
+	return n == 0, err
+}
+
+// forceRuin resets a channel outside applyCount (tampering) and records the ruin.
+func (m *Module) forceRuin(channelID, userID, username, messageID, reason string) (int64, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var lastCount int64
+	err = tx.QueryRow(`SELECT last_count FROM counting_state WHERE channel_id = ?;`, channelID).Scan(&lastCount)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	if err := m.resetState(tx, channelID); err != nil {
+		return 0, err
+	}
+	if err := txInsertHistory(tx, historyRow{
+		ChannelID: channelID,
+		Count:     lastCount,
+		UserID:    userID,
+		Username:  username,
+		MessageID: messageID,
+		Event:     historyEventRuin,
+		Note:      reason,
+	}); err != nil {
+		return 0, err
+	}
+	return lastCount, tx.Commit()
+}
+
+func (m *Module) nextNumber(channelID string) int64 {
+	var lastCount int64
+	_ = m.db.QueryRow(`SELECT last_count FROM counting_state WHERE channel_id = ?;`, channelID).Scan(&lastCount)
+	return lastCount + 1
+}
+
+/* =========================
+   Tampering (edit / delete of a counted message)
+   ========================= */
+
+// tamperAction returns the configured action for an edit/delete.
+func (m *Module) tamperAction(kind string) string {
+	if kind == violationDelete {
+		return m.antiCheat.DeleteAction
+	}
+	return m.antiCheat.EditAction
+}
+
+// handleTamper applies action after a counted message was edited or deleted.
+// newContent is only set for edits.
+func (m *Module) handleTamper(s *discordgo.Session, guildID string, counted historyRow, kind, newContent, action string) {
+	verb := "edited"
+	if kind == violationDelete {
+		verb = "deleted"
+	}
+
+	detail := ""
+	if kind == violationEdit {
+		detail = "now: " + truncate(strings.TrimSpace(newContent), 200)
+	}
+
+	v := violation{
+		ChannelID: counted.ChannelID,
+		UserID:    counted.UserID,
+		Username:  counted.Username,
+		MessageID: counted.MessageID,
+		Kind:      kind,
+		Count:     counted.Count,
+		Detail:    detail,
+	}
+
+	if action != TamperIgnore {
+		if m.claimTamper(counted.MessageID) {
+			// The violation row is written by then, so later events find it in the DB
+			defer m.releaseTamper(counted.MessageID)
+		} else {
+			action = TamperIgnore
+		}
+	}
+
+	if action == TamperRuin {
+		// Same lock as live counting and catch-up, so a count landing now can't interleave with the reset
+		lock := m.channelLock(counted.ChannelID)
+		lock.Lock()
+		inRun, err := m.inCurrentRun(counted)
+		if err != nil {
+			log.Printf("[counting] tamper run check failed: %v", err)
+		}
+		var ruinedAt int64
+		if inRun {
+			ruinedAt, err = m.forceRuin(counted.ChannelID, counted.UserID, counted.Username, counted.MessageID, "Tampered with their count ("+verb+").")
+		}
+		lock.Unlock()
+
+		if inRun {
+			if err != nil {
+				log.Printf("[counting] tamper ruin failed: %v", err)
+				return
+			}
+			v.Action = actionRuin
+			m.recordViolation(v)
+
+			msg := fmt.Sprintf(
+				"<@%s> **RUINED IT AT %d!!**\nThey %s their count (**%d**). Next number is **1**.",
+				counted.UserID, ruinedAt, verb, counted.Count,
+			)
+			_, _ = s.ChannelMessageSend(counted.ChannelID, msg)
+			m.punish(s, guildID, counted.UserID)
+			m.scoreCompetition(s, guildID, counted.ChannelID, counted.UserID, counted.Username, nil, false)
+			m.repostOriginal(s, counted, verb)
+			return
+		}
+		// Old count from before the last ruin: nothing left to ruin, so it's a strike instead.
+		action = TamperStrike
+	}
+
+	next := m.nextNumber(counted.ChannelID)
+
+	if action == TamperStrike {
+		v.Action = actionStrike
+		m.recordViolation(v)
+
+		strikes, err := m.activeStrikes(counted.UserID)
+		if err != nil {
+			log.Printf("[counting] strike count failed: %v", err)
+		}
+
+		msg := fmt.Sprintf("<@%s> %s their count (**%d**).", counted.UserID, verb, counted.Count)
+		if m.antiCheat.StrikeLimit > 0 {
+			msg += fmt.Sprintf(" Strike **%d/%d**.", strikes, m.antiCheat.StrikeLimit)
+		} else {
+			msg += fmt.Sprintf(" Strike **%d**.", strikes)
+		}
+		if m.antiCheat.StrikeLimit > 0 && strikes >= m.antiCheat.StrikeLimit {
+			msg += " That's too many, enjoy the ruined role."
+			m.punish(s, guildID, counted.UserID)
+			_, _ = m.pardonStrikes(counted.UserID) // start fresh after the punishment
+		}
+		msg += fmt.Sprintf("\nThe next number is **%d**", next)
+		_, _ = s.ChannelMessageSend(counted.ChannelID, msg)
+		m.repostOriginal(s, counted, verb)
+		return
+	}
+
+	// TamperIgnore: log it, but only call out the latest count (old behaviour)
+	v.Action = actionAnnounce
+	m.recordViolation(v)
+
+	var lastMsgID string
+	_ = m.db.QueryRow(`SELECT last_message_id FROM counting_state WHERE channel_id = ?;`, counted.ChannelID).Scan(&lastMsgID)
+	if lastMsgID == "" || lastMsgID != counted.MessageID {
+		return
+	}
+
+	var msg string
+	if kind == violationDelete {
+		msg = fmt.Sprintf("<@%s> has deleted their count, the next number is **%d**.", counted.UserID, next)
+	} else {
+		msg = fmt.Sprintf(
+			"<@%s> has edited their count because they think it's funny.\nThe next number is **%d**",
+			counted.UserID, next,
+		)
+	}
+	_, _ = s.ChannelMessageSend(counted.ChannelID, msg)
+	m.repostOriginal(s, counted, verb)
+}
+
+// repostOriginal re-sends the original number under the counter's name/avatar so the channel still reads in order.
+func (m *Module) repostOriginal(s *discordgo.Session, counted historyRow, verb string) {
+	if !m.antiCheat.RepostTampered {
+		return
+	}
+
+	content := fmt.Sprintf("> **%d**\n-# original count, %s by its author", counted.Count, verb)
+
+	wh := m.countingWebhook(s, counted.ChannelID)
+	if wh != nil {
+		name := strings.TrimSpace(counted.Username)
+		avatar := ""
+		if u, err := s.User(counted.UserID); err == nil && u != nil {
+			if u.GlobalName != "" {
+				name = u.GlobalName
+			} else if u.Username != "" {
+				name = u.Username
+			}
+			avatar = u.AvatarURL("128")
+		}
+		if name == "" {
+			name = "Counter"
+		}
+
+		_, err := s.WebhookExecute(wh.ID, wh.Token, false, &discordgo.WebhookParams{
+			Content:         content,
+			Username:        truncate(name, 80),
+			AvatarURL:       avatar,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		if err == nil {
+			return
+		}
+		log.Printf("[counting] webhook repost failed (falling back): %v", err)
+	}
+
+	// No Manage Webhooks: plain quote from the bot instead
+	_, _ = s.ChannelMessageSendComplex(counted.ChannelID, &discordgo.MessageSend{
+		Content:         fmt.Sprintf("> **%d**\n-# original count by <@%s>, %s", counted.Count, counted.UserID, verb),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+}
+
+// countingWebhook finds (or creates) the bot's webhook in a counting channel. Cached per channel.
+func (m *Module) countingWebhook(s *discordgo.Session, channelID string) *discordgo.Webhook {
+	m.whMu.Lock()
+	defer m.whMu.Unlock()
+
+	if wh, ok := m.webhooks[channelID]; ok {
+		return wh
+	}
+
+	botID := ""
+	if s.State != nil && s.State.User != nil {
+		botID = s.State.User.ID
+	}
+
+	hooks, err := s.ChannelWebhooks(channelID)
+	if err == nil {
+		for _, wh := range hooks {
+			if wh == nil || wh.Name != countingWebhookName || wh.Token == "" {
+				continue
+			}
+			if botID != "" && wh.User != nil && wh.User.ID != botID {
+				continue
+			}
+			m.webhooks[channelID] = wh
+			return wh
+		}
+	}
+
+	wh, err := s.WebhookCreate(channelID, countingWebhookName, "")
+	if err != nil {
+		log.Printf("[counting] webhook create failed: %v", err)
+		return nil
+	}
+	m.webhooks[channelID] = wh
+	return wh
+}
+
+/* =========================
+   Who may count
+   ========================= */
+
+// countBlockReason returns a violation kind + human reason if the author may not count.
+func (m *Module) countBlockReason(s *discordgo.Session, e *discordgo.MessageCreate) (string, string) {
+	if m.antiCheat.MinAccountAge > 0 {
+		if created, err := discordgo.SnowflakeTimestamp(e.Author.ID); err == nil {
+			if time.Since(created) < m.antiCheat.MinAccountAge {
+				days := int(m.antiCheat.MinAccountAge.Hours() / 24)
+				return violationYoung, fmt.Sprintf("your account must be at least **%d days** old", days)
+			}
+		}
+	}
+
+	if m.antiCheat.RequiredRoleID != "" && e.GuildID != "" {
+		var roles []string
+		if e.Member != nil {
+			roles = e.Member.Roles
+		} else if r, ok := m.memberRoles(s, e.GuildID, e.Author.ID); ok {
+			roles = r
+		} else {
+			// Can't tell; don't block on an API failure.
+			return "", ""
+		}
+
+		for _, r := range roles {
+			if r == m.antiCheat.RequiredRoleID {
+				return "", ""
+			}
+		}
+		return violationMissingRole, "you need the Member role"
+	}
+
+	return "", ""
+}
+
+// blockCount removes a counting attempt from someone not allowed to count and tells them why (rate limited).
+func (m *Module) blockCount(s *discordgo.Session, e *discordgo.MessageCreate, n int64, kind, reason string) {
+	if err := s.ChannelMessageDelete(e.ChannelID, e.ID); err != nil {
+		_ = s.MessageReactionAdd(e.ChannelID, e.ID, reactBlocked)
+	}
+
+	var recent int
+	_ = m.db.QueryRow(
+		`SELECT COUNT(*) FROM counting_violations
+		 WHERE user_id = ? AND action = ? AND created_at >= ?;`,
+		e.Author.ID, actionBlocked, time.Now().Add(-blockedDMCooldown).Unix(),
+	).Scan(&recent)
+
+	m.recordViolation(violation{
+		ChannelID: e.ChannelID,
+		UserID:    e.Author.ID,
+		Username:  e.Author.Username,
+		MessageID: e.ID,
+		Kind:      kind,
+		Action:    actionBlocked,
+		Count:     n,
+		Detail:    truncate(strings.TrimSpace(e.Content), 200),
+	})
+
+	if recent > 0 {
+		return
+	}
+	ch, err := s.UserChannelCreate(e.Author.ID)
+	if err != nil {
+		return
+	}
+	_, _ = s.ChannelMessageSend(ch.ID, fmt.Sprintf("You can't count in <#%s> yet: %s.", e.ChannelID, reason))
+}
+
+/* =========================
+   /countingviolations list|clear (staff)
+   ========================= */
+
+func (m *Module) handleCountingViolations(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if !isStaff(i) {
+		respondEphemeral(s, i, "You need **Manage Server** to use this.")
+		return
+	}
+	if len(data.Options) == 0 || data.Options[0] == nil {
+		respondEphemeral(s, i, "Missing subcommand.")
+		return
+	}
+	sub := data.Options[0]
+
+	userID := ""
+	for _, opt := range sub.Options {
+		if opt != nil && opt.Name == "user" {
+			if u := opt.UserValue(nil); u != nil {
+				userID = u.ID
+			}
+		}
+	}
+
+	switch sub.Name {
+	case "list":
+		list, err := m.queryViolations(userID, violationsPageSize)
+		if err != nil {
+			log.Printf("[counting] violations query failed: %v", err)
+			respondEphemeral(s, i, "DB error reading violations.")
+			return
+		}
+		if len(list) == 0 {
+			respondEphemeral(s, i, "No counting violations recorded.")
+			return
+		}
+
+		var b strings.Builder
+		for _, v := range list {
+			b.WriteString(formatViolationLine(i.GuildID, v))
+			b.WriteString("\n")
+		}
+
+		title := "Counting violations"
+		if userID != "" {
+			strikes, _ := m.activeStrikes(userID)
+			title = fmt.Sprintf("Counting violations — %d active strike(s)", strikes)
+		}
+
+		embed := &discordgo.MessageEmbed{
+			Title:       title,
+			Description: truncate(b.String(), 4000),
+			Color:       0xED4245,
+			Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Latest %d", len(list))},
+			Timestamp:   time.Now().Format(time.RFC3339),
+		}
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:          []*discordgo.MessageEmbed{embed},
+				Flags:           discordgo.MessageFlagsEphemeral,
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+			},
+		})
+
+	case "clear":
+		if userID == "" {
+			respondEphemeral(s, i, "Pick a user.")
+			return
+		}
+		n, err := m.pardonStrikes(userID)
+		if err != nil {
+			log.Printf("[counting] pardon failed: %v", err)
+			respondEphemeral(s, i, "DB error clearing strikes.")
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("Cleared **%d** strike(s) for <@%s>.", n, userID))
+
+	default:
+		respondEphemeral(s, i, "Unknown subcommand.")
+	}
+}
+
+func formatViolationLine(guildID string, v violation) string {
+	num := fmt.Sprintf("**%d**", v.Count)
+	if guildID != "" && v.MessageID != "" && v.Kind == violationEdit {
+		num = fmt.Sprintf("[**%d**](https://discord.com/channels/%s/%s/%s)", v.Count, guildID, v.ChannelID, v.MessageID)
+	}
+
+	var what string
+	switch v.Kind {
+	case violationEdit:
+		what = "edited " + num
+	case violationDelete:
+		what = "deleted " + num
+	case violationYoung:
+		what = "blocked (young account) " + num
+	case violationMissingRole:
+		what = "blocked (no Member role) " + num
+	default:
+		what = v.Kind + " " + num
+	}
+
+	line := fmt.Sprintf("<@%s> %s in <#%s> → **%s** — <t:%d:R>", v.UserID, what, v.ChannelID, v.Action, v.CreatedAt)
+	if v.Pardoned {
+		line += " · pardoned"
+	}
+	if v.Detail != "" {
+		line += " · `" + strings.ReplaceAll(truncate(v.Detail, 60), "`", "'") + "`"
+	}
+	return line
+}
@@ -0,0 +1,244 @@
+package counting
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	catchUpPageSize = 100
+	// Safety cap so a very long outage can't replay forever.
+	catchUpMaxMessages = 5000
+)
+
+type catchUpSummary struct {
+	Attempts int
+	Counted  int
+	Ignored  int // grace duplicates
+	Ruins    []string
+}
+
+// channelLock serialises writes to a channel's count: live messages, catch-up replay, tamper
+// ruins, /countset and restores.
+func (m *Module) channelLock(channelID string) *sync.Mutex {
+	m.lockMu.Lock()
+	defer m.lockMu.Unlock()
+
+	l, ok := m.countLocks[channelID]
+	if !ok {
+		l = &sync.Mutex{}
+		m.countLocks[channelID] = l
+	}
+	return l
+}
+
+// alreadyApplied reports whether a message has already been through applyCount.
+func (m *Module) alreadyApplied(messageID string) bool {
+	if m.db == nil || messageID == "" {
+		return false
+	}
+	var one int
+	err := m.db.QueryRow(`SELECT 1 FROM counting_history WHERE message_id = ? LIMIT 1;`, messageID).Scan(&one)
+	return err == nil
+}
+
+// catchUpAnchor is the last message the bot is known to have processed in a channel.
+// last_message_id is cleared on ruins/sets, so fall back to the newest message in history.
+func (m *Module) catchUpAnchor(channelID string) (string, error) {
+	var lastMsgID string
+	err := m.db.QueryRow(`SELECT last_message_id FROM counting_state WHERE channel_id = ?;`, channelID).Scan(&lastMsgID)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+
+	var histMsgID string
+	err = m.db.QueryRow(
+		`SELECT message_id FROM counting_history
+		 WHERE channel_id = ? AND message_id != ''
+		 ORDER BY id DESC LIMIT 1;`,
+		channelID,
+	).Scan(&histMsgID)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+
+	// Whichever is newer (snowflakes sort by time)
+	if snowflakeLess(lastMsgID, histMsgID) {
+		return histMsgID, nil
+	}
+	return lastMsgID, nil
+}
+
+func snowflakeLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+func (m *Module) onReadyCatchUp(s *discordgo.Session, r *discordgo.Ready) {
+	if m.db == nil {
+		return
+	}
+	// Ready can arrive before Start on a fresh DB.
+	m.ensureDeleteTrackingSchema()
+
+	for _, channelID := range []string{m.countingChannelID, m.triosChannelID} {
+		if channelID == "" {
+			continue
+		}
+		m.catchUp(s, channelID)
+	}
+}
+
+// catchUp replays counting messages posted while the bot was offline, oldest first.
+func (m *Module) catchUp(s *discordgo.Session, channelID string) {
+	mode := m.channelMode(channelID)
+	if mode == modeDisabled {
+		return
+	}
+
+	after, err := m.catchUpAnchor(channelID)
+	if err != nil {
+		log.Printf("[counting] catch-up anchor failed: %v", err)
+		return
+	}
+	if after == "" {
+		return // nothing ever counted here; nothing to reconcile
+	}
+
+	guildID := m.guildID
+	if ch, err := s.State.Channel(channelID); err == nil && ch != nil {
+		guildID = ch.GuildID
+	} else if ch, err := s.Channel(channelID); err == nil && ch != nil {
+		guildID = ch.GuildID
+	}
+
+	var sum catchUpSummary
+	seen := 0
+
+	for seen < catchUpMaxMessages {
+		batch, err := s.ChannelMessages(channelID, catchUpPageSize, "", after, "")
+		if err != nil {
+			log.Printf("[counting] catch-up fetch failed: %v", err)
+			break
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		sort.Slice(batch, func(a, b int) bool { return snowflakeLess(batch[a].ID, batch[b].ID) })
+		after = batch[len(batch)-1].ID
+		seen += len(batch)
+
+		for _, msg := range batch {
+			if msg.GuildID == "" {
+				msg.GuildID = guildID
+			}
+			m.replayMessage(s, mode, msg, &sum)
+		}
+
+		if len(batch) < catchUpPageSize {
+			break
+		}
+	}
+
+	if sum.Attempts == 0 {
+		return
+	}
+
+	log.Printf("[counting] caught up %s: %d attempts, %d counted, %d ruins", channelID, sum.Attempts, sum.Counted, len(sum.Ruins))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔄 I was offline and caught up on **%d** counting message(s) in %s.\n", sum.Attempts, m.channelLabel(channelID))
+	fmt.Fprintf(&b, "✅ Counted: **%d**", sum.Counted)
+	if sum.Ignored > 0 {
+		fmt.Fprintf(&b, " · Ignored: **%d**", sum.Ignored)
+	}
+	if len(sum.Ruins) > 0 {
+		ruins := sum.Ruins
+		if len(ruins) > 10 {
+			ruins = append(ruins[:10:10], fmt.Sprintf("+%d more", len(sum.Ruins)-10))
+		}
+		fmt.Fprintf(&b, "\n💥 Ruins: %s", strings.Join(ruins, ", "))
+		if !m.catchUpPunish {
+			b.WriteString(" (no punishments while I was away)")
+		}
+	}
+	fmt.Fprintf(&b, "\nThe next number is **%d**.", m.nextNumber(channelID))
+
+	_, _ = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:         b.String(),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+}
+
+func (m *Module) replayMessage(s *discordgo.Session, mode channelMode, msg *discordgo.Message, sum *catchUpSummary) {
+	if msg == nil || msg.Author == nil || msg.Author.Bot || msg.WebhookID != "" {
+		return
+	}
+	n, ok := parseLeadingInt(msg.Content)
+	if !ok {
+		return
+	}
+	if m.alreadyApplied(msg.ID) || blockedByMe(msg) {
+		return
+	}
+
+	// Blocked accounts never count, so they aren't attempts for the summary either
+	if kind, _ := m.countBlockReason(s, &discordgo.MessageCreate{Message: msg}); kind != "" {
+		_ = s.MessageReactionAdd(msg.ChannelID, msg.ID, reactBlocked)
+		return
+	}
+
+	// Only the apply is serialised with live counting; a live message may have got here first.
+	lock := m.channelLock(msg.ChannelID)
+	lock.Lock()
+	if m.alreadyApplied(msg.ID) {
+		lock.Unlock()
+		return
+	}
+	res, err := m.applyCount(mode, msg.GuildID, msg.ChannelID, msg.Author.ID, msg.Author.Username, msg.ID, n)
+	lock.Unlock()
+	sum.Attempts++
+	if err != nil {
+		log.Printf("[counting] catch-up apply error: %v", err)
+		return
+	}
+
+	switch {
+	case res.Grace:
+		_ = s.MessageReactionAdd(msg.ChannelID, msg.ID, reactGrace)
+		sum.Ignored++
+
+	case res.OK:
+		m.onCounted(s, msg.GuildID, msg.ChannelID, msg.ID, msg.Author.ID, msg.Author.Username, memberRoles(msg.Member), res)
+		sum.Counted++
+
+	default:
+		_ = s.MessageReactionAdd(msg.ChannelID, msg.ID, reactBad)
+		if res.RuinedAt > 0 {
+			sum.Ruins = append(sum.Ruins, fmt.Sprintf("<@%s> at **%d**", msg.Author.ID, res.RuinedAt))
+			m.scoreCompetition(s, msg.GuildID, msg.ChannelID, msg.Author.ID, msg.Author.Username, memberRoles(msg.Member), false)
+		}
+		if m.catchUpPunish {
+			m.punish(s, msg.GuildID, msg.Author.ID)
+		}
+	}
+}
+
+// blockedByMe reports whether the bot already marked msg as blocked (the delete failed last time).
+func blockedByMe(msg *discordgo.Message) bool {
+	for _, r := range msg.Reactions {
+		if r != nil && r.Me && r.Emoji != nil && r.Emoji.Name == reactBlocked {
+			return true
+		}
+	}
+	return false
+}
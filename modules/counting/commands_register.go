@@ -1,7 +1,9 @@
 package counting
 
 import (
+	"fmt"
 	"log"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -18,13 +20,24 @@ func (m *Module) onReady(s *discordgo.Session, r *discordgo.Ready) {
 
 	guildID := m.guildID
 
-	_ = deleteCommandsByName(s, appID, guildID, "countingleaderboard")
-	_ = deleteCommandsByName(s, appID, guildID, "countinginfo")
-	_ = deleteCommandsByName(s, appID, guildID, "countscoreincrease")
-	if guildID != "" {
-		_ = deleteCommandsByName(s, appID, "", "countingleaderboard")
-		_ = deleteCommandsByName(s, appID, "", "countinginfo")
-		_ = deleteCommandsByName(s, appID, "", "countscoreincrease")
+	names := []string{
+		"countingleaderboard",
+		"countinginfo",
+		"countscoreincrease",
+		"countrestore",
+		"counthistory",
+		"countingmilestone",
+		"countinggoal",
+		"countinggraph",
+		"countingviolations",
+		"countingevent",
+		"countset",
+	}
+	for _, name := range names {
+		_ = deleteCommandsByName(s, appID, guildID, name)
+		if guildID != "" {
+			_ = deleteCommandsByName(s, appID, "", name)
+		}
 	}
 
 	_, err := s.ApplicationCommandCreate(appID, guildID, &discordgo.ApplicationCommand{
@@ -75,24 +88,393 @@ func (m *Module) onReady(s *discordgo.Session, r *discordgo.Ready) {
 				Required:    true,
 				MinValue:    func() *float64 { v := 1.0; return &v }(),
 			},
+			countingChannelOption("Which counting channel to apply this to (optional if you run it inside one)"),
+		},
+	})
+	if err != nil {
+		log.Printf("[counting] command create failed (countscoreincrease): %v", err)
+		return
+	}
+
+	// /countrestore number [channel]
+	_, err = s.ApplicationCommandCreate(appID, guildID, &discordgo.ApplicationCommand{
+		Name:        "countrestore",
+		Description: "Staff: rewind a counting channel to a previously accepted number",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "number",
+				Description: "Number to restore the count to",
+				Required:    true,
+				MinValue:    func() *float64 { v := 1.0; return &v }(),
+			},
+			countingChannelOption("Which counting channel to restore (optional if you run it inside one)"),
+		},
+	})
+	if err != nil {
+		log.Printf("[counting] command create failed (countrestore): %v", err)
+		return
+	}
+
+	// /counthistory [channel]
+	_, err = s.ApplicationCommandCreate(appID, guildID, &discordgo.ApplicationCommand{
+		Name:        "counthistory",
+		Description: "Browse every accepted count and ruin in a counting channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			countingChannelOption("Which counting channel to show (optional if you run it inside one)"),
+		},
+	})
+	if err != nil {
+		log.Printf("[counting] command create failed (counthistory): %v", err)
+		return
+	}
+
+	// /countingmilestone add|remove|list
+	_, err = s.ApplicationCommandCreate(appID, guildID, &discordgo.ApplicationCommand{
+		Name:        "countingmilestone",
+		Description: "Manage counting milestones (reactions, announcements, roles)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Staff: add a milestone at a number or every multiple of N",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "at",
+						Description: "Fire at exactly this number",
+						Required:    false,
+						MinValue:    func() *float64 { v := 1.0; return &v }(),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "every",
+						Description: "Fire at every multiple of this number",
+						Required:    false,
+						MinValue:    func() *float64 { v := 2.0; return &v }(),
+					},
+					countingChannelOption("Which counting channel (optional if you run it inside one)"),
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "emoji",
+						Description: "Reaction emoji (unicode or custom <:name:id>)",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "announce",
+						Description: "Post an announcement embed in the counting channel",
+						Required:    false,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionRole,
+						Name:        "role",
+						Description: "Role to grant the user who hits the milestone",
+						Required:    false,
+					},
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         "ping_channel",
+						Description:  "Also post the announcement in this channel",
+						Required:     false,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Staff: remove a milestone by id",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "id",
+						Description: "Milestone id (see /countingmilestone list)",
+						Required:    true,
+						MinValue:    func() *float64 { v := 1.0; return &v }(),
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List milestones for a counting channel",
+				Options: []*discordgo.ApplicationCommandOption{
+					countingChannelOption("Which counting channel (optional if you run it inside one)"),
+				},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("[counting] command create failed (countingmilestone): %v", err)
+		return
+	}
+
+	// /countinggoal set|clear|show
+	_, err = s.ApplicationCommandCreate(appID, guildID, &discordgo.ApplicationCommand{
+		Name:        "countinggoal",
+		Description: "Counting goal shown in the daily/weekly recaps",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "set",
+				Description: "Staff: set a goal, e.g. reach 5000 by Friday",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "target",
+						Description: "Number to reach",
+						Required:    true,
+						MinValue:    func() *float64 { v := 1.0; return &v }(),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "by",
+						Description: "Optional deadline date, YYYY-MM-DD (UK time)",
+						Required:    false,
+					},
+					countingChannelOption("Which counting channel (optional if you run it inside one)"),
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "clear",
+				Description: "Staff: remove the goal",
+				Options: []*discordgo.ApplicationCommandOption{
+					countingChannelOption("Which counting channel (optional if you run it inside one)"),
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Show progress towards the goal",
+				Options: []*discordgo.ApplicationCommandOption{
+					countingChannelOption("Which counting channel (optional if you run it inside one)"),
+				},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("[counting] command create failed (countinggoal): %v", err)
+		return
+	}
+
+	// /countinggraph [channel] [range]
+	_, err = s.ApplicationCommandCreate(appID, guildID, &discordgo.ApplicationCommand{
+		Name:        "countinggraph",
+		Description: "Chart the count over time (ruins marked) and the top contributors",
+		Options: []*discordgo.ApplicationCommandOption{
+			countingChannelOption("Which counting channel to chart (optional if you run it inside one)"),
 			{
 				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "channel",
-				Description: "Which counting channel to apply this to (optional if you run it inside one)",
+				Name:        "range",
+				Description: "How far back to chart (default: all time)",
 				Required:    false,
 				Choices: []*discordgo.ApplicationCommandOptionChoice{
-					{Name: "counting", Value: "counting"},
-					{Name: "counting-trios", Value: "counting-trios"},
+					{Name: "Last 24 hours", Value: "day"},
+					{Name: "Last 7 days", Value: "week"},
+					{Name: "Last month", Value: "month"},
+					{Name: "All time", Value: "all"},
 				},
 			},
 		},
 	})
 	if err != nil {
-		log.Printf("[counting] command create failed (countscoreincrease): %v", err)
+		log.Printf("[counting] command create failed (countinggraph): %v", err)
 		return
 	}
 
-	log.Println("[counting] registered /countingleaderboard, /countinginfo, /countscoreincrease")
+	// /countingviolations list|clear
+	_, err = s.ApplicationCommandCreate(appID, guildID, &discordgo.ApplicationCommand{
+		Name:        "countingviolations",
+		Description: "Staff: review counting anti-cheat logs (edits, deletes, blocked accounts)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Show the latest violations",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "Only show this user (also shows their active strikes)",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "clear",
+				Description: "Pardon a user's strikes",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "User to pardon",
+						Required:    true,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("[counting] command create failed (countingviolations): %v", err)
+		return
+	}
+
+	// /countingevent start|end|status (team competitions)
+	startOpts := []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "name",
+			Description: "Event name",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "hours",
+			Description: "How long the event runs",
+			Required:    true,
+			MinValue:    func() *float64 { v := 1.0; return &v }(),
+			MaxValue:    24 * 14,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "team1",
+			Description: "First team, e.g. \"🔴 Red\"",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "team2",
+			Description: "Second team, e.g. \"🔵 Blue\"",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "team3",
+			Description: "Optional third team",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "team4",
+			Description: "Optional fourth team",
+			Required:    false,
+		},
+	}
+	for n := 1; n <= 4; n++ {
+		startOpts = append(startOpts, &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionRole,
+			Name:        fmt.Sprintf("role%d", n),
+			Description: fmt.Sprintf("Optional role for team %d (holders join automatically; joiners get it)", n),
+			Required:    false,
+		})
+	}
+	startOpts = append(startOpts,
+		&discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "ruin_penalty",
+			Description: "Points a team loses per ruin (default 10)",
+			Required:    false,
+			MinValue:    func() *float64 { v := 0.0; return &v }(),
+		},
+		countingChannelOption("Which counting channel (optional if you run it inside one)"),
+		&discordgo.ApplicationCommandOption{
+			Type:         discordgo.ApplicationCommandOptionChannel,
+			Name:         "scoreboard_channel",
+			Description:  "Where to post the live scoreboard (default: here)",
+			Required:     false,
+			ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+		},
+	)
+
+	_, err = s.ApplicationCommandCreate(appID, guildID, &discordgo.ApplicationCommand{
+		Name:        "countingevent",
+		Description: "Team counting competitions",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "start",
+				Description: "Events: start a timeboxed team competition",
+				Options:     startOpts,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "end",
+				Description: "Events: end the running competition now",
+				Options: []*discordgo.ApplicationCommandOption{
+					countingChannelOption("Which counting channel (optional if you run it inside one)"),
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show the live scoreboard",
+				Options: []*discordgo.ApplicationCommandOption{
+					countingChannelOption("Which counting channel (optional if you run it inside one)"),
+				},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("[counting] command create failed (countingevent): %v", err)
+		return
+	}
+
+	// /countset <number> [channel] [last_user] [prev_user] [high_score]
+	_, err = s.ApplicationCommandCreate(appID, guildID, &discordgo.ApplicationCommand{
+		Name:        "countset",
+		Description: "Staff: set the current count directly (e.g. after downtime)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "number",
+				Description: "The last correct number (next number will be this + 1)",
+				Required:    true,
+				MinValue:    func() *float64 { v := 0.0; return &v }(),
+			},
+			countingChannelOption("Which counting channel to set (optional if you run it inside one)"),
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "last_user",
+				Description: "Who counted that number (they can't count next)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "prev_user",
+				Description: "Who counted before them (trios spacing)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "high_score",
+				Description: "Also set the channel high score to this number",
+				Required:    false,
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("[counting] command create failed (countset): %v", err)
+		return
+	}
+
+	log.Printf("[counting] registered /%s", strings.Join(names, ", /"))
+}
+
+// countingChannelOption is the shared optional "channel" picker (counting / counting-trios).
+func countingChannelOption(description string) *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "channel",
+		Description: description,
+		Required:    false,
+		Choices: []*discordgo.ApplicationCommandOptionChoice{
+			{Name: "counting", Value: "counting"},
+			{Name: "counting-trios", Value: "counting-trios"},
+		},
+	}
 }
 
 func deleteCommandsByName(s *discordgo.Session, appID, guildID, name string) error {
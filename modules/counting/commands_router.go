@@ -146,23 +146,8 @@ func (m *Module) onInteractionCreate(s *discordgo.Session, i *discordgo.Interact
 			// Decide which channel leaderboard to apply to:
 			// - If channel option provided: use that
 			// - Else: use current channel if it's a counting channel
-			targetChannelID := ""
-			switch channelChoice {
-			case "counting":
-				targetChannelID = m.countingChannelID
-			case "counting-trios":
-				targetChannelID = m.triosChannelID
-			case "":
-				// fallback to current channel if it's a counting channel
-				if m.channelMode(i.ChannelID) != modeDisabled {
-					targetChannelID = i.ChannelID
-				}
-			default:
-				// should never happen because choices restrict it, but be safe
-				targetChannelID = ""
-			}
-
-			if strings.TrimSpace(targetChannelID) == "" {
+			targetChannelID := m.resolveChannelChoice(channelChoice, i.ChannelID)
+			if targetChannelID == "" {
 				respondEphemeral(s, i, "Pick a channel option (counting / counting-trios), or run the command inside one of the counting channels.")
 				return
 			}
@@ -181,19 +166,80 @@ func (m *Module) onInteractionCreate(s *discordgo.Session, i *discordgo.Interact
 				return
 			}
 
-			which := "this channel"
-			if targetChannelID == m.countingChannelID {
-				which = "#counting"
-			} else if targetChannelID == m.triosChannelID {
-				which = "#counting-trios"
-			}
+			respondEphemeral(s, i, fmt.Sprintf("Added **%d** to <@%s> in %s’s counting leaderboard.", amount, targetUserID, m.channelLabel(targetChannelID)))
+
+		case "countrestore":
+			m.handleCountRestore(s, i, data)
+
+		case "counthistory":
+			m.handleCountHistory(s, i, data)
 
-			respondEphemeral(s, i, fmt.Sprintf("Added **%d** to <@%s> in %s’s counting leaderboard.", amount, targetUserID, which))
+		case "countingmilestone":
+			m.handleCountingMilestone(s, i, data)
+
+		case "countinggoal":
+			m.handleCountingGoal(s, i, data)
+
+		case "countinggraph":
+			m.handleCountingGraph(s, i, data)
+
+		case "countingviolations":
+			m.handleCountingViolations(s, i, data)
+
+		case "countingevent":
+			m.handleCountingEvent(s, i, data)
+
+		case "countset":
+			m.handleCountSet(s, i, data)
 		}
 
 	case discordgo.InteractionMessageComponent:
-		m.handleLeaderboardButtons(s, i)
+		cid := i.MessageComponentData().CustomID
+		switch {
+		case strings.HasPrefix(cid, histCustomBase+":"):
+			m.handleHistoryButtons(s, i)
+		case strings.HasPrefix(cid, teamCustomBase+":"):
+			m.handleTeamButton(s, i)
+		case strings.HasPrefix(cid, setCustomBase+":"):
+			m.handleCountSetButtons(s, i)
+		default:
+			m.handleLeaderboardButtons(s, i)
+		}
+	}
+}
+
+// resolveChannelChoice maps a "channel" option (counting / counting-trios) to a channel ID.
+// With no choice it falls back to the current channel if it's a counting channel.
+func (m *Module) resolveChannelChoice(choice, currentChannelID string) string {
+	switch choice {
+	case "counting":
+		return m.countingChannelID
+	case "counting-trios":
+		return m.triosChannelID
+	case "":
+		if m.channelMode(currentChannelID) != modeDisabled {
+			return currentChannelID
+		}
+	}
+	return ""
+}
+
+func (m *Module) channelLabel(channelID string) string {
+	switch channelID {
+	case m.countingChannelID:
+		return "#counting"
+	case m.triosChannelID:
+		return "#counting-trios"
+	}
+	return "this channel"
+}
+
+// isStaff requires Manage Server (or Administrator).
+func isStaff(i *discordgo.InteractionCreate) bool {
+	if i == nil || i.Member == nil {
+		return false
 	}
+	return i.Member.Permissions&(discordgo.PermissionManageGuild|discordgo.PermissionAdministrator) != 0
 }
 
 func interactionUserID(i *discordgo.InteractionCreate) string {
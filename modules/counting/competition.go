@@ -0,0 +1,837 @@
+package counting
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	teamCustomBase = "cteam" // cteam:<eventID>:<teamID>
+
+	maxEventTeams          = 4
+	defaultEventRuinPoints = 10
+
+	// Scoreboard edits are batched on this interval (Discord rate limits message edits).
+	scoreboardInterval = 15 * time.Second
+)
+
+type compEvent struct {
+	ID             int64
+	ChannelID      string
+	Name           string
+	StartsAt       int64
+	EndsAt         int64
+	RuinPenalty    int64
+	BoardChannelID string
+	BoardMessageID string
+	Ended          bool
+}
+
+type compTeam struct {
+	ID     int64
+	Name   string
+	Emoji  string
+	RoleID string
+}
+
+type teamStanding struct {
+	Team    compTeam
+	Points  int64
+	Counts  int64
+	Ruins   int64
+	Members int
+	MVP     string // user ID with most points
+}
+
+func (t compTeam) label() string {
+	if t.Emoji != "" {
+		return displayEmoji(t.Emoji) + " " + t.Name
+	}
+	return t.Name
+}
+
+/* =========================
+   DB helpers
+   ========================= */
+
+const compEventCols = `id, channel_id, name, starts_at, ends_at, ruin_penalty, board_channel_id, board_message_id, ended`
+
+func scanCompEvent(row interface{ Scan(...any) error }) (compEvent, error) {
+	var ev compEvent
+	var ended int
+	err := row.Scan(&ev.ID, &ev.ChannelID, &ev.Name, &ev.StartsAt, &ev.EndsAt, &ev.RuinPenalty, &ev.BoardChannelID, &ev.BoardMessageID, &ended)
+	ev.Ended = ended != 0
+	return ev, err
+}
+
+// activeEvent returns the running (not ended, not expired) event for a channel.
+func (m *Module) activeEvent(channelID string) (compEvent, bool, error) {
+	ev, err := scanCompEvent(m.db.QueryRow(
+		`SELECT `+compEventCols+`
+		 FROM counting_events
+		 WHERE channel_id = ? AND ended = 0 AND ends_at > ?
+		 ORDER BY id DESC LIMIT 1;`,
+		channelID, time.Now().Unix(),
+	))
+	if err == sql.ErrNoRows {
+		return compEvent{}, false, nil
+	}
+	if err != nil {
+		return compEvent{}, false, err
+	}
+	return ev, true, nil
+}
+
+func (m *Module) getEvent(eventID int64) (compEvent, error) {
+	return scanCompEvent(m.db.QueryRow(
+		`SELECT `+compEventCols+` FROM counting_events WHERE id = ?;`,
+		eventID,
+	))
+}
+
+func (m *Module) createEvent(ev compEvent, teams []compTeam, createdBy string) (int64, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.Exec(
+		`INSERT INTO counting_events (channel_id, name, starts_at, ends_at, ruin_penalty, board_channel_id, created_by, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?);`,
+		ev.ChannelID, ev.Name, ev.StartsAt, ev.EndsAt, ev.RuinPenalty, ev.BoardChannelID, createdBy, time.Now().Unix(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, t := range teams {
+		if _, err := tx.Exec(
+			`INSERT INTO counting_event_teams (event_id, name, emoji, role_id) VALUES (?, ?, ?, ?);`,
+			id, t.Name, t.Emoji, t.RoleID,
+		); err != nil {
+			return 0, err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+func (m *Module) eventTeams(eventID int64) ([]compTeam, error) {
+	rows, err := m.db.Query(
+		`SELECT id, name, emoji, role_id FROM counting_event_teams WHERE event_id = ? ORDER BY id;`,
+		eventID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []compTeam
+	for rows.Next() {
+		var t compTeam
+		if err := rows.Scan(&t.ID, &t.Name, &t.Emoji, &t.RoleID); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// memberTeam returns the team a user joined for an event (0 if none).
+func (m *Module) memberTeam(eventID int64, userID string) (int64, error) {
+	var teamID int64
+	err := m.db.QueryRow(
+		`SELECT team_id FROM counting_event_members WHERE event_id = ? AND user_id = ?;`,
+		eventID, userID,
+	).Scan(&teamID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return teamID, err
+}
+
+func (m *Module) joinTeam(eventID, teamID int64, userID, username string) error {
+	_, err := m.db.Exec(
+		`INSERT INTO counting_event_members (event_id, user_id, team_id, username, joined_at)
+		 VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT(event_id, user_id) DO NOTHING;`,
+		eventID, userID, teamID, strings.TrimSpace(username), time.Now().Unix(),
+	)
+	return err
+}
+
+func (m *Module) addEventScore(eventID int64, userID string, counts, ruins, points int64) error {
+	_, err := m.db.Exec(
+		`UPDATE counting_event_members
+		 SET counts = counts + ?, ruins = ruins + ?, points = points + ?
+		 WHERE event_id = ? AND user_id = ?;`,
+		counts, ruins, points, eventID, userID,
+	)
+	return err
+}
+
+// eventStandings returns every team (even empty ones) ordered by points.
+func (m *Module) eventStandings(eventID int64) ([]teamStanding, error) {
+	teams, err := m.eventTeams(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]*teamStanding, len(teams))
+	out := make([]teamStanding, len(teams))
+	for idx, t := range teams {
+		out[idx] = teamStanding{Team: t}
+		byID[t.ID] = &out[idx]
+	}
+
+	rows, err := m.db.Query(
+		`SELECT team_id, user_id, counts, ruins, points
+		 FROM counting_event_members
+		 WHERE event_id = ?
+		 ORDER BY points DESC, counts DESC;`,
+		eventID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var teamID, counts, ruins, points int64
+		var userID string
+		if err := rows.Scan(&teamID, &userID, &counts, &ruins, &points); err != nil {
+			return nil, err
+		}
+		st := byID[teamID]
+		if st == nil {
+			continue
+		}
+		st.Points += points
+		st.Counts += counts
+		st.Ruins += ruins
+		st.Members++
+		if st.MVP == "" && points > 0 {
+			st.MVP = userID // rows are already ordered by points
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(out, func(a, b int) bool { return out[a].Points > out[b].Points })
+	return out, nil
+}
+
+/* =========================
+   Scoring (called after applyCount)
+   ========================= */
+
+// scoreCompetition credits a correct count (+1) or charges a ruin (-penalty) to the user's team.
+// Users who haven't pressed a join button are placed by team role, if they have one.
+func (m *Module) scoreCompetition(s *discordgo.Session, guildID, channelID, userID, username string, roles []string, correct bool) {
+	if m.db == nil {
+		return
+	}
+	ev, ok, err := m.activeEvent(channelID)
+	if err != nil {
+		log.Printf("[counting] event lookup failed: %v", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	teamID, err := m.memberTeam(ev.ID, userID)
+	if err != nil {
+		log.Printf("[counting] event member lookup failed: %v", err)
+		return
+	}
+
+	if teamID == 0 {
+		teamID = m.teamFromRoles(s, guildID, ev.ID, userID, roles)
+		if teamID == 0 {
+			return // not competing
+		}
+		if err := m.joinTeam(ev.ID, teamID, userID, username); err != nil {
+			log.Printf("[counting] event auto-join failed: %v", err)
+			return
+		}
+	}
+
+	if correct {
+		err = m.addEventScore(ev.ID, userID, 1, 0, 1)
+	} else {
+		err = m.addEventScore(ev.ID, userID, 0, 1, -ev.RuinPenalty)
+	}
+	if err != nil {
+		log.Printf("[counting] event score failed: %v", err)
+		return
+	}
+
+	m.markScoreboardDirty(ev.ID)
+}
+
+func (m *Module) teamFromRoles(s *discordgo.Session, guildID string, eventID int64, userID string, roles []string) int64 {
+	teams, err := m.eventTeams(eventID)
+	if err != nil {
+		return 0
+	}
+
+	hasRoleTeam := false
+	for _, t := range teams {
+		if t.RoleID != "" {
+			hasRoleTeam = true
+			break
+		}
+	}
+	if !hasRoleTeam {
+		return 0
+	}
+
+	if roles == nil && guildID != "" {
+		roles, _ = m.memberRoles(s, guildID, userID)
+	}
+
+	for _, t := range teams {
+		if t.RoleID == "" {
+			continue
+		}
+		for _, r := range roles {
+			if r == t.RoleID {
+				return t.ID
+			}
+		}
+	}
+	return 0
+}
+
+func (m *Module) markScoreboardDirty(eventID int64) {
+	m.compMu.Lock()
+	m.compDirty[eventID] = true
+	m.compMu.Unlock()
+}
+
+/* =========================
+   Scoreboard + event lifecycle
+   ========================= */
+
+// tickCompetitions refreshes changed scoreboards and finishes expired events.
+func (m *Module) tickCompetitions(s *discordgo.Session) {
+	if m.db == nil {
+		return
+	}
+
+	m.compMu.Lock()
+	dirty := make([]int64, 0, len(m.compDirty))
+	for id := range m.compDirty {
+		dirty = append(dirty, id)
+	}
+	m.compDirty = make(map[int64]bool)
+	m.compMu.Unlock()
+
+	for _, id := range dirty {
+		ev, err := m.getEvent(id)
+		if err != nil || ev.Ended {
+			continue
+		}
+		m.refreshScoreboard(s, ev)
+	}
+
+	rows, err := m.db.Query(
+		`SELECT `+compEventCols+` FROM counting_events WHERE ended = 0 AND ends_at <= ?;`,
+		time.Now().Unix(),
+	)
+	if err != nil {
+		log.Printf("[counting] expired events query failed: %v", err)
+		return
+	}
+	var expired []compEvent
+	for rows.Next() {
+		ev, err := scanCompEvent(rows)
+		if err != nil {
+			log.Printf("[counting] expired events scan failed: %v", err)
+			break
+		}
+		expired = append(expired, ev)
+	}
+	rows.Close()
+
+	for _, ev := range expired {
+		m.finishEvent(s, ev)
+	}
+}
+
+func (m *Module) finishEvent(s *discordgo.Session, ev compEvent) {
+	res, err := m.db.Exec(`UPDATE counting_events SET ended = 1 WHERE id = ? AND ended = 0;`, ev.ID)
+	if err != nil {
+		log.Printf("[counting] end event failed: %v", err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return // already finished elsewhere
+	}
+	ev.Ended = true
+
+	m.refreshScoreboard(s, ev)
+
+	standings, err := m.eventStandings(ev.ID)
+	if err != nil || len(standings) == 0 {
+		return
+	}
+
+	var winners []string
+	for _, st := range standings {
+		if st.Points == standings[0].Points {
+			winners = append(winners, "**"+st.Team.label()+"**")
+		}
+	}
+
+	msg := fmt.Sprintf("🏁 **%s** is over! Winner: %s with **%d** points.", ev.Name, strings.Join(winners, " & "), standings[0].Points)
+	if len(winners) > 1 {
+		msg = fmt.Sprintf("🏁 **%s** is over! It's a tie between %s on **%d** points.", ev.Name, strings.Join(winners, " & "), standings[0].Points)
+	}
+
+	_, _ = s.ChannelMessageSendComplex(ev.ChannelID, &discordgo.MessageSend{
+		Content:         msg,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if ev.BoardChannelID != "" && ev.BoardChannelID != ev.ChannelID {
+		_, _ = s.ChannelMessageSendComplex(ev.BoardChannelID, &discordgo.MessageSend{
+			Content:         msg,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+	}
+}
+
+func (m *Module) refreshScoreboard(s *discordgo.Session, ev compEvent) {
+	if ev.BoardChannelID == "" || ev.BoardMessageID == "" {
+		return
+	}
+	embed, comps, err := m.buildScoreboard(ev)
+	if err != nil {
+		log.Printf("[counting] scoreboard build failed: %v", err)
+		return
+	}
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    ev.BoardChannelID,
+		ID:         ev.BoardMessageID,
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &comps,
+	})
+	if err != nil {
+		log.Printf("[counting] scoreboard edit failed: %v", err)
+	}
+}
+
+func (m *Module) buildScoreboard(ev compEvent) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	standings, err := m.eventStandings(ev.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	desc := fmt.Sprintf("Counting in <#%s> · correct count **+1** · ruin **-%d**\nEnds <t:%d:R>", ev.ChannelID, ev.RuinPenalty, ev.EndsAt)
+	if ev.Ended {
+		desc = fmt.Sprintf("Counting in <#%s> · ended <t:%d:R>", ev.ChannelID, ev.EndsAt)
+	}
+
+	medals := []string{"🥇", "🥈", "🥉"}
+	fields := make([]*discordgo.MessageEmbedField, 0, len(standings))
+	for idx, st := range standings {
+		prefix := ""
+		if idx < len(medals) && st.Points > 0 {
+			prefix = medals[idx] + " "
+		}
+		value := fmt.Sprintf("**%d** pts · %d counts · %d ruins · %d members", st.Points, st.Counts, st.Ruins, st.Members)
+		if st.MVP != "" {
+			value += fmt.Sprintf("\nMVP: <@%s>", st.MVP)
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  prefix + st.Team.label(),
+			Value: value,
+		})
+	}
+
+	title := "🏁 " + ev.Name
+	if ev.Ended {
+		title += " (final)"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: desc,
+		Color:       0xF1C40F,
+		Fields:      fields,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Press a button to join a team"},
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+
+	if ev.Ended {
+		embed.Footer = nil
+		return embed, []discordgo.MessageComponent{}, nil
+	}
+
+	// Join buttons in standings order would jump around; keep team order stable.
+	teams, err := m.eventTeams(ev.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	buttons := make([]discordgo.MessageComponent, 0, len(teams))
+	for _, t := range teams {
+		btn := discordgo.Button{
+			Label:    "Join " + t.Name,
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("%s:%d:%d", teamCustomBase, ev.ID, t.ID),
+		}
+		if t.Emoji != "" {
+			btn.Emoji = buttonEmoji(t.Emoji)
+		}
+		buttons = append(buttons, btn)
+	}
+
+	return embed, []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}, nil
+}
+
+// buttonEmoji turns a stored reaction emoji ("🔴" or "name:id") into a button emoji.
+func buttonEmoji(emoji string) *discordgo.ComponentEmoji {
+	if name, id, ok := strings.Cut(emoji, ":"); ok {
+		return &discordgo.ComponentEmoji{Name: name, ID: id}
+	}
+	return &discordgo.ComponentEmoji{Name: emoji}
+}
+
+/* =========================
+   Join button
+   ========================= */
+
+func (m *Module) handleTeamButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) != 3 {
+		return
+	}
+	eventID, err1 := strconv.ParseInt(parts[1], 10, 64)
+	teamID, err2 := strconv.ParseInt(parts[2], 10, 64)
+	if err1 != nil || err2 != nil {
+		return
+	}
+
+	userID := interactionUserID(i)
+	username := ""
+	if i.Member != nil && i.Member.User != nil {
+		username = i.Member.User.Username
+	}
+
+	ev, err := m.getEvent(eventID)
+	if err != nil {
+		respondEphemeral(s, i, "That event no longer exists.")
+		return
+	}
+	if ev.Ended || ev.EndsAt <= time.Now().Unix() {
+		respondEphemeral(s, i, "This event has ended.")
+		return
+	}
+
+	teams, err := m.eventTeams(eventID)
+	if err != nil {
+		respondEphemeral(s, i, "DB error reading teams.")
+		return
+	}
+	var team *compTeam
+	for idx := range teams {
+		if teams[idx].ID == teamID {
+			team = &teams[idx]
+		}
+	}
+	if team == nil {
+		respondEphemeral(s, i, "That team no longer exists.")
+		return
+	}
+
+	current, err := m.memberTeam(eventID, userID)
+	if err != nil {
+		respondEphemeral(s, i, "DB error joining team.")
+		return
+	}
+	if current != 0 {
+		for _, t := range teams {
+			if t.ID == current {
+				respondEphemeral(s, i, fmt.Sprintf("You're already on **%s** for this event.", t.label()))
+				return
+			}
+		}
+	}
+
+	if err := m.joinTeam(eventID, teamID, userID, username); err != nil {
+		log.Printf("[counting] team join failed: %v", err)
+		respondEphemeral(s, i, "DB error joining team.")
+		return
+	}
+	if team.RoleID != "" && i.GuildID != "" {
+		if err := s.GuildMemberRoleAdd(i.GuildID, userID, team.RoleID); err != nil {
+			log.Printf("[counting] team role add failed: %v", err)
+		}
+	}
+
+	m.markScoreboardDirty(eventID)
+	respondEphemeral(s, i, fmt.Sprintf("You joined **%s**! Count in <#%s> to score.", team.label(), ev.ChannelID))
+}
+
+/* =========================
+   /countingevent start|end|status
+   ========================= */
+
+func canRunEvents(i *discordgo.InteractionCreate) bool {
+	if isStaff(i) {
+		return true
+	}
+	return i != nil && i.Member != nil && i.Member.Permissions&discordgo.PermissionManageEvents != 0
+}
+
+func (m *Module) handleCountingEvent(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if len(data.Options) == 0 || data.Options[0] == nil {
+		respondEphemeral(s, i, "Missing subcommand.")
+		return
+	}
+	sub := data.Options[0]
+
+	switch sub.Name {
+	case "start":
+		m.handleEventStart(s, i, sub.Options)
+	case "end":
+		m.handleEventEnd(s, i, sub.Options)
+	case "status":
+		m.handleEventStatus(s, i, sub.Options)
+	default:
+		respondEphemeral(s, i, "Unknown subcommand.")
+	}
+}
+
+func (m *Module) handleEventStart(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	if !canRunEvents(i) {
+		respondEphemeral(s, i, "You need **Manage Events** or **Manage Server** to use this.")
+		return
+	}
+
+	name := ""
+	hours := int64(0)
+	penalty := int64(defaultEventRuinPoints)
+	channelChoice := ""
+	boardChannelID := i.ChannelID
+	teamNames := make([]string, maxEventTeams)
+	teamRoles := make([]string, maxEventTeams)
+
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		switch {
+		case opt.Name == "name":
+			name = strings.TrimSpace(opt.StringValue())
+		case opt.Name == "hours":
+			hours = opt.IntValue()
+		case opt.Name == "ruin_penalty":
+			penalty = opt.IntValue()
+		case opt.Name == "channel":
+			channelChoice = opt.StringValue()
+		case opt.Name == "scoreboard_channel":
+			if ch := opt.ChannelValue(nil); ch != nil {
+				boardChannelID = ch.ID
+			}
+		case strings.HasPrefix(opt.Name, "team") && len(opt.Name) == 5:
+			if n := int(opt.Name[4] - '1'); n >= 0 && n < maxEventTeams {
+				teamNames[n] = strings.TrimSpace(opt.StringValue())
+			}
+		case strings.HasPrefix(opt.Name, "role") && len(opt.Name) == 5:
+			if n := int(opt.Name[4] - '1'); n >= 0 && n < maxEventTeams {
+				if r := opt.RoleValue(nil, ""); r != nil {
+					teamRoles[n] = r.ID
+				}
+			}
+		}
+	}
+
+	channelID := m.resolveChannelChoice(channelChoice, i.ChannelID)
+	if channelID == "" {
+		respondEphemeral(s, i, "Pick a channel option (counting / counting-trios), or run the command inside one of the counting channels.")
+		return
+	}
+	if name == "" || hours <= 0 {
+		respondEphemeral(s, i, "Give the event a **name** and a duration in **hours**.")
+		return
+	}
+
+	var teams []compTeam
+	for n := 0; n < maxEventTeams; n++ {
+		if teamNames[n] == "" {
+			continue
+		}
+		t := parseTeamInput(teamNames[n])
+		t.RoleID = teamRoles[n]
+		teams = append(teams, t)
+	}
+	if len(teams) < 2 {
+		respondEphemeral(s, i, "An event needs at least **2 teams**.")
+		return
+	}
+
+	if _, ok, err := m.activeEvent(channelID); err != nil {
+		respondEphemeral(s, i, "DB error checking events.")
+		return
+	} else if ok {
+		respondEphemeral(s, i, fmt.Sprintf("%s already has an event running. End it first with `/countingevent end`.", m.channelLabel(channelID)))
+		return
+	}
+
+	now := time.Now()
+	ev := compEvent{
+		ChannelID:      channelID,
+		Name:           name,
+		StartsAt:       now.Unix(),
+		EndsAt:         now.Add(time.Duration(hours) * time.Hour).Unix(),
+		RuinPenalty:    penalty,
+		BoardChannelID: boardChannelID,
+	}
+	id, err := m.createEvent(ev, teams, interactionUserID(i))
+	if err != nil {
+		log.Printf("[counting] create event failed: %v", err)
+		respondEphemeral(s, i, "DB error creating the event.")
+		return
+	}
+	ev.ID = id
+
+	embed, comps, err := m.buildScoreboard(ev)
+	if err != nil {
+		respondEphemeral(s, i, "DB error building the scoreboard.")
+		return
+	}
+	board, err := s.ChannelMessageSendComplex(boardChannelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		Components:      comps,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		log.Printf("[counting] scoreboard send failed: %v", err)
+		respondEphemeral(s, i, "Event created, but I couldn't post the scoreboard in that channel.")
+		return
+	}
+	_, _ = m.db.Exec(`UPDATE counting_events SET board_message_id = ? WHERE id = ?;`, board.ID, id)
+	_ = s.ChannelMessagePin(boardChannelID, board.ID)
+
+	if boardChannelID != channelID {
+		_, _ = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+			Content:         fmt.Sprintf("🏁 **%s** has started! Join a team here: https://discord.com/channels/%s/%s/%s", name, i.GuildID, boardChannelID, board.ID),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+	}
+
+	respondEphemeral(s, i, fmt.Sprintf("Started **%s** in %s, ending <t:%d:R>.", name, m.channelLabel(channelID), ev.EndsAt))
+}
+
+func (m *Module) handleEventEnd(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	if !canRunEvents(i) {
+		respondEphemeral(s, i, "You need **Manage Events** or **Manage Server** to use this.")
+		return
+	}
+
+	channelChoice := ""
+	for _, opt := range opts {
+		if opt != nil && opt.Name == "channel" {
+			channelChoice = opt.StringValue()
+		}
+	}
+	channelID := m.resolveChannelChoice(channelChoice, i.ChannelID)
+	if channelID == "" {
+		respondEphemeral(s, i, "Pick a channel option (counting / counting-trios), or run the command inside one of the counting channels.")
+		return
+	}
+
+	ev, ok, err := m.activeEvent(channelID)
+	if err != nil {
+		respondEphemeral(s, i, "DB error checking events.")
+		return
+	}
+	if !ok {
+		respondEphemeral(s, i, fmt.Sprintf("No event is running in %s.", m.channelLabel(channelID)))
+		return
+	}
+
+	ev.EndsAt = time.Now().Unix()
+	_, _ = m.db.Exec(`UPDATE counting_events SET ends_at = ? WHERE id = ?;`, ev.EndsAt, ev.ID)
+	m.finishEvent(s, ev)
+
+	respondEphemeral(s, i, fmt.Sprintf("Ended **%s**.", ev.Name))
+}
+
+func (m *Module) handleEventStatus(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	channelChoice := ""
+	for _, opt := range opts {
+		if opt != nil && opt.Name == "channel" {
+			channelChoice = opt.StringValue()
+		}
+	}
+	channelID := m.resolveChannelChoice(channelChoice, i.ChannelID)
+	if channelID == "" {
+		respondEphemeral(s, i, "Pick a channel option (counting / counting-trios), or run the command inside one of the counting channels.")
+		return
+	}
+
+	ev, ok, err := m.activeEvent(channelID)
+	if err != nil {
+		respondEphemeral(s, i, "DB error checking events.")
+		return
+	}
+	if !ok {
+		respondEphemeral(s, i, fmt.Sprintf("No event is running in %s.", m.channelLabel(channelID)))
+		return
+	}
+
+	embed, comps, err := m.buildScoreboard(ev)
+	if err != nil {
+		respondEphemeral(s, i, "DB error building the scoreboard.")
+		return
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          []*discordgo.MessageEmbed{embed},
+			Components:      comps,
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// parseTeamInput splits "🔴 Red" / "<:red:123> Red" into emoji + name.
+func parseTeamInput(input string) compTeam {
+	input = strings.TrimSpace(input)
+	first, rest, found := strings.Cut(input, " ")
+	if found {
+		if emoji, err := parseReactionEmoji(first); err == nil && !isPlainWord(first) {
+			return compTeam{Name: truncate(strings.TrimSpace(rest), 60), Emoji: emoji}
+		}
+	}
+	return compTeam{Name: truncate(input, 60)}
+}
+
+func isPlainWord(s string) bool {
+	for _, r := range s {
+		if r > 0x7F {
+			return false
+		}
+		if r == '<' || r == ':' {
+			return false
+		}
+	}
+	return true
+}
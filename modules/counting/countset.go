@@ -0,0 +1,289 @@
+package counting
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// cset:<ownerID>:<n|t>:<number>:<lastUserID>:<prevUserID>:<0|1 high score>:<ok|no>
+const setCustomBase = "cset"
+
+type countSetRequest struct {
+	ChannelID string
+	Number    int64
+	LastUser  string
+	PrevUser  string
+	HighScore bool
+}
+
+func (m *Module) channelKey(channelID string) string {
+	if channelID == m.triosChannelID {
+		return "t"
+	}
+	return "n"
+}
+
+func (m *Module) channelFromKey(key string) string {
+	switch key {
+	case "n":
+		return m.countingChannelID
+	case "t":
+		return m.triosChannelID
+	}
+	return ""
+}
+
+func (m *Module) setCustomID(ownerID string, req countSetRequest, action string) string {
+	return fmt.Sprintf("%s:%s:%s:%d:%s:%s:%d:%s",
+		setCustomBase, ownerID, m.channelKey(req.ChannelID), req.Number, req.LastUser, req.PrevUser, boolToInt(req.HighScore), action)
+}
+
+// setCountState overwrites counting_state (and optionally the high score) and logs it to counting_history.
+// Returns the count it replaced.
+func (m *Module) setCountState(req countSetRequest, staffID, staffName string) (int64, error) {
+	if m.db == nil {
+		return 0, sql.ErrConnDone
+	}
+
+	// Same lock as live counting, so a count landing now can't be overwritten by /countset
+	lock := m.channelLock(req.ChannelID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var was int64
+	err = tx.QueryRow(`SELECT last_count FROM counting_state WHERE channel_id = ?;`, req.ChannelID).Scan(&was)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	now := time.Now().Unix()
+
+	// last_message_id is cleared: the "latest count" message is no longer known.
+	_, err = tx.Exec(
+		`INSERT INTO counting_state (channel_id, last_count, last_user_id, prev_user_id, updated_at, last_message_id)
+		 VALUES (?, ?, ?, ?, ?, '')
+		 ON CONFLICT(channel_id) DO UPDATE SET
+			last_count = excluded.last_count,
+			last_user_id = excluded.last_user_id,
+			prev_user_id = excluded.prev_user_id,
+			updated_at = excluded.updated_at,
+			last_message_id = '';`,
+		req.ChannelID, req.Number, req.LastUser, req.PrevUser, now,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if req.HighScore {
+		_, err = tx.Exec(
+			`INSERT INTO counting_channel_stats (channel_id, high_score, high_score_at, total_counted)
+			 VALUES (?, ?, ?, 0)
+			 ON CONFLICT(channel_id) DO UPDATE SET
+				high_score = excluded.high_score,
+				high_score_at = excluded.high_score_at;`,
+			req.ChannelID, req.Number, now,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	note := fmt.Sprintf("was %d", was)
+	if req.LastUser != "" {
+		note += fmt.Sprintf("; last counter <@%s>", req.LastUser)
+	}
+	if req.HighScore {
+		note += "; high score set"
+	}
+
+	if err := txInsertHistory(tx, historyRow{
+		ChannelID: req.ChannelID,
+		Count:     req.Number,
+		UserID:    staffID,
+		Username:  staffName,
+		Event:     historyEventSet,
+		Note:      note,
+		CreatedAt: now,
+	}); err != nil {
+		return 0, err
+	}
+
+	return was, tx.Commit()
+}
+
+/* =========================
+   /countset (staff)
+   ========================= */
+
+func (m *Module) handleCountSet(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if !isStaff(i) {
+		respondEphemeral(s, i, "You need **Manage Server** to use this.")
+		return
+	}
+
+	req := countSetRequest{Number: -1}
+	channelChoice := ""
+	for _, opt := range data.Options {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "number":
+			req.Number = opt.IntValue()
+		case "channel":
+			if v, ok := opt.Value.(string); ok {
+				channelChoice = v
+			}
+		case "last_user":
+			if u := opt.UserValue(nil); u != nil {
+				req.LastUser = u.ID
+			}
+		case "prev_user":
+			if u := opt.UserValue(nil); u != nil {
+				req.PrevUser = u.ID
+			}
+		case "high_score":
+			req.HighScore = opt.BoolValue()
+		}
+	}
+
+	if req.Number < 0 {
+		respondEphemeral(s, i, "Number must be **0 or higher**.")
+		return
+	}
+
+	req.ChannelID = m.resolveChannelChoice(channelChoice, i.ChannelID)
+	if req.ChannelID == "" {
+		respondEphemeral(s, i, "Pick a channel option (counting / counting-trios), or run the command inside one of the counting channels.")
+		return
+	}
+
+	var curCount int64
+	var curLast, curPrev string
+	err := m.db.QueryRow(
+		`SELECT last_count, last_user_id, prev_user_id FROM counting_state WHERE channel_id = ?;`,
+		req.ChannelID,
+	).Scan(&curCount, &curLast, &curPrev)
+	if err != nil && err != sql.ErrNoRows {
+		respondEphemeral(s, i, "DB error reading counting state.")
+		return
+	}
+
+	var curHigh int64
+	_ = m.db.QueryRow(`SELECT high_score FROM counting_channel_stats WHERE channel_id = ?;`, req.ChannelID).Scan(&curHigh)
+
+	userOrNone := func(id string) string {
+		if id == "" {
+			return "none"
+		}
+		return "<@" + id + ">"
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "Count", Value: fmt.Sprintf("**%d** → **%d** (next number **%d**)", curCount, req.Number, req.Number+1), Inline: false},
+		{Name: "Last counter", Value: fmt.Sprintf("%s → %s", userOrNone(curLast), userOrNone(req.LastUser)), Inline: true},
+		{Name: "Previous counter", Value: fmt.Sprintf("%s → %s", userOrNone(curPrev), userOrNone(req.PrevUser)), Inline: true},
+	}
+	if req.HighScore {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "High score",
+			Value: fmt.Sprintf("**%d** → **%d**", curHigh, req.Number),
+		})
+	}
+
+	ownerID := interactionUserID(i)
+	embed := &discordgo.MessageEmbed{
+		Title:       "Set counting state — " + m.channelLabel(req.ChannelID),
+		Description: "Check this before confirming; it overwrites the channel's state.",
+		Color:       0xE67E22,
+		Fields:      fields,
+	}
+	comps := []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "Confirm", Style: discordgo.DangerButton, CustomID: m.setCustomID(ownerID, req, "ok")},
+		discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: m.setCustomID(ownerID, req, "no")},
+	}}}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          []*discordgo.MessageEmbed{embed},
+			Components:      comps,
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+func (m *Module) handleCountSetButtons(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) != 8 || parts[0] != setCustomBase {
+		return
+	}
+
+	ownerID := parts[1]
+	clicker := interactionUserID(i)
+	if clicker == "" || clicker != ownerID {
+		respondEphemeral(s, i, "Only the person who ran this command can use these buttons.")
+		return
+	}
+
+	update := func(content string) {
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:    content,
+				Embeds:     []*discordgo.MessageEmbed{},
+				Components: []discordgo.MessageComponent{},
+			},
+		})
+	}
+
+	if parts[7] != "ok" {
+		update("Cancelled, nothing was changed.")
+		return
+	}
+
+	number, err := strconv.ParseInt(parts[3], 10, 64)
+	req := countSetRequest{
+		ChannelID: m.channelFromKey(parts[2]),
+		Number:    number,
+		LastUser:  parts[4],
+		PrevUser:  parts[5],
+		HighScore: parts[6] == "1",
+	}
+	if err != nil || req.ChannelID == "" {
+		update("That request is no longer valid.")
+		return
+	}
+
+	staffName := ""
+	if i.Member != nil && i.Member.User != nil {
+		staffName = i.Member.User.Username
+	}
+
+	was, err := m.setCountState(req, clicker, staffName)
+	if err != nil {
+		log.Printf("[counting] countset db error: %v", err)
+		update("DB error setting the count.")
+		return
+	}
+
+	_, _ = s.ChannelMessageSendComplex(req.ChannelID, &discordgo.MessageSend{
+		Content:         fmt.Sprintf("🛠️ Staff set the count to **%d**.\nThe next number is **%d**.", req.Number, req.Number+1),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+
+	update(fmt.Sprintf("Set %s from **%d** to **%d**.", m.channelLabel(req.ChannelID), was, req.Number))
+}
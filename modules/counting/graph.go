@@ -0,0 +1,471 @@
+package counting
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	graphWidth    = 900
+	graphHeight   = 420
+	graphMarginL  = 70
+	graphMarginR  = 24
+	graphMarginT  = 44
+	graphMarginB  = 40
+	graphYTicks   = 5
+	graphXTicks   = 5
+	graphTopUsers = 10
+	graphBarRowH  = 28
+	graphBarNameW = 160
+
+	graphCountFile   = "counting-graph.png"
+	graphContribFile = "counting-contributors.png"
+)
+
+var (
+	graphBG   = color.RGBA{0x2B, 0x2D, 0x31, 0xFF}
+	graphGrid = color.RGBA{0x3F, 0x42, 0x48, 0xFF}
+	graphAxis = color.RGBA{0x99, 0xAA, 0xB5, 0xFF}
+	graphText = color.RGBA{0xDC, 0xDD, 0xDE, 0xFF}
+	graphLine = color.RGBA{0x58, 0x65, 0xF2, 0xFF}
+	graphRuin = color.RGBA{0xED, 0x42, 0x45, 0xFF}
+	graphBar  = color.RGBA{0x2E, 0xCC, 0x71, 0xFF}
+)
+
+// graphPoint is one step of the count-over-time line. Ruin points mark where
+// the count was lost; the line drops to 0 straight after them.
+type graphPoint struct {
+	At    int64
+	Count int64
+	Ruin  bool
+}
+
+/* =========================
+   Data
+   ========================= */
+
+func (m *Module) queryGraphPoints(channelID string, since int64) ([]graphPoint, error) {
+	rows, err := m.db.Query(
+		`SELECT created_at, count, event
+		 FROM counting_history
+		 WHERE channel_id = ? AND created_at >= ? AND event IN (?, ?, ?, ?)
+		 ORDER BY id;`,
+		channelID, since, historyEventCount, historyEventRuin, historyEventRestore, historyEventSet,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []graphPoint
+	for rows.Next() {
+		var at, count int64
+		var event string
+		if err := rows.Scan(&at, &count, &event); err != nil {
+			return nil, err
+		}
+		if event == historyEventRuin {
+			if count <= 0 {
+				continue // nothing was lost
+			}
+			out = append(out, graphPoint{At: at, Count: count, Ruin: true})
+			out = append(out, graphPoint{At: at, Count: 0})
+			continue
+		}
+		out = append(out, graphPoint{At: at, Count: count})
+	}
+	return out, rows.Err()
+}
+
+// queryGraphContributors is the top counters in channelID since since, from the same history
+// as the count line so both charts cover the same range.
+func (m *Module) queryGraphContributors(channelID string, since int64, limit int) ([]lbRow, error) {
+	rows, err := m.db.Query(
+		`SELECT user_id, MAX(username), COUNT(*) AS counts
+		 FROM counting_history
+		 WHERE channel_id = ? AND created_at >= ? AND event = ? AND user_id <> ''
+		 GROUP BY user_id
+		 ORDER BY counts DESC, MAX(id) DESC
+		 LIMIT ?;`,
+		channelID, since, historyEventCount, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []lbRow
+	for rows.Next() {
+		var r lbRow
+		if err := rows.Scan(&r.UserID, &r.Username, &r.Counts); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func graphRangeStart(now time.Time, r string) int64 {
+	switch r {
+	case "day":
+		return now.Add(-24 * time.Hour).Unix()
+	case "week":
+		return now.AddDate(0, 0, -7).Unix()
+	case "month":
+		return now.AddDate(0, -1, 0).Unix()
+	default:
+		return 0
+	}
+}
+
+/* =========================
+   /countinggraph
+   ========================= */
+
+func (m *Module) handleCountingGraph(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	channelChoice := ""
+	rangeOpt := "all"
+	for _, opt := range data.Options {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "channel":
+			if v, ok := opt.Value.(string); ok {
+				channelChoice = v
+			}
+		case "range":
+			if v, ok := opt.Value.(string); ok && v != "" {
+				rangeOpt = v
+			}
+		}
+	}
+
+	channelID := m.resolveChannelChoice(channelChoice, i.ChannelID)
+	if channelID == "" {
+		respondEphemeral(s, i, "Pick a channel option (counting / counting-trios), or run the command inside one of the counting channels.")
+		return
+	}
+
+	// Rendering + big history reads can take a moment
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	editText := func(msg string) {
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+	}
+
+	since := graphRangeStart(time.Now(), rangeOpt)
+	points, err := m.queryGraphPoints(channelID, since)
+	if err != nil {
+		log.Printf("[counting] graph query failed: %v", err)
+		editText("DB error reading counting history.")
+		return
+	}
+	if len(points) == 0 {
+		editText("No counting history for this range yet.")
+		return
+	}
+
+	title := fmt.Sprintf("%s - count over time (%s)", m.channelLabel(channelID), rangeOpt)
+	countPNG, err := renderCountChart(points, title)
+	if err != nil {
+		log.Printf("[counting] graph render failed: %v", err)
+		editText("Failed to render the graph.")
+		return
+	}
+
+	files := []*discordgo.File{{Name: graphCountFile, ContentType: "image/png", Reader: bytes.NewReader(countPNG)}}
+	embeds := []*discordgo.MessageEmbed{{
+		Title: "📈 Counting graph — " + m.channelLabel(channelID),
+		Color: 0x5865F2,
+		Image: &discordgo.MessageEmbedImage{URL: "attachment://" + graphCountFile},
+	}}
+
+	rows, err := m.queryGraphContributors(channelID, since, graphTopUsers)
+	if err != nil {
+		log.Printf("[counting] contributor query failed: %v", err)
+	}
+	if err == nil && len(rows) > 0 {
+		contribPNG, err := renderContribChart(rows, fmt.Sprintf("%s - top contributors (%s)", m.channelLabel(channelID), rangeOpt))
+		if err == nil {
+			files = append(files, &discordgo.File{Name: graphContribFile, ContentType: "image/png", Reader: bytes.NewReader(contribPNG)})
+			embeds = append(embeds, &discordgo.MessageEmbed{
+				Color: 0x2ECC71,
+				Image: &discordgo.MessageEmbedImage{URL: "attachment://" + graphContribFile},
+			})
+		} else {
+			log.Printf("[counting] contributor chart render failed: %v", err)
+		}
+	}
+
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &embeds,
+		Files:  files,
+	})
+	if err != nil {
+		log.Printf("[counting] graph response failed: %v", err)
+	}
+}
+
+/* =========================
+   Rendering (pure Go)
+   ========================= */
+
+func renderCountChart(points []graphPoint, title string) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, graphWidth, graphHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(graphBG), image.Point{}, draw.Src)
+
+	minT, maxT := points[0].At, points[0].At
+	maxY := int64(1)
+	for _, p := range points {
+		if p.At < minT {
+			minT = p.At
+		}
+		if p.At > maxT {
+			maxT = p.At
+		}
+		if p.Count > maxY {
+			maxY = p.Count
+		}
+	}
+	if maxT == minT {
+		maxT = minT + 1
+	}
+
+	step := niceStep(maxY, graphYTicks)
+	maxY = ((maxY + step - 1) / step) * step
+
+	plotL, plotR := graphMarginL, graphWidth-graphMarginR
+	plotT, plotB := graphMarginT, graphHeight-graphMarginB
+
+	xOf := func(t int64) int {
+		return plotL + int(float64(t-minT)/float64(maxT-minT)*float64(plotR-plotL))
+	}
+	yOf := func(v int64) int {
+		return plotB - int(float64(v)/float64(maxY)*float64(plotB-plotT))
+	}
+
+	// Grid + Y labels
+	for v := int64(0); v <= maxY; v += step {
+		y := yOf(v)
+		drawLine(img, plotL, y, plotR, y, graphGrid)
+		label := fmt.Sprintf("%d", v)
+		drawText(img, plotL-8-textWidth(label), y+4, label, graphAxis)
+	}
+
+	// X labels
+	span := time.Duration(maxT-minT) * time.Second
+	layout := "02 Jan"
+	switch {
+	case span < 36*time.Hour:
+		layout = "15:04"
+	case span < 7*24*time.Hour:
+		layout = "02 Jan 15:04"
+	}
+	for k := 0; k <= graphXTicks; k++ {
+		t := minT + (maxT-minT)*int64(k)/graphXTicks
+		x := xOf(t)
+		label := time.Unix(t, 0).In(recapLocation()).Format(layout)
+		drawLine(img, x, plotB, x, plotB+4, graphAxis)
+		drawText(img, x-textWidth(label)/2, plotB+18, label, graphAxis)
+	}
+
+	// Axes
+	drawLine(img, plotL, plotT, plotL, plotB, graphAxis)
+	drawLine(img, plotL, plotB, plotR, plotB, graphAxis)
+
+	// Count line
+	prevX, prevY := xOf(points[0].At), yOf(points[0].Count)
+	for _, p := range points[1:] {
+		x, y := xOf(p.At), yOf(p.Count)
+		drawThickLine(img, prevX, prevY, x, y, graphLine)
+		prevX, prevY = x, y
+	}
+
+	// Ruin markers on top
+	ruins := 0
+	for _, p := range points {
+		if p.Ruin {
+			fillCircle(img, xOf(p.At), yOf(p.Count), 4, graphRuin)
+			ruins++
+		}
+	}
+
+	drawText(img, plotL, 24, asciiOnly(title), graphText)
+	legend := fmt.Sprintf("ruins: %d", ruins)
+	fillCircle(img, plotR-textWidth(legend)-12, 20, 4, graphRuin)
+	drawText(img, plotR-textWidth(legend), 24, legend, graphText)
+
+	return encodePNG(img)
+}
+
+func renderContribChart(rows []lbRow, title string) ([]byte, error) {
+	h := graphMarginT + len(rows)*graphBarRowH + graphMarginB/2
+	img := image.NewRGBA(image.Rect(0, 0, graphWidth, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(graphBG), image.Point{}, draw.Src)
+
+	drawText(img, graphMarginR, 24, asciiOnly(title), graphText)
+
+	maxCount := int64(1)
+	for _, r := range rows {
+		if r.Counts > maxCount {
+			maxCount = r.Counts
+		}
+	}
+
+	barL := graphMarginR + graphBarNameW
+	barMaxW := graphWidth - graphMarginR - barL - 70
+
+	for idx, r := range rows {
+		top := graphMarginT + idx*graphBarRowH
+
+		name := strings.TrimSpace(r.Username)
+		if name == "" {
+			name = r.UserID
+		}
+		name = asciiOnly(name)
+		if len(name) > 20 {
+			name = name[:19] + "~"
+		}
+		drawText(img, graphMarginR, top+16, fmt.Sprintf("%d. %s", idx+1, name), graphText)
+
+		w := int(float64(r.Counts) / float64(maxCount) * float64(barMaxW))
+		if w < 2 {
+			w = 2
+		}
+		fillRect(img, image.Rect(barL, top+4, barL+w, top+graphBarRowH-6), graphBar)
+		drawText(img, barL+w+8, top+16, fmt.Sprintf("%d", r.Counts), graphAxis)
+	}
+
+	return encodePNG(img)
+}
+
+// niceStep picks a 1/2/5×10ⁿ tick step so there are roughly `ticks` gridlines up to max.
+func niceStep(max int64, ticks int) int64 {
+	if max <= 0 || ticks <= 0 {
+		return 1
+	}
+	raw := max / int64(ticks)
+	if raw < 1 {
+		return 1
+	}
+	mag := int64(1)
+	for mag*10 <= raw {
+		mag *= 10
+	}
+	switch {
+	case raw <= mag:
+		return mag
+	case raw <= 2*mag:
+		return 2 * mag
+	case raw <= 5*mag:
+		return 5 * mag
+	default:
+		return 10 * mag
+	}
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawText(img draw.Image, x, y int, s string, c color.Color) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
+
+func textWidth(s string) int {
+	return font.MeasureString(basicfont.Face7x13, s).Round()
+}
+
+// asciiOnly replaces characters basicfont can't draw.
+func asciiOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= 0x20 && r < 0x7F {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+func fillRect(img draw.Image, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func fillCircle(img *image.RGBA, cx, cy, r int, c color.Color) {
+	for dy := -r; dy <= r; dy++ {
+		for dx := -r; dx <= r; dx++ {
+			if dx*dx+dy*dy <= r*r {
+				img.Set(cx+dx, cy+dy, c)
+			}
+		}
+	}
+}
+
+// drawLine is a plain Bresenham line.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func drawThickLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	drawLine(img, x0, y0, x1, y1, c)
+	drawLine(img, x0, y0+1, x1, y1+1, c)
+	drawLine(img, x0+1, y0, x1+1, y1, c)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
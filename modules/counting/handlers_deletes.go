@@ -1,30 +1,76 @@
 package counting
 
 import (
-	"fmt"
 	"log"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
+// Who deleted a counted message, as far as the audit log can tell.
+const (
+	deletedByAuthor = iota
+	deletedByModerator
+	deletedByUnknown
+)
+
+const (
+	// Discord writes the audit log entry just after the delete event
+	auditLogDelay = 2 * time.Second
+
+	// Entries read per look; a full page that doesn't reach back to the last look may have missed one
+	auditLogPage = 50
+
+	// A moderator delete seen while checking another one is claimable for this long
+	auditLogRecent = 5 * time.Minute
+
+	// Entries older than this are dropped from the snapshot
+	auditLogKeep = 24 * time.Hour
+)
+
+// deleteAudit is the message-delete audit log as last seen. Discord only logs deletes of someone
+// else's message, and folds repeats by the same moderator into one entry with a higher count, so
+// a moderator delete shows up as a new entry, or a count that went up, since the last look.
+type deleteAudit struct {
+	// Held across the GuildAuditLog fetch so deletes are diffed one at a time
+	mu      sync.Mutex
+	guildID string
+	ready   bool
+	takenAt time.Time
+	counts  map[string]int // entry ID -> count
+
+	// Entries don't say which message they were for, only whose and where: moderator deletes
+	// seen beyond the one being checked wait here for the next delete of that author's count
+	// in that channel.
+	unclaimed map[string]auditCredit
+}
+
+type auditCredit struct {
+	n  int
+	at time.Time
+}
+
 func (m *Module) onMessageDelete(s *discordgo.Session, e *discordgo.MessageDelete) {
 	if e == nil {
 		return
 	}
-	m.handleDeletedMessage(s, e.GuildID, e.ChannelID, e.ID)
+	m.handleDeletedMessage(s, e.GuildID, e.ChannelID, e.ID, m.tamperAction(violationDelete))
 }
 
 func (m *Module) onMessageDeleteBulk(s *discordgo.Session, e *discordgo.MessageDeleteBulk) {
 	if e == nil {
 		return
 	}
+	// Only staff/bots can bulk delete, so treat it as cleanup: log + announce, never punish.
 	for _, id := range e.Messages {
-		m.handleDeletedMessage(s, e.GuildID, e.ChannelID, id)
+		m.handleDeletedMessage(s, e.GuildID, e.ChannelID, id, TamperIgnore)
 	}
 }
 
-func (m *Module) handleDeletedMessage(s *discordgo.Session, guildID, channelID, messageID string) {
+func (m *Module) handleDeletedMessage(s *discordgo.Session, guildID, channelID, messageID, action string) {
 	// Only act in the 2 counting channels
 	if m.channelMode(channelID) == modeDisabled {
 		return
@@ -33,33 +79,183 @@ func (m *Module) handleDeletedMessage(s *discordgo.Session, guildID, channelID,
 		return
 	}
 
-	var lastCount int64
-	var lastUserID string
-	var lastMsgID string
-
-	err := m.db.QueryRow(
-		`SELECT last_count, last_user_id, last_message_id
-		 FROM counting_state
-		 WHERE channel_id = ?;`,
-		channelID,
-	).Scan(&lastCount, &lastUserID, &lastMsgID)
-
+	// Only accepted counts matter; action decides what happens.
+	counted, ok, err := m.countedMessage(channelID, messageID)
 	if err != nil {
-		// no rows / schema mismatch / etc -> ignore
+		log.Printf("[counting] delete lookup failed: %v", err)
+		return
+	}
+	if !ok || counted.UserID == "" {
 		return
 	}
 
-	if lastMsgID == "" || lastMsgID != messageID {
+	if action == TamperIgnore {
+		m.handleTamper(s, guildID, counted, violationDelete, "", action)
 		return
 	}
 
-	next := lastCount + 1
-	if lastUserID == "" {
+	// The delete event doesn't say who deleted it: only punish when the audit log shows nobody
+	// else did, since moderator cleanup is never the author's fault. The entry is written just
+	// after the event, so wait for it off the gateway goroutine.
+	time.AfterFunc(auditLogDelay, func() {
+		if m.deletedBy(s, guildID, channelID, counted.UserID) != deletedByAuthor {
+			action = TamperIgnore
+		}
+		m.handleTamper(s, guildID, counted, violationDelete, "", action)
+	})
+}
+
+// onReadyAudit takes the audit log baseline, so the first delete after a restart has something
+// to compare against.
+func (m *Module) onReadyAudit(s *discordgo.Session, r *discordgo.Ready) {
+	if m.guildID == "" || m.tamperAction(violationDelete) == TamperIgnore {
+		return
+	}
+	entries, err := fetchDeleteAudit(s, m.guildID)
+	if err != nil {
+		log.Printf("[counting] read audit log failed (needs View Audit Log): %v", err)
 		return
 	}
 
-	msg := fmt.Sprintf("<@%s> has deleted their count, the next number is **%d**.", lastUserID, next)
-	_, _ = s.ChannelMessageSend(channelID, msg)
+	a := &m.audit
+	a.mu.Lock()
+	a.reset(m.guildID, entries, time.Now())
+	a.mu.Unlock()
+}
+
+// deletedBy checks the audit log for a moderator deleting authorID's message in channelID.
+func (m *Module) deletedBy(s *discordgo.Session, guildID, channelID, authorID string) int {
+	if guildID == "" {
+		return deletedByUnknown
+	}
+
+	a := &m.audit
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries, err := fetchDeleteAudit(s, guildID)
+	if err != nil {
+		log.Printf("[counting] read audit log failed (needs View Audit Log): %v", err)
+		return deletedByUnknown
+	}
+
+	now := time.Now()
+	if !a.ready || a.guildID != guildID {
+		// Nothing to compare against yet
+		a.reset(guildID, entries, now)
+		return deletedByUnknown
+	}
+
+	botID := ""
+	if s.State != nil && s.State.User != nil {
+		botID = s.State.User.ID
+	}
+	return a.diff(entries, botID, auditKey(authorID, channelID), now)
+}
+
+// diff compares entries with the snapshot, credits every moderator delete since, merges them
+// into the snapshot, and says who deleted the count keyed by key. Anything it can't be sure of
+// is deletedByUnknown.
+func (a *deleteAudit) diff(entries []*discordgo.AuditLogEntry, botID, key string, now time.Time) int {
+	ambiguous := false
+	for _, e := range entries {
+		if e == nil || e.Options == nil || e.UserID == botID {
+			continue // the bot's own deletes are blocked counts, never counted ones
+		}
+		count, _ := strconv.Atoi(e.Options.Count)
+		k := auditKey(e.TargetID, e.Options.ChannelID)
+
+		prev, seen := a.counts[e.ID]
+		switch {
+		case seen:
+			if count > prev {
+				a.credit(k, count-prev, now)
+			}
+		case entryTime(e.ID).After(a.takenAt):
+			a.credit(k, count, now)
+		case k == key:
+			// Older than the last look but not in it: it may or may not have just been bumped
+			ambiguous = true
+		}
+	}
+	if len(entries) >= auditLogPage && entryTime(entries[len(entries)-1].ID).After(a.takenAt) {
+		ambiguous = true
+	}
+	a.merge(entries, now)
+
+	if c, ok := a.unclaimed[key]; ok {
+		if c.n <= 1 {
+			delete(a.unclaimed, key)
+		} else {
+			c.n--
+			a.unclaimed[key] = c
+		}
+		return deletedByModerator
+	}
+	if ambiguous {
+		return deletedByUnknown
+	}
+	return deletedByAuthor
+}
+
+// reset takes entries as a fresh snapshot.
+func (a *deleteAudit) reset(guildID string, entries []*discordgo.AuditLogEntry, now time.Time) {
+	a.guildID = guildID
+	a.counts = make(map[string]int, len(entries))
+	a.unclaimed = map[string]auditCredit{}
+	a.merge(entries, now)
+	a.ready = true
+}
+
+// merge adds entries to the snapshot; entries that fell off the page are kept until auditLogKeep.
+func (a *deleteAudit) merge(entries []*discordgo.AuditLogEntry, now time.Time) {
+	for _, e := range entries {
+		if e == nil || e.Options == nil {
+			continue
+		}
+		count, _ := strconv.Atoi(e.Options.Count)
+		a.counts[e.ID] = count
+	}
+	for id := range a.counts {
+		if now.Sub(entryTime(id)) > auditLogKeep {
+			delete(a.counts, id)
+		}
+	}
+	for k, c := range a.unclaimed {
+		if now.Sub(c.at) > auditLogRecent {
+			delete(a.unclaimed, k)
+		}
+	}
+	a.takenAt = now
+}
+
+func (a *deleteAudit) credit(key string, n int, now time.Time) {
+	c := a.unclaimed[key]
+	a.unclaimed[key] = auditCredit{n: c.n + n, at: now}
+}
+
+func fetchDeleteAudit(s *discordgo.Session, guildID string) ([]*discordgo.AuditLogEntry, error) {
+	audit, err := s.GuildAuditLog(guildID, "", "", int(discordgo.AuditLogActionMessageDelete), auditLogPage)
+	if err != nil {
+		return nil, err
+	}
+	if audit == nil {
+		return nil, nil
+	}
+	return audit.AuditLogEntries, nil
+}
+
+func auditKey(authorID, channelID string) string {
+	return authorID + ":" + channelID
+}
+
+// entryTime is when an audit entry was created; zero if the ID isn't a snowflake.
+func entryTime(id string) time.Time {
+	t, err := discordgo.SnowflakeTimestamp(id)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
 }
 
 // Adds counting_state.last_message_id if missing (required for delete announcements).
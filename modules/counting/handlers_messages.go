@@ -11,7 +11,7 @@ func (m *Module) onMessageCreate(s *discordgo.Session, e *discordgo.MessageCreat
 	if e == nil || e.Message == nil || e.Author == nil {
 		return
 	}
-	if e.Author.Bot {
+	if e.Author.Bot || e.WebhookID != "" {
 		return
 	}
 
@@ -26,36 +26,35 @@ func (m *Module) onMessageCreate(s *discordgo.Session, e *discordgo.MessageCreat
 		return
 	}
 
+	// Alt / unverified accounts can't count at all
+	if kind, reason := m.countBlockReason(s, e); kind != "" {
+		m.blockCount(s, e, n, kind, reason)
+		return
+	}
+
+	// Serialised with catch-up replay; skip anything catch-up already handled.
+	lock := m.channelLock(e.ChannelID)
+	lock.Lock()
+	if m.alreadyApplied(e.ID) {
+		lock.Unlock()
+		return
+	}
 	res, err := m.applyCount(mode, e.GuildID, e.ChannelID, e.Author.ID, e.Author.Username, e.ID, n)
+	lock.Unlock()
 	if err != nil {
 		log.Printf("[counting] apply error: %v", err)
 		_ = s.MessageReactionAdd(e.ChannelID, e.ID, reactBad)
 		return
 	}
 
-	if res.OK {
-		// ✅ normal vs ☑️ high score
-		if res.HighScore {
-			_ = s.MessageReactionAdd(e.ChannelID, e.ID, reactHighScore)
-		} else {
-			_ = s.MessageReactionAdd(e.ChannelID, e.ID, reactOK)
-		}
-
-		// 💯 at 100
-		if res.Count == 100 {
-			_ = s.MessageReactionAdd(e.ChannelID, e.ID, reactHundred)
-		}
-
-		// custom milestone emojis
-		switch res.Count {
-		case 200:
-			_ = s.MessageReactionAdd(e.ChannelID, e.ID, emoji200)
-		case 500:
-			_ = s.MessageReactionAdd(e.ChannelID, e.ID, emoji500)
-		case 1000:
-			_ = s.MessageReactionAdd(e.ChannelID, e.ID, emoji1000)
-		}
+	if res.Grace {
+		// Honest race with the previous counter; neither ruined nor counted.
+		_ = s.MessageReactionAdd(e.ChannelID, e.ID, reactGrace)
+		return
+	}
 
+	if res.OK {
+		m.onCounted(s, e.GuildID, e.ChannelID, e.ID, e.Author.ID, e.Author.Username, memberRoles(e.Member), res)
 		return
 	}
 
@@ -63,6 +62,8 @@ func (m *Module) onMessageCreate(s *discordgo.Session, e *discordgo.MessageCreat
 
 	// Announce and punish
 	if res.RuinedAt > 0 {
+		m.scoreCompetition(s, e.GuildID, e.ChannelID, e.Author.ID, e.Author.Username, memberRoles(e.Member), false)
+
 		// Custom reaction for specific user
 		if e.Author.ID == customRuinerUserID {
 			msg := fmt.Sprintf(
@@ -87,9 +88,25 @@ func (m *Module) onMessageCreate(s *discordgo.Session, e *discordgo.MessageCreat
 	m.punish(s, e.GuildID, e.Author.ID)
 }
 
+// onCounted reacts to an accepted count and runs everything that hangs off it.
+func (m *Module) onCounted(s *discordgo.Session, guildID, channelID, messageID, userID, username string, roles []string, res applyResult) {
+	// ✅ normal vs ☑️ high score
+	if res.HighScore {
+		_ = s.MessageReactionAdd(channelID, messageID, reactHighScore)
+	} else {
+		_ = s.MessageReactionAdd(channelID, messageID, reactOK)
+	}
+
+	// Configured milestones (/countingmilestone)
+	m.applyMilestones(s, guildID, channelID, messageID, userID, res.Count)
+
+	// Team competition point (if an event is running)
+	m.scoreCompetition(s, guildID, channelID, userID, username, roles, true)
+}
+
 // If a message is edited in a counting channel:
+// - If it was an accepted count and the number changed, apply the anti-cheat edit action.
 // - If it becomes a number (e.g. "hello" -> "27"), announce it and remind the next number.
-// - If it is the latest count message, also announce that they edited the count.
 func (m *Module) onMessageUpdate(s *discordgo.Session, e *discordgo.MessageUpdate) {
 	if e == nil {
 		return
@@ -106,39 +123,28 @@ func (m *Module) onMessageUpdate(s *discordgo.Session, e *discordgo.MessageUpdat
 	if err != nil || msg == nil || msg.Author == nil {
 		return
 	}
-	if msg.Author.Bot {
+	if msg.Author.Bot || msg.WebhookID != "" {
 		return
 	}
 
-	// Only care if the edited message NOW starts with a number
-	editedNum, ok := parseLeadingInt(msg.Content)
-	if !ok {
-		return
-	}
+	editedNum, isNum := parseLeadingInt(msg.Content)
 
-	// Read current channel state so we can say what the next number is
-	var lastCount int64
-	var lastMsgID string
-	err = m.db.QueryRow(
-		`SELECT last_count, last_message_id
-		 FROM counting_state
-		 WHERE channel_id = ?;`,
-		e.ChannelID,
-	).Scan(&lastCount, &lastMsgID)
+	// Edited an accepted count (any of them, not just the latest)
+	counted, ok, err := m.countedMessage(e.ChannelID, e.ID)
 	if err != nil {
+		log.Printf("[counting] edit lookup failed: %v", err)
+		return
+	}
+	if ok {
+		if isNum && editedNum == counted.Count {
+			return // "27" -> "27 nice" is fine
+		}
+		m.handleTamper(s, e.GuildID, counted, violationEdit, msg.Content, m.tamperAction(violationEdit))
 		return
 	}
 
-	next := lastCount + 1
-
-	// If they edited the latest count message, call it out specifically
-	if lastMsgID != "" && lastMsgID == e.ID {
-		txt := fmt.Sprintf(
-			"<@%s> has edited their count because they think it's funny.\nThe next number is **%d**",
-			msg.Author.ID,
-			next,
-		)
-		_, _ = s.ChannelMessageSend(e.ChannelID, txt)
+	// Only care if the edited message NOW starts with a number
+	if !isNum {
 		return
 	}
 
@@ -147,7 +153,7 @@ func (m *Module) onMessageUpdate(s *discordgo.Session, e *discordgo.MessageUpdat
 		"<@%s> has edited their message to **%d**.\nThe next number is **%d**",
 		msg.Author.ID,
 		editedNum,
-		next,
+		m.nextNumber(e.ChannelID),
 	)
 	_, _ = s.ChannelMessageSend(e.ChannelID, txt)
 }
@@ -181,3 +187,11 @@ func (m *Module) onMessageReactionAdd(s *discordgo.Session, e *discordgo.Message
 		_ = s.MessageReactionRemove(e.ChannelID, e.MessageID, emojiName, e.UserID)
 	}
 }
+
+// memberRoles returns the roles on a (possibly nil) message member.
+func memberRoles(mem *discordgo.Member) []string {
+	if mem == nil {
+		return nil
+	}
+	return mem.Roles
+}
@@ -0,0 +1,457 @@
+package counting
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	historyPageSize     = 10
+	histCustomBase      = "chist" // chist:<ownerID>:<channelID>:<page>:<action>
+	historyEventCount   = "count"
+	historyEventRuin    = "ruin"
+	historyEventRestore = "restore"
+	historyEventGrace   = "grace"
+	historyEventSet     = "set"
+)
+
+// errNoHistory is returned by restoreCount when the number was never counted.
+var errNoHistory = errors.New("no history for that number")
+
+type historyRow struct {
+	ID        int64
+	ChannelID string
+	Count     int64
+	UserID    string
+	Username  string
+	MessageID string
+	Event     string
+	Note      string
+	CreatedAt int64
+}
+
+/* =========================
+   DB helpers
+   ========================= */
+
+// txInsertHistory appends one row to counting_history inside an existing transaction.
+func txInsertHistory(tx *sql.Tx, r historyRow) error {
+	if r.CreatedAt == 0 {
+		r.CreatedAt = time.Now().Unix()
+	}
+	if r.Event == "" {
+		r.Event = historyEventCount
+	}
+	_, err := tx.Exec(
+		`INSERT INTO counting_history (channel_id, count, user_id, username, message_id, event, note, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?);`,
+		r.ChannelID, r.Count, r.UserID, strings.TrimSpace(r.Username), r.MessageID, r.Event, r.Note, r.CreatedAt,
+	)
+	return err
+}
+
+func (m *Module) countHistory(channelID string) (int, error) {
+	if m.db == nil {
+		return 0, sql.ErrConnDone
+	}
+	var n int
+	err := m.db.QueryRow(
+		`SELECT COUNT(*) FROM counting_history WHERE channel_id = ?;`,
+		channelID,
+	).Scan(&n)
+	return n, err
+}
+
+// queryHistoryPage returns history rows newest-first.
+func (m *Module) queryHistoryPage(channelID string, limit, offset int) ([]historyRow, error) {
+	if m.db == nil {
+		return nil, sql.ErrConnDone
+	}
+	rows, err := m.db.Query(
+		`SELECT id, channel_id, count, user_id, username, message_id, event, note, created_at
+		 FROM counting_history
+		 WHERE channel_id = ?
+		 ORDER BY id DESC
+		 LIMIT ? OFFSET ?;`,
+		channelID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]historyRow, 0, limit)
+	for rows.Next() {
+		var r historyRow
+		if err := rows.Scan(&r.ID, &r.ChannelID, &r.Count, &r.UserID, &r.Username, &r.MessageID, &r.Event, &r.Note, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// restoreCount rewinds counting_state to the most recent accepted count of `number`.
+// The user who counted `number` becomes last_user_id and whoever counted number-1
+// before them becomes prev_user_id, so the spacing rules carry on as if nothing happened.
+func (m *Module) restoreCount(channelID string, number int64, staffID, staffName string) (historyRow, error) {
+	if m.db == nil {
+		return historyRow{}, sql.ErrConnDone
+	}
+
+	// Same lock as live counting, so a count landing now can't be overwritten by the restore
+	lock := m.channelLock(channelID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return historyRow{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var target historyRow
+	err = tx.QueryRow(
+		`SELECT id, channel_id, count, user_id, username, message_id, event, note, created_at
+		 FROM counting_history
+		 WHERE channel_id = ? AND event = ? AND count = ?
+		 ORDER BY id DESC
+		 LIMIT 1;`,
+		channelID, historyEventCount, number,
+	).Scan(&target.ID, &target.ChannelID, &target.Count, &target.UserID, &target.Username, &target.MessageID, &target.Event, &target.Note, &target.CreatedAt)
+	if err == sql.ErrNoRows {
+		return historyRow{}, errNoHistory
+	}
+	if err != nil {
+		return historyRow{}, err
+	}
+
+	prevUser := ""
+	err = tx.QueryRow(
+		`SELECT user_id
+		 FROM counting_history
+		 WHERE channel_id = ? AND event = ? AND count = ? AND id < ?
+		 ORDER BY id DESC
+		 LIMIT 1;`,
+		channelID, historyEventCount, number-1, target.ID,
+	).Scan(&prevUser)
+	if err != nil && err != sql.ErrNoRows {
+		return historyRow{}, err
+	}
+
+	now := time.Now().Unix()
+
+	_, err = tx.Exec(
+		`INSERT INTO counting_state (channel_id, last_count, last_user_id, prev_user_id, updated_at, last_message_id)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(channel_id) DO UPDATE SET
+			last_count = excluded.last_count,
+			last_user_id = excluded.last_user_id,
+			prev_user_id = excluded.prev_user_id,
+			updated_at = excluded.updated_at,
+			last_message_id = excluded.last_message_id;`,
+		channelID, number, target.UserID, prevUser, now, target.MessageID,
+	)
+	if err != nil {
+		return historyRow{}, err
+	}
+
+	if err := txInsertHistory(tx, historyRow{
+		ChannelID: channelID,
+		Count:     number,
+		UserID:    staffID,
+		Username:  staffName,
+		MessageID: target.MessageID,
+		Event:     historyEventRestore,
+		Note:      fmt.Sprintf("restored to count #%d", target.ID),
+		CreatedAt: now,
+	}); err != nil {
+		return historyRow{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return historyRow{}, err
+	}
+	return target, nil
+}
+
+/* =========================
+   /countrestore (staff)
+   ========================= */
+
+func (m *Module) handleCountRestore(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if !isStaff(i) {
+		respondEphemeral(s, i, "You need **Manage Server** to use this.")
+		return
+	}
+
+	number := int64(0)
+	channelChoice := ""
+	for _, opt := range data.Options {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "number":
+			number = opt.IntValue()
+		case "channel":
+			if v, ok := opt.Value.(string); ok {
+				channelChoice = v
+			}
+		}
+	}
+
+	if number <= 0 {
+		respondEphemeral(s, i, "Number must be **1 or higher**.")
+		return
+	}
+
+	channelID := m.resolveChannelChoice(channelChoice, i.ChannelID)
+	if channelID == "" {
+		respondEphemeral(s, i, "Pick a channel option (counting / counting-trios), or run the command inside one of the counting channels.")
+		return
+	}
+
+	staffID := interactionUserID(i)
+	staffName := ""
+	if i.Member != nil && i.Member.User != nil {
+		staffName = i.Member.User.Username
+	}
+
+	target, err := m.restoreCount(channelID, number, staffID, staffName)
+	if err == errNoHistory {
+		respondEphemeral(s, i, fmt.Sprintf("No accepted count of **%d** found in %s’s history.", number, m.channelLabel(channelID)))
+		return
+	}
+	if err != nil {
+		log.Printf("[counting] countrestore db error: %v", err)
+		respondEphemeral(s, i, "DB error restoring the count.")
+		return
+	}
+
+	by := "an unknown user"
+	if target.UserID != "" {
+		by = "<@" + target.UserID + ">"
+	}
+
+	announce := fmt.Sprintf(
+		"🔁 The count has been restored to **%d** (counted by %s).\nThe next number is **%d**.",
+		number, by, number+1,
+	)
+	_, _ = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:         announce,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+
+	respondEphemeral(s, i, fmt.Sprintf("Restored %s to **%d**.", m.channelLabel(channelID), number))
+}
+
+/* =========================
+   /counthistory (viewer)
+   ========================= */
+
+func (m *Module) handleCountHistory(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	channelChoice := ""
+	for _, opt := range data.Options {
+		if opt != nil && opt.Name == "channel" {
+			if v, ok := opt.Value.(string); ok {
+				channelChoice = v
+			}
+		}
+	}
+
+	channelID := m.resolveChannelChoice(channelChoice, i.ChannelID)
+	if channelID == "" {
+		respondEphemeral(s, i, "Pick a channel option (counting / counting-trios), or run the command inside one of the counting channels.")
+		return
+	}
+
+	ownerID := interactionUserID(i)
+	if ownerID == "" {
+		respondEphemeral(s, i, "Could not determine user.")
+		return
+	}
+
+	embed, comps, err := m.buildHistoryEmbed(i.GuildID, ownerID, channelID, 0)
+	if err != nil {
+		respondEphemeral(s, i, "DB error reading counting history.")
+		return
+	}
+	if embed == nil {
+		respondEphemeral(s, i, "No counting history yet.")
+		return
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: comps,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+func (m *Module) buildHistoryEmbed(guildID, ownerID, channelID string, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	total, err := m.countHistory(channelID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return nil, nil, nil
+	}
+
+	maxPage := (total - 1) / historyPageSize
+	if page < 0 {
+		page = 0
+	}
+	if page > maxPage {
+		page = maxPage
+	}
+
+	rows, err := m.queryHistoryPage(channelID, historyPageSize, page*historyPageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var b strings.Builder
+	for _, r := range rows {
+		b.WriteString(formatHistoryLine(guildID, r))
+		b.WriteString("\n")
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Counting history — %s", m.channelLabel(channelID)),
+		Description: b.String(),
+		Color:       0x5865F2,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%d entries (Page %d/%d)", total, page+1, maxPage+1),
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	return embed, historyButtons(ownerID, channelID, page, maxPage), nil
+}
+
+func formatHistoryLine(guildID string, r historyRow) string {
+	who := "unknown"
+	if r.UserID != "" {
+		who = "<@" + r.UserID + ">"
+	}
+
+	num := fmt.Sprintf("**%d**", r.Count)
+	if guildID != "" && r.MessageID != "" {
+		num = fmt.Sprintf("[**%d**](https://discord.com/channels/%s/%s/%s)", r.Count, guildID, r.ChannelID, r.MessageID)
+	}
+
+	when := fmt.Sprintf("<t:%d:R>", r.CreatedAt)
+
+	switch r.Event {
+	case historyEventRuin:
+		line := fmt.Sprintf("💥 Ruined at **%d** by %s — %s", r.Count, who, when)
+		if r.Note != "" {
+			line += " · " + r.Note
+		}
+		return line
+	case historyEventRestore:
+		return fmt.Sprintf("🔁 Restored to %s by %s — %s", num, who, when)
+	case historyEventSet:
+		line := fmt.Sprintf("🛠️ Set to **%d** by %s — %s", r.Count, who, when)
+		if r.Note != "" {
+			line += " · " + r.Note
+		}
+		return line
+	case historyEventGrace:
+		line := fmt.Sprintf("%s Ignored duplicate %s from %s — %s", reactGrace, num, who, when)
+		if r.Note != "" {
+			line += " · " + r.Note
+		}
+		return line
+	default:
+		return fmt.Sprintf("%s — %s — %s", num, who, when)
+	}
+}
+
+func historyButtons(ownerID, channelID string, page, maxPage int) []discordgo.MessageComponent {
+	prevDisabled := page <= 0
+	nextDisabled := page >= maxPage
+
+	custom := func(action string) string {
+		return fmt.Sprintf("%s:%s:%s:%d:%s", histCustomBase, ownerID, channelID, page, action)
+	}
+
+	row := discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "⏮", Style: discordgo.SecondaryButton, CustomID: custom("top"), Disabled: prevDisabled},
+		discordgo.Button{Label: "◀", Style: discordgo.SecondaryButton, CustomID: custom("prev"), Disabled: prevDisabled},
+		discordgo.Button{Label: "▶", Style: discordgo.SecondaryButton, CustomID: custom("next"), Disabled: nextDisabled},
+		discordgo.Button{Label: "⏭", Style: discordgo.SecondaryButton, CustomID: custom("end"), Disabled: nextDisabled},
+		discordgo.Button{Label: "🔄", Style: discordgo.PrimaryButton, CustomID: custom("refresh")},
+	}}
+
+	return []discordgo.MessageComponent{row}
+}
+
+func (m *Module) handleHistoryButtons(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i == nil || i.Message == nil {
+		return
+	}
+
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) != 5 || parts[0] != histCustomBase {
+		return
+	}
+
+	ownerID := parts[1]
+	channelID := parts[2]
+	page, _ := strconv.Atoi(parts[3])
+	action := parts[4]
+
+	clicker := interactionUserID(i)
+	if clicker == "" || clicker != ownerID {
+		respondEphemeral(s, i, "Only the person who ran this command can use these buttons.")
+		return
+	}
+
+	total, err := m.countHistory(channelID)
+	if err != nil {
+		respondEphemeral(s, i, "DB error reading counting history.")
+		return
+	}
+	maxPage := 0
+	if total > 0 {
+		maxPage = (total - 1) / historyPageSize
+	}
+
+	target := page
+	switch action {
+	case "top":
+		target = 0
+	case "prev":
+		target = page - 1
+	case "next":
+		target = page + 1
+	case "end":
+		target = maxPage
+	}
+
+	embed, comps, err := m.buildHistoryEmbed(i.GuildID, ownerID, channelID, target)
+	if err != nil || embed == nil {
+		respondEphemeral(s, i, "DB error reading counting history.")
+		return
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: comps,
+		},
+	})
+}
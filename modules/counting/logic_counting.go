@@ -2,10 +2,13 @@ package counting
 
 import (
 	"database/sql"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
+
+	"github.com/bwmarrin/discordgo"
 )
 
 type channelMode int
@@ -56,6 +59,10 @@ type applyResult struct {
 	RuinedAt int64
 	Reason   string
 
+	// Grace is set when a duplicate of the just-accepted number was ignored
+	// (network-lag race) instead of ruining the count.
+	Grace bool
+
 	HighScore bool
 	Count     int64
 }
@@ -63,11 +70,14 @@ type applyResult struct {
 // applyCount enforces per-channel counting rules and persists state.
 //
 // If a user fails, the counter is reset to 0 so the next correct number is 1.
+// Every accepted count and every ruin is also appended to counting_history.
 //
 // Rules:
 //  - Both modes: newCount must equal lastCount+1.
 //  - Normal: same user cannot count twice in a row.
 //  - Trios: user cannot count if they were one of the last TWO counters.
+//  - Grace: a different user repeating the just-accepted number within graceWindow
+//    of it is ignored (both raced honestly) rather than ruining the count.
 func (m *Module) applyCount(mode channelMode, guildID, channelID, userID, username, messageID string, newCount int64) (applyResult, error) {
 	if m.db == nil {
 		return applyResult{OK: false}, sql.ErrConnDone
@@ -82,58 +92,85 @@ func (m *Module) applyCount(mode channelMode, guildID, channelID, userID, userna
 	var lastCount int64
 	var lastUser string
 	var prevUser string
+	var lastMsgID string
 
 	err = tx.QueryRow(
-		`SELECT last_count, last_user_id, prev_user_id
+		`SELECT last_count, last_user_id, prev_user_id, last_message_id
 		 FROM counting_state
 		 WHERE channel_id = ?;`,
 		channelID,
-	).Scan(&lastCount, &lastUser, &prevUser)
+	).Scan(&lastCount, &lastUser, &prevUser, &lastMsgID)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
 			lastCount = 0
 			lastUser = ""
 			prevUser = ""
+			lastMsgID = ""
 		} else {
 			return applyResult{OK: false}, err
 		}
 	}
 
-	expected := lastCount + 1
-
-	// Validate number
-	if newCount != expected {
+	// ruin resets the channel and records where it was ruined.
+	ruin := func(reason string) (applyResult, error) {
 		if err := m.resetState(tx, channelID); err != nil {
 			return applyResult{OK: false}, err
 		}
+		if err := txInsertHistory(tx, historyRow{
+			ChannelID: channelID,
+			Count:     lastCount,
+			UserID:    userID,
+			Username:  username,
+			MessageID: messageID,
+			Event:     historyEventRuin,
+			Note:      reason,
+		}); err != nil {
+			return applyResult{OK: false}, err
+		}
 		if err := tx.Commit(); err != nil {
 			return applyResult{OK: false}, err
 		}
-		return applyResult{OK: false, RuinedAt: lastCount, Reason: "Wrong number."}, nil
+		return applyResult{OK: false, RuinedAt: lastCount, Reason: reason}, nil
 	}
 
-	// Validate spacing
-	switch mode {
-	case modeNormal:
-		if lastUser != "" && userID == lastUser {
-			if err := m.resetState(tx, channelID); err != nil {
+	// Network-lag grace: someone else sent the same number a moment after it was accepted.
+	if lastCount > 0 && newCount == lastCount && lastUser != "" && userID != lastUser {
+		if gap, ok := m.withinGrace(lastMsgID, messageID); ok {
+			if err := txInsertHistory(tx, historyRow{
+				ChannelID: channelID,
+				Count:     newCount,
+				UserID:    userID,
+				Username:  username,
+				MessageID: messageID,
+				Event:     historyEventGrace,
+				Note:      fmt.Sprintf("duplicate within %dms", gap.Milliseconds()),
+			}); err != nil {
 				return applyResult{OK: false}, err
 			}
 			if err := tx.Commit(); err != nil {
 				return applyResult{OK: false}, err
 			}
-			return applyResult{OK: false, RuinedAt: lastCount, Reason: "You can't count twice in a row."}, nil
+			return applyResult{OK: false, Grace: true, Count: newCount}, nil
+		}
+	}
+
+	expected := lastCount + 1
+
+	// Validate number
+	if newCount != expected {
+		return ruin("Wrong number.")
+	}
+
+	// Validate spacing
+	switch mode {
+	case modeNormal:
+		if lastUser != "" && userID == lastUser {
+			return ruin("You can't count twice in a row.")
 		}
 	case modeTrios:
 		if (lastUser != "" && userID == lastUser) || (prevUser != "" && userID == prevUser) {
-			if err := m.resetState(tx, channelID); err != nil {
-				return applyResult{OK: false}, err
-			}
-			if err := tx.Commit(); err != nil {
-				return applyResult{OK: false}, err
-			}
-			return applyResult{OK: false, RuinedAt: lastCount, Reason: "In trios you must wait for 2 other people to count."}, nil
+			return ruin("In trios you must wait for 2 other people to count.")
 		}
 	}
 
@@ -198,6 +235,19 @@ func (m *Module) applyCount(mode channelMode, guildID, channelID, userID, userna
 		return applyResult{OK: false}, err
 	}
 
+	// ✅ History (used by /counthistory and /countrestore)
+	if err := txInsertHistory(tx, historyRow{
+		ChannelID: channelID,
+		Count:     newCount,
+		UserID:    userID,
+		Username:  username,
+		MessageID: messageID,
+		Event:     historyEventCount,
+		CreatedAt: now,
+	}); err != nil {
+		return applyResult{OK: false}, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return applyResult{OK: false}, err
 	}
@@ -224,3 +274,24 @@ func (m *Module) resetState(tx *sql.Tx, channelID string) error {
 	)
 	return err
 }
+
+// withinGrace reports whether two messages were sent within m.graceWindow of each other,
+// using the creation time encoded in their snowflake IDs (independent of bot/gateway lag).
+func (m *Module) withinGrace(firstMsgID, secondMsgID string) (time.Duration, bool) {
+	if m.graceWindow <= 0 || firstMsgID == "" || secondMsgID == "" {
+		return 0, false
+	}
+	t1, err := discordgo.SnowflakeTimestamp(firstMsgID)
+	if err != nil {
+		return 0, false
+	}
+	t2, err := discordgo.SnowflakeTimestamp(secondMsgID)
+	if err != nil {
+		return 0, false
+	}
+	gap := t2.Sub(t1)
+	if gap < 0 {
+		gap = -gap
+	}
+	return gap, gap <= m.graceWindow
+}
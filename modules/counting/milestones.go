@@ -0,0 +1,465 @@
+package counting
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// milestone is one row of counting_milestones.
+// Exactly one of Number (exact count) or Every (every multiple of N) is set.
+type milestone struct {
+	ID            int64
+	ChannelID     string
+	Number        int64
+	Every         int64
+	Emoji         string // reaction form: unicode or name:id
+	Announce      bool
+	RoleID        string
+	PingChannelID string
+}
+
+/* =========================
+   DB helpers
+   ========================= */
+
+const milestonesSeededKey = "milestones_seeded"
+
+// seedDefaultMilestones copies the old hardcoded reactions (💯 at 100 and the
+// 200/500/1000 emojis from main.go) into counting_milestones once, so upgrading
+// doesn't silently drop them. A marker in counting_settings keeps it one-off:
+// if staff later remove every milestone, they stay removed.
+func (m *Module) seedDefaultMilestones() {
+	if m.db == nil {
+		return
+	}
+
+	var seeded int
+	if err := m.db.QueryRow(`SELECT COUNT(*) FROM counting_settings WHERE key = ?;`, milestonesSeededKey).Scan(&seeded); err != nil {
+		log.Printf("[counting] milestone seed check failed: %v", err)
+		return
+	}
+	if seeded > 0 {
+		return
+	}
+
+	var n int
+	if err := m.db.QueryRow(`SELECT COUNT(*) FROM counting_milestones;`).Scan(&n); err != nil {
+		log.Printf("[counting] milestone seed check failed: %v", err)
+		return
+	}
+	if n > 0 {
+		// Seeded before the marker existed
+		m.markMilestonesSeeded()
+		return
+	}
+
+	defaults := []struct {
+		number int64
+		emoji  string
+	}{
+		{100, reactHundred},
+		{200, m.emoji200},
+		{500, m.emoji500},
+		{1000, m.emoji1000},
+	}
+
+	for _, ch := range []string{m.countingChannelID, m.triosChannelID} {
+		if ch == "" {
+			continue
+		}
+		for _, d := range defaults {
+			if d.emoji == "" {
+				continue
+			}
+			if _, err := m.addMilestone(milestone{ChannelID: ch, Number: d.number, Emoji: d.emoji}); err != nil {
+				log.Printf("[counting] milestone seed failed: %v", err)
+				return
+			}
+		}
+	}
+	m.markMilestonesSeeded()
+}
+
+func (m *Module) markMilestonesSeeded() {
+	if _, err := m.db.Exec(
+		`INSERT OR IGNORE INTO counting_settings (key, value, updated_at) VALUES (?, '1', ?);`,
+		milestonesSeededKey, time.Now().Unix(),
+	); err != nil {
+		log.Printf("[counting] milestone seed marker failed: %v", err)
+	}
+}
+
+func (m *Module) addMilestone(ms milestone) (int64, error) {
+	if m.db == nil {
+		return 0, sql.ErrConnDone
+	}
+	res, err := m.db.Exec(
+		`INSERT INTO counting_milestones (channel_id, number, every, emoji, announce, role_id, ping_channel_id, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?);`,
+		ms.ChannelID, ms.Number, ms.Every, ms.Emoji, boolToInt(ms.Announce), ms.RoleID, ms.PingChannelID, time.Now().Unix(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (m *Module) deleteMilestone(id int64) (int64, error) {
+	if m.db == nil {
+		return 0, sql.ErrConnDone
+	}
+	res, err := m.db.Exec(`DELETE FROM counting_milestones WHERE id = ?;`, id)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (m *Module) listMilestones(channelID string) ([]milestone, error) {
+	return m.queryMilestones(
+		`SELECT id, channel_id, number, every, emoji, announce, role_id, ping_channel_id
+		 FROM counting_milestones
+		 WHERE channel_id = ?
+		 ORDER BY every > 0, number, every, id;`,
+		channelID,
+	)
+}
+
+// matchingMilestones returns every milestone that fires for `count` in a channel.
+func (m *Module) matchingMilestones(channelID string, count int64) ([]milestone, error) {
+	return m.queryMilestones(
+		`SELECT id, channel_id, number, every, emoji, announce, role_id, ping_channel_id
+		 FROM counting_milestones
+		 WHERE channel_id = ?
+		   AND ((number > 0 AND number = ?) OR (every > 0 AND ? % every = 0))
+		 ORDER BY id;`,
+		channelID, count, count,
+	)
+}
+
+func (m *Module) queryMilestones(q string, args ...any) ([]milestone, error) {
+	if m.db == nil {
+		return nil, sql.ErrConnDone
+	}
+	rows, err := m.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []milestone
+	for rows.Next() {
+		var ms milestone
+		var announce int
+		if err := rows.Scan(&ms.ID, &ms.ChannelID, &ms.Number, &ms.Every, &ms.Emoji, &announce, &ms.RoleID, &ms.PingChannelID); err != nil {
+			return nil, err
+		}
+		ms.Announce = announce != 0
+		out = append(out, ms)
+	}
+	return out, rows.Err()
+}
+
+/* =========================
+   Firing milestones
+   ========================= */
+
+// applyMilestones runs every milestone configured for `count` after a successful count.
+func (m *Module) applyMilestones(s *discordgo.Session, guildID, channelID, messageID, userID string, count int64) {
+	list, err := m.matchingMilestones(channelID, count)
+	if err != nil {
+		log.Printf("[counting] milestone lookup failed: %v", err)
+		return
+	}
+
+	for _, ms := range list {
+		if ms.Emoji != "" {
+			if err := s.MessageReactionAdd(channelID, messageID, ms.Emoji); err != nil {
+				log.Printf("[counting] milestone reaction failed (id=%d): %v", ms.ID, err)
+			}
+		}
+
+		if ms.RoleID != "" && guildID != "" {
+			if err := s.GuildMemberRoleAdd(guildID, userID, ms.RoleID); err != nil {
+				log.Printf("[counting] milestone role add failed (id=%d role=%s): %v", ms.ID, ms.RoleID, err)
+			}
+		}
+
+		if !ms.Announce && ms.PingChannelID == "" {
+			continue
+		}
+
+		embed := &discordgo.MessageEmbed{
+			Title:       "🎉 Counting milestone!",
+			Description: fmt.Sprintf("<@%s> counted **%d** in <#%s>!", userID, count, channelID),
+			Color:       0xF1C40F,
+			Timestamp:   time.Now().Format(time.RFC3339),
+		}
+		if guildID != "" {
+			embed.URL = fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, messageID)
+		}
+		if ms.RoleID != "" {
+			embed.Fields = []*discordgo.MessageEmbedField{
+				{Name: "Reward", Value: "<@&" + ms.RoleID + ">"},
+			}
+		}
+
+		if ms.Announce {
+			_, _ = s.ChannelMessageSendEmbed(channelID, embed)
+		}
+		if ms.PingChannelID != "" && ms.PingChannelID != channelID {
+			_, _ = s.ChannelMessageSendEmbed(ms.PingChannelID, embed)
+		}
+	}
+}
+
+/* =========================
+   /countingmilestone add|remove|list
+   ========================= */
+
+func (m *Module) handleCountingMilestone(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if len(data.Options) == 0 || data.Options[0] == nil {
+		respondEphemeral(s, i, "Missing subcommand.")
+		return
+	}
+	sub := data.Options[0]
+
+	switch sub.Name {
+	case "add":
+		m.handleMilestoneAdd(s, i, sub.Options)
+	case "remove":
+		m.handleMilestoneRemove(s, i, sub.Options)
+	case "list":
+		m.handleMilestoneList(s, i, sub.Options)
+	default:
+		respondEphemeral(s, i, "Unknown subcommand.")
+	}
+}
+
+func (m *Module) handleMilestoneAdd(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	if !isStaff(i) {
+		respondEphemeral(s, i, "You need **Manage Server** to use this.")
+		return
+	}
+
+	var ms milestone
+	channelChoice := ""
+	emojiInput := ""
+
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "at":
+			ms.Number = opt.IntValue()
+		case "every":
+			ms.Every = opt.IntValue()
+		case "channel":
+			if v, ok := opt.Value.(string); ok {
+				channelChoice = v
+			}
+		case "emoji":
+			if v, ok := opt.Value.(string); ok {
+				emojiInput = strings.TrimSpace(v)
+			}
+		case "announce":
+			ms.Announce = opt.BoolValue()
+		case "role":
+			if r := opt.RoleValue(s, i.GuildID); r != nil {
+				ms.RoleID = r.ID
+			}
+		case "ping_channel":
+			if ch := opt.ChannelValue(s); ch != nil {
+				ms.PingChannelID = ch.ID
+			}
+		}
+	}
+
+	if (ms.Number > 0) == (ms.Every > 0) {
+		respondEphemeral(s, i, "Set exactly one of **at** (a single number) or **every** (every multiple of N).")
+		return
+	}
+
+	if emojiInput != "" {
+		emoji, err := parseReactionEmoji(emojiInput)
+		if err != nil {
+			respondEphemeral(s, i, "Could not parse emoji. Use unicode 🎉 or custom <:name:id>.")
+			return
+		}
+		ms.Emoji = emoji
+	}
+
+	if ms.Emoji == "" && !ms.Announce && ms.RoleID == "" && ms.PingChannelID == "" {
+		respondEphemeral(s, i, "Give the milestone something to do: an emoji, announce, a role and/or a ping channel.")
+		return
+	}
+
+	ms.ChannelID = m.resolveChannelChoice(channelChoice, i.ChannelID)
+	if ms.ChannelID == "" {
+		respondEphemeral(s, i, "Pick a channel option (counting / counting-trios), or run the command inside one of the counting channels.")
+		return
+	}
+
+	id, err := m.addMilestone(ms)
+	if err != nil {
+		log.Printf("[counting] milestone add failed: %v", err)
+		respondEphemeral(s, i, "DB error saving milestone.")
+		return
+	}
+	ms.ID = id
+
+	respondEphemeral(s, i, "✅ Milestone added to "+m.channelLabel(ms.ChannelID)+":\n"+formatMilestone(ms))
+}
+
+func (m *Module) handleMilestoneRemove(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	if !isStaff(i) {
+		respondEphemeral(s, i, "You need **Manage Server** to use this.")
+		return
+	}
+
+	id := int64(0)
+	for _, opt := range opts {
+		if opt != nil && opt.Name == "id" {
+			id = opt.IntValue()
+		}
+	}
+	if id <= 0 {
+		respondEphemeral(s, i, "Missing milestone id (see `/countingmilestone list`).")
+		return
+	}
+
+	n, err := m.deleteMilestone(id)
+	if err != nil {
+		log.Printf("[counting] milestone remove failed: %v", err)
+		respondEphemeral(s, i, "DB error removing milestone.")
+		return
+	}
+	if n == 0 {
+		respondEphemeral(s, i, fmt.Sprintf("No milestone with id **%d**.", id))
+		return
+	}
+
+	respondEphemeral(s, i, fmt.Sprintf("✅ Removed milestone **%d**.", id))
+}
+
+func (m *Module) handleMilestoneList(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	channelChoice := ""
+	for _, opt := range opts {
+		if opt != nil && opt.Name == "channel" {
+			if v, ok := opt.Value.(string); ok {
+				channelChoice = v
+			}
+		}
+	}
+
+	channelID := m.resolveChannelChoice(channelChoice, i.ChannelID)
+	if channelID == "" {
+		respondEphemeral(s, i, "Pick a channel option (counting / counting-trios), or run the command inside one of the counting channels.")
+		return
+	}
+
+	list, err := m.listMilestones(channelID)
+	if err != nil {
+		respondEphemeral(s, i, "DB error reading milestones.")
+		return
+	}
+	if len(list) == 0 {
+		respondEphemeral(s, i, "No milestones configured for "+m.channelLabel(channelID)+".")
+		return
+	}
+
+	lines := make([]string, 0, len(list))
+	for _, ms := range list {
+		lines = append(lines, formatMilestone(ms))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Counting milestones — " + m.channelLabel(channelID),
+		Description: truncate(strings.Join(lines, "\n"), 4000),
+		Color:       0xF1C40F,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+func formatMilestone(ms milestone) string {
+	when := fmt.Sprintf("at **%d**", ms.Number)
+	if ms.Every > 0 {
+		when = fmt.Sprintf("every **%d**", ms.Every)
+	}
+
+	var actions []string
+	if ms.Emoji != "" {
+		actions = append(actions, displayEmoji(ms.Emoji))
+	}
+	if ms.Announce {
+		actions = append(actions, "announce")
+	}
+	if ms.RoleID != "" {
+		actions = append(actions, "role <@&"+ms.RoleID+">")
+	}
+	if ms.PingChannelID != "" {
+		actions = append(actions, "ping <#"+ms.PingChannelID+">")
+	}
+
+	return fmt.Sprintf("`#%d` %s → %s", ms.ID, when, strings.Join(actions, " · "))
+}
+
+// parseReactionEmoji turns user input (unicode or <:name:id> / <a:name:id>) into
+// the form MessageReactionAdd expects (unicode or name:id).
+func parseReactionEmoji(input string) (string, error) {
+	in := strings.TrimSpace(input)
+	if in == "" {
+		return "", fmt.Errorf("empty")
+	}
+
+	if strings.HasPrefix(in, "<") && strings.HasSuffix(in, ">") && strings.Contains(in, ":") {
+		trim := strings.TrimSuffix(strings.TrimPrefix(in, "<"), ">")
+		trim = strings.TrimPrefix(trim, "a:")
+		trim = strings.TrimPrefix(trim, ":")
+
+		parts := strings.Split(trim, ":")
+		if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+			return parts[0] + ":" + parts[1], nil
+		}
+		return "", fmt.Errorf("bad custom emoji")
+	}
+
+	return in, nil
+}
+
+// displayEmoji renders a stored reaction emoji for use in message text.
+func displayEmoji(emoji string) string {
+	if strings.Contains(emoji, ":") {
+		return "<:" + emoji + ">"
+	}
+	return emoji
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max]) + "…"
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
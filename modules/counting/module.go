@@ -5,8 +5,10 @@ import (
 	"database/sql"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/Sentinaut/AuraBot/modules/members"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -15,17 +17,15 @@ const (
 	reactHighScore = "☑️"
 	reactBad       = "❌"
 	reactHundred   = "💯"
+	reactGrace     = "😐"
+	reactBlocked   = "⛔"
 )
 
 // NOTE:
 // These are intentionally VARIABLES (not const) so they can be injected from cmd/bot/main.go.
-// Other counting files can continue to reference emoji200/emoji500/emoji1000/customRuinerUserID/customRuinerGIFURL
+// Other counting files can continue to reference customRuinerUserID/customRuinerGIFURL
 // without needing edits.
 var (
-	emoji200  string
-	emoji500  string
-	emoji1000 string
-
 	customRuinerUserID string
 	customRuinerGIFURL string
 )
@@ -43,7 +43,35 @@ type Module struct {
 	ruinedRoleID string
 	ruinedFor    time.Duration
 
-	// Stored on the module too (useful if you later want m.emoji200 style access)
+	// Duplicates of the just-accepted number from another user inside this window are ignored.
+	graceWindow time.Duration
+
+	// Edit/delete enforcement + who may count
+	antiCheat AntiCheat
+
+	// Give the ruined role for ruins found while catching up after downtime
+	catchUpPunish bool
+
+	// Per-channel lock around applyCount (live messages vs catch-up replay)
+	lockMu     sync.Mutex
+	countLocks map[string]*sync.Mutex
+
+	// Per-channel webhook used to repost tampered counts
+	whMu     sync.Mutex
+	webhooks map[string]*discordgo.Webhook
+
+	// Message-delete audit log as last seen, to tell moderator deletes from self-deletes
+	audit deleteAudit
+
+	// Counted messages being punished right now (edit and delete events can race)
+	tamperMu       sync.Mutex
+	tamperInFlight map[string]bool
+
+	// Team competition scoreboards waiting for an edit
+	compMu    sync.Mutex
+	compDirty map[int64]bool
+
+	// Default milestone emojis; only used to seed counting_milestones on first run
 	emoji200  string
 	emoji500  string
 	emoji1000 string
@@ -51,6 +79,9 @@ type Module struct {
 	customRuinerUserID string
 	customRuinerGIFURL string
 
+	// Shared member tracker (roles for the anti-cheat + team checks); nil = ask Discord
+	tracker *members.Tracker
+
 	stop chan struct{}
 }
 
@@ -62,11 +93,15 @@ func New(
 	triosChannelID string,
 	ruinedRoleID string,
 	ruinedFor time.Duration,
+	graceWindow time.Duration,
+	antiCheat AntiCheat,
+	catchUpPunish bool,
 	inEmoji200 string,
 	inEmoji500 string,
 	inEmoji1000 string,
 	inCustomRuinerUserID string,
 	inCustomRuinerGIFURL string,
+	tracker *members.Tracker,
 	db *sql.DB,
 ) *Module {
 
@@ -77,11 +112,11 @@ func New(
 	inEmoji1000 = strings.TrimSpace(inEmoji1000)
 	inCustomRuinerUserID = strings.TrimSpace(inCustomRuinerUserID)
 	inCustomRuinerGIFURL = strings.TrimSpace(inCustomRuinerGIFURL)
+	antiCheat.EditAction = strings.ToLower(strings.TrimSpace(antiCheat.EditAction))
+	antiCheat.DeleteAction = strings.ToLower(strings.TrimSpace(antiCheat.DeleteAction))
+	antiCheat.RequiredRoleID = strings.TrimSpace(antiCheat.RequiredRoleID)
 
 	// Set package-level vars so existing files can continue referencing them
-	emoji200 = inEmoji200
-	emoji500 = inEmoji500
-	emoji1000 = inEmoji1000
 	customRuinerUserID = inCustomRuinerUserID
 	customRuinerGIFURL = inCustomRuinerGIFURL
 
@@ -92,6 +127,13 @@ func New(
 		triosChannelID:    strings.TrimSpace(triosChannelID),
 		ruinedRoleID:      strings.TrimSpace(ruinedRoleID),
 		ruinedFor:         ruinedFor,
+		graceWindow:       graceWindow,
+		antiCheat:         antiCheat,
+		catchUpPunish:     catchUpPunish,
+		countLocks:        make(map[string]*sync.Mutex),
+		webhooks:          make(map[string]*discordgo.Webhook),
+		tamperInFlight:    make(map[string]bool),
+		compDirty:         make(map[int64]bool),
 
 		emoji200:  inEmoji200,
 		emoji500:  inEmoji500,
@@ -100,6 +142,8 @@ func New(
 		customRuinerUserID: inCustomRuinerUserID,
 		customRuinerGIFURL: inCustomRuinerGIFURL,
 
+		tracker: tracker,
+
 		stop: make(chan struct{}),
 	}
 }
@@ -111,10 +155,16 @@ func (m *Module) Register(s *discordgo.Session) error {
 	s.AddHandler(m.onReady)
 	s.AddHandler(m.onInteractionCreate)
 
+	// Replay counts posted while the bot was offline
+	s.AddHandler(m.onReadyCatchUp)
+
+	// Audit log baseline for telling who deleted a count
+	s.AddHandler(m.onReadyAudit)
+
 	// Counting message handler
 	s.AddHandler(m.onMessageCreate)
 
-	// Edited message handler (latest count edits + edits-to-number)
+	// Edited message handler (counted-message tampering + edits-to-number)
 	s.AddHandler(m.onMessageUpdate)
 
 	// Remove user-added tick reactions in counting channels
@@ -131,13 +181,17 @@ func (m *Module) Start(ctx context.Context, s *discordgo.Session) error {
 	// Schema is owned by internal/db/migrate.go, but we need ONE extra column for this feature.
 	m.ensureDeleteTrackingSchema()
 
-	// Background expiry cleanup (role removals)
+	// Carry the old hardcoded milestone reactions over on first run.
+	m.seedDefaultMilestones()
+
+	// Background expiry cleanup (role removals) + daily/weekly recaps
 	go func() {
 		t := time.NewTicker(5 * time.Minute)
 		defer t.Stop()
 
 		// run once at startup
 		m.cleanupExpired(s)
+		m.postDueRecaps(s)
 
 		for {
 			select {
@@ -147,6 +201,26 @@ func (m *Module) Start(ctx context.Context, s *discordgo.Session) error {
 				return
 			case <-t.C:
 				m.cleanupExpired(s)
+				m.postDueRecaps(s)
+			}
+		}
+	}()
+
+	// Team competitions: batched scoreboard edits + ending expired events
+	go func() {
+		t := time.NewTicker(scoreboardInterval)
+		defer t.Stop()
+
+		m.tickCompetitions(s)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.stop:
+				return
+			case <-t.C:
+				m.tickCompetitions(s)
 			}
 		}
 	}()
@@ -154,3 +228,16 @@ func (m *Module) Start(ctx context.Context, s *discordgo.Session) error {
 	log.Println("[counting] module started")
 	return nil
 }
+
+// memberRoles is a member's roles from the tracker, falling back to the API.
+func (m *Module) memberRoles(s *discordgo.Session, guildID, userID string) ([]string, bool) {
+	if m.tracker != nil {
+		if mem, ok := m.tracker.Get(userID); ok {
+			return mem.Roles, true
+		}
+	}
+	if mem, err := s.GuildMember(guildID, userID); err == nil && mem != nil {
+		return mem.Roles, true
+	}
+	return nil, false
+}
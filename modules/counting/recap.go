@@ -0,0 +1,425 @@
+package counting
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	recapDaily  = "daily"
+	recapWeekly = "weekly"
+
+	recapTopN = 5
+
+	// Most periods posted at once after the bot was offline (the newest ones)
+	recapMaxBacklog = 7
+)
+
+type countingGoal struct {
+	ChannelID string
+	Target    int64
+	Deadline  int64 // unix; 0 = no deadline
+	SetBy     string
+	CreatedAt int64
+}
+
+type recapStats struct {
+	Counted int64
+	Ruins   int64
+	Top     []lbRow
+}
+
+// recapLocation is the timezone day/week boundaries are computed in (same as /joins).
+func recapLocation() *time.Location {
+	loc, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// lastCompletedPeriod returns the most recent fully finished day or week (Monday 00:00) before now.
+func lastCompletedPeriod(now time.Time, period string) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if period == recapWeekly {
+		shift := (int(today.Weekday()) + 6) % 7 // Monday=0 ... Sunday=6
+		weekStart := today.AddDate(0, 0, -shift)
+		return weekStart.AddDate(0, 0, -7), weekStart
+	}
+	return today.AddDate(0, 0, -1), today
+}
+
+// periodsToRecap returns the completed periods after lastStart, oldest first, capped to the
+// newest recapMaxBacklog. With no recap on record only the latest completed period is due.
+func periodsToRecap(now time.Time, period string, lastStart time.Time, hasLast bool) [][2]time.Time {
+	step := 1
+	if period == recapWeekly {
+		step = 7
+	}
+
+	start, end := lastCompletedPeriod(now, period)
+	var out [][2]time.Time
+	for len(out) < recapMaxBacklog {
+		if hasLast && !start.After(lastStart) {
+			break
+		}
+		out = append(out, [2]time.Time{start, end})
+		if !hasLast {
+			break
+		}
+		start, end = start.AddDate(0, 0, -step), start
+	}
+
+	for a, b := 0, len(out)-1; a < b; a, b = a+1, b-1 {
+		out[a], out[b] = out[b], out[a]
+	}
+	return out
+}
+
+/* =========================
+   Scheduler
+   ========================= */
+
+// postDueRecaps posts every daily/weekly recap missed since the last one posted.
+// Called from the module's background ticker; counting_recaps makes it restart-safe.
+func (m *Module) postDueRecaps(s *discordgo.Session) {
+	if m.db == nil || s == nil {
+		return
+	}
+
+	now := time.Now().In(recapLocation())
+
+	for _, ch := range []string{m.countingChannelID, m.triosChannelID} {
+		if ch == "" {
+			continue
+		}
+		for _, period := range []string{recapDaily, recapWeekly} {
+			lastStart, hasLast, err := m.lastRecapStart(ch, period)
+			if err != nil {
+				log.Printf("[counting] recap check failed: %v", err)
+				return
+			}
+
+			for _, p := range periodsToRecap(now, period, lastStart, hasLast) {
+				if err := m.postRecap(s, ch, period, p[0], p[1]); err != nil {
+					log.Printf("[counting] recap post failed (channel=%s period=%s): %v", ch, period, err)
+					break // retried next tick; later periods wait so none are skipped
+				}
+			}
+		}
+	}
+}
+
+func (m *Module) postRecap(s *discordgo.Session, channelID, period string, start, end time.Time) error {
+	stats, err := m.recapStatsBetween(channelID, start.Unix(), end.Unix())
+	if err != nil {
+		return err
+	}
+
+	// Quiet period: mark it done without spamming the channel.
+	if stats.Counted > 0 || stats.Ruins > 0 {
+		embed, err := m.buildRecapEmbed(channelID, period, start, end, stats)
+		if err != nil {
+			return err
+		}
+		if _, err := s.ChannelMessageSendEmbed(channelID, embed); err != nil {
+			return err
+		}
+	}
+
+	_, err = m.db.Exec(
+		`INSERT OR IGNORE INTO counting_recaps (channel_id, period, period_start, posted_at)
+		 VALUES (?, ?, ?, ?);`,
+		channelID, period, start.Unix(), time.Now().Unix(),
+	)
+	return err
+}
+
+func (m *Module) buildRecapEmbed(channelID, period string, start, end time.Time, stats recapStats) (*discordgo.MessageEmbed, error) {
+	var lastCount, highScore int64
+	_ = m.db.QueryRow(`SELECT last_count FROM counting_state WHERE channel_id = ?;`, channelID).Scan(&lastCount)
+	_ = m.db.QueryRow(`SELECT high_score FROM counting_channel_stats WHERE channel_id = ?;`, channelID).Scan(&highScore)
+
+	title := fmt.Sprintf("📊 Daily recap — %s (%s)", m.channelLabel(channelID), start.Format("Mon 02 Jan"))
+	if period == recapWeekly {
+		title = fmt.Sprintf("📊 Weekly recap — %s (%s – %s)", m.channelLabel(channelID), start.Format("02 Jan"), end.AddDate(0, 0, -1).Format("02 Jan"))
+	}
+
+	top := "Nobody counted."
+	if len(stats.Top) > 0 {
+		lines := make([]string, 0, len(stats.Top))
+		for idx, r := range stats.Top {
+			lines = append(lines, fmt.Sprintf("**#%d** <@%s>, **%d**", idx+1, r.UserID, r.Counts))
+		}
+		top = strings.Join(lines, "\n")
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: title,
+		Color: 0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Numbers counted", Value: fmt.Sprintf("**%d**", stats.Counted), Inline: true},
+			{Name: "Ruins", Value: fmt.Sprintf("**%d**", stats.Ruins), Inline: true},
+			{Name: "Current / High score", Value: fmt.Sprintf("**%d** / **%d**", lastCount, highScore), Inline: true},
+			{Name: "Top contributors", Value: top},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	goal, err := m.getGoal(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if goal != nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Goal",
+			Value: formatGoalProgress(*goal, lastCount),
+		})
+	}
+
+	return embed, nil
+}
+
+/* =========================
+   DB helpers
+   ========================= */
+
+// lastRecapStart is the start of the newest recap recorded for channelID and period.
+func (m *Module) lastRecapStart(channelID, period string) (time.Time, bool, error) {
+	var start sql.NullInt64
+	err := m.db.QueryRow(
+		`SELECT MAX(period_start) FROM counting_recaps WHERE channel_id = ? AND period = ?;`,
+		channelID, period,
+	).Scan(&start)
+	if err != nil || !start.Valid {
+		return time.Time{}, false, err
+	}
+	return time.Unix(start.Int64, 0).In(recapLocation()), true, nil
+}
+
+// recapStatsBetween buckets counting_history into [start, end).
+func (m *Module) recapStatsBetween(channelID string, start, end int64) (recapStats, error) {
+	var st recapStats
+
+	err := m.db.QueryRow(
+		`SELECT
+			COALESCE(SUM(CASE WHEN event = ? THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN event = ? AND count > 0 THEN 1 ELSE 0 END), 0)
+		 FROM counting_history
+		 WHERE channel_id = ? AND created_at >= ? AND created_at < ?;`,
+		historyEventCount, historyEventRuin, channelID, start, end,
+	).Scan(&st.Counted, &st.Ruins)
+	if err != nil {
+		return st, err
+	}
+
+	rows, err := m.db.Query(
+		`SELECT user_id, MAX(username), COUNT(*) AS c
+		 FROM counting_history
+		 WHERE channel_id = ? AND event = ? AND created_at >= ? AND created_at < ?
+		 GROUP BY user_id
+		 ORDER BY c DESC
+		 LIMIT ?;`,
+		channelID, historyEventCount, start, end, recapTopN,
+	)
+	if err != nil {
+		return st, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r lbRow
+		if err := rows.Scan(&r.UserID, &r.Username, &r.Counts); err != nil {
+			return st, err
+		}
+		st.Top = append(st.Top, r)
+	}
+	return st, rows.Err()
+}
+
+func (m *Module) getGoal(channelID string) (*countingGoal, error) {
+	if m.db == nil {
+		return nil, sql.ErrConnDone
+	}
+	var g countingGoal
+	err := m.db.QueryRow(
+		`SELECT channel_id, target, deadline, set_by, created_at
+		 FROM counting_goals
+		 WHERE channel_id = ?;`,
+		channelID,
+	).Scan(&g.ChannelID, &g.Target, &g.Deadline, &g.SetBy, &g.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (m *Module) setGoal(g countingGoal) error {
+	if m.db == nil {
+		return sql.ErrConnDone
+	}
+	_, err := m.db.Exec(
+		`INSERT INTO counting_goals (channel_id, target, deadline, set_by, created_at)
+		 VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT(channel_id) DO UPDATE SET
+			target = excluded.target,
+			deadline = excluded.deadline,
+			set_by = excluded.set_by,
+			created_at = excluded.created_at;`,
+		g.ChannelID, g.Target, g.Deadline, g.SetBy, g.CreatedAt,
+	)
+	return err
+}
+
+func (m *Module) clearGoal(channelID string) (int64, error) {
+	if m.db == nil {
+		return 0, sql.ErrConnDone
+	}
+	res, err := m.db.Exec(`DELETE FROM counting_goals WHERE channel_id = ?;`, channelID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func formatGoalProgress(g countingGoal, current int64) string {
+	pct := int64(0)
+	if g.Target > 0 {
+		pct = current * 100 / g.Target
+	}
+	if pct > 100 {
+		pct = 100
+	}
+
+	out := fmt.Sprintf("Reach **%d**: **%d** / **%d** (%d%%)", g.Target, current, g.Target, pct)
+	switch {
+	case current >= g.Target:
+		out += "\n✅ Goal reached!"
+	case g.Deadline > 0 && time.Now().Unix() > g.Deadline:
+		out += fmt.Sprintf("\n❌ Deadline passed <t:%d:R>", g.Deadline)
+	case g.Deadline > 0:
+		out += fmt.Sprintf("\n⏳ Deadline <t:%d:F> (<t:%d:R>)", g.Deadline, g.Deadline)
+	}
+	return out
+}
+
+/* =========================
+   /countinggoal set|clear|show
+   ========================= */
+
+func (m *Module) handleCountingGoal(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if len(data.Options) == 0 || data.Options[0] == nil {
+		respondEphemeral(s, i, "Missing subcommand.")
+		return
+	}
+	sub := data.Options[0]
+
+	target := int64(0)
+	by := ""
+	channelChoice := ""
+	for _, opt := range sub.Options {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "target":
+			target = opt.IntValue()
+		case "by":
+			if v, ok := opt.Value.(string); ok {
+				by = strings.TrimSpace(v)
+			}
+		case "channel":
+			if v, ok := opt.Value.(string); ok {
+				channelChoice = v
+			}
+		}
+	}
+
+	channelID := m.resolveChannelChoice(channelChoice, i.ChannelID)
+	if channelID == "" {
+		respondEphemeral(s, i, "Pick a channel option (counting / counting-trios), or run the command inside one of the counting channels.")
+		return
+	}
+
+	switch sub.Name {
+	case "set":
+		if !isStaff(i) {
+			respondEphemeral(s, i, "You need **Manage Server** to use this.")
+			return
+		}
+		if target <= 0 {
+			respondEphemeral(s, i, "Target must be **greater than 0**.")
+			return
+		}
+
+		deadline := int64(0)
+		if by != "" {
+			day, err := time.ParseInLocation("2006-01-02", by, recapLocation())
+			if err != nil {
+				respondEphemeral(s, i, "Deadline must look like `2026-03-27` (UK time).")
+				return
+			}
+			// End of that day
+			deadline = day.AddDate(0, 0, 1).Unix() - 1
+		}
+
+		g := countingGoal{
+			ChannelID: channelID,
+			Target:    target,
+			Deadline:  deadline,
+			SetBy:     interactionUserID(i),
+			CreatedAt: time.Now().Unix(),
+		}
+		if err := m.setGoal(g); err != nil {
+			log.Printf("[counting] goal set failed: %v", err)
+			respondEphemeral(s, i, "DB error saving goal.")
+			return
+		}
+
+		var current int64
+		_ = m.db.QueryRow(`SELECT last_count FROM counting_state WHERE channel_id = ?;`, channelID).Scan(&current)
+		respondEphemeral(s, i, "✅ Goal set for "+m.channelLabel(channelID)+".\n"+formatGoalProgress(g, current))
+
+	case "clear":
+		if !isStaff(i) {
+			respondEphemeral(s, i, "You need **Manage Server** to use this.")
+			return
+		}
+		n, err := m.clearGoal(channelID)
+		if err != nil {
+			log.Printf("[counting] goal clear failed: %v", err)
+			respondEphemeral(s, i, "DB error clearing goal.")
+			return
+		}
+		if n == 0 {
+			respondEphemeral(s, i, "There is no goal set for "+m.channelLabel(channelID)+".")
+			return
+		}
+		respondEphemeral(s, i, "✅ Cleared the goal for "+m.channelLabel(channelID)+".")
+
+	case "show":
+		g, err := m.getGoal(channelID)
+		if err != nil {
+			respondEphemeral(s, i, "DB error reading goal.")
+			return
+		}
+		if g == nil {
+			respondEphemeral(s, i, "There is no goal set for "+m.channelLabel(channelID)+".")
+			return
+		}
+		var current int64
+		_ = m.db.QueryRow(`SELECT last_count FROM counting_state WHERE channel_id = ?;`, channelID).Scan(&current)
+		respondEphemeral(s, i, "🎯 Goal for "+m.channelLabel(channelID)+"\n"+formatGoalProgress(*g, current))
+
+	default:
+		respondEphemeral(s, i, "Unknown subcommand.")
+	}
+}
@@ -0,0 +1,35 @@
+package counting
+
+import "fmt"
+
+// LeaderboardEntry is one row of a counting leaderboard, for the web leaderboard (modules/web).
+type LeaderboardEntry struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Counts   int64  `json:"counts"`
+}
+
+// Leaderboard returns the full /leaderboard for scope: "normal", "trios" or "total".
+func (m *Module) Leaderboard(scope string) ([]LeaderboardEntry, error) {
+	var rows []lbRow
+	var err error
+	switch scope {
+	case "", "normal":
+		rows, err = m.fetchLeaderboard("channel", m.countingChannelID)
+	case "trios":
+		rows, err = m.fetchLeaderboard("channel", m.triosChannelID)
+	case "total":
+		rows, err = m.fetchLeaderboard("total", "")
+	default:
+		return nil, fmt.Errorf("unknown counting scope %q", scope)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]LeaderboardEntry, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, LeaderboardEntry{UserID: r.UserID, Username: r.Username, Counts: r.Counts})
+	}
+	return out, nil
+}
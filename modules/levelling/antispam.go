@@ -0,0 +1,410 @@
+package levelling
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// XPRules decide which messages earn XP at all, and how much rapid chatting is worth.
+type XPRules struct {
+	// Members with any of these roles, or these users, never earn XP (/xpblock adds more at runtime)
+	BlockedRoles []string
+	BlockedUsers []string
+
+	// Minimum visible characters / words, ignoring mentions, custom emoji and links (0 = off)
+	MinChars int
+	MinWords int
+
+	// Same text (case/spacing/punctuation ignored) from the same user within this window earns nothing (0 = off)
+	DuplicateWindow time.Duration
+
+	// Diminishing returns: after RapidFree messages inside RapidWindow, each extra message
+	// multiplies XP by RapidDecay, never below RapidFloor (RapidWindow 0 = off)
+	RapidWindow time.Duration
+	RapidFree   int
+	RapidDecay  float64
+	RapidFloor  float64
+}
+
+const (
+	blockKindUser = "user"
+	blockKindRole = "role"
+
+	// Remembered per user for duplicate checks
+	spamMaxHashes = 8
+	// Drop idle users from memory once the map gets this big
+	spamPruneAt = 2000
+)
+
+var reDiscordToken = regexp.MustCompile(`<a?:\w+:\d+>|<[@#][!&]?\d+>|https?://\S+`)
+
+type spamState struct {
+	recent []time.Time // message times inside RapidWindow
+	hashes []spamHash
+}
+
+type spamHash struct {
+	sum uint64
+	at  time.Time
+}
+
+type spamTracker struct {
+	mu    sync.Mutex
+	users map[string]*spamState
+}
+
+func normalizeXPRules(r XPRules) XPRules {
+	if r.RapidDecay <= 0 || r.RapidDecay > 1 {
+		r.RapidDecay = 1
+	}
+	if r.RapidFloor < 0 {
+		r.RapidFloor = 0
+	}
+	if r.RapidFree < 0 {
+		r.RapidFree = 0
+	}
+	return r
+}
+
+// visibleText strips mentions, custom emoji and links, which shouldn't count toward length.
+func visibleText(content string) string {
+	return strings.TrimSpace(reDiscordToken.ReplaceAllString(content, " "))
+}
+
+// contentHash ignores case, spacing and punctuation so "lol", "LOL!!" and "l o l" match.
+func contentHash(content string) (uint64, bool) {
+	var b strings.Builder
+	for _, r := range strings.ToLower(content) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return 0, false
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(b.String()))
+	return h.Sum64(), true
+}
+
+// tooShort reports whether a message misses MinChars / MinWords.
+func (m *Module) tooShort(content string) bool {
+	text := visibleText(content)
+	if m.rules.MinChars > 0 {
+		n := 0
+		for _, r := range text {
+			if !unicode.IsSpace(r) {
+				n++
+			}
+		}
+		if n < m.rules.MinChars {
+			return true
+		}
+	}
+	if m.rules.MinWords > 0 && len(strings.Fields(text)) < m.rules.MinWords {
+		return true
+	}
+	return false
+}
+
+// trackMessage records a message for a user and returns whether it's a duplicate
+// and the rapid-chat multiplier. Every message in an XP channel is tracked, including
+// ones still on cooldown, so spamming between XP awards still counts against the user.
+func (m *Module) trackMessage(userID, content string, now time.Time) (duplicate bool, rapid float64) {
+	m.spam.mu.Lock()
+	defer m.spam.mu.Unlock()
+
+	if m.spam.users == nil {
+		m.spam.users = map[string]*spamState{}
+	}
+	if len(m.spam.users) >= spamPruneAt {
+		m.pruneSpamLocked(now)
+	}
+
+	st, ok := m.spam.users[userID]
+	if !ok {
+		st = &spamState{}
+		m.spam.users[userID] = st
+	}
+
+	if w := m.rules.DuplicateWindow; w > 0 {
+		if sum, ok := contentHash(visibleText(content)); ok {
+			kept := st.hashes[:0]
+			for _, h := range st.hashes {
+				if now.Sub(h.at) > w {
+					continue
+				}
+				if h.sum == sum {
+					duplicate = true
+				}
+				kept = append(kept, h)
+			}
+			st.hashes = append(kept, spamHash{sum: sum, at: now})
+			if len(st.hashes) > spamMaxHashes {
+				st.hashes = st.hashes[len(st.hashes)-spamMaxHashes:]
+			}
+		}
+	}
+
+	rapid = 1
+	if w := m.rules.RapidWindow; w > 0 {
+		kept := st.recent[:0]
+		for _, t := range st.recent {
+			if now.Sub(t) <= w {
+				kept = append(kept, t)
+			}
+		}
+		st.recent = append(kept, now)
+
+		if extra := len(st.recent) - m.rules.RapidFree; extra > 0 {
+			rapid = math.Max(math.Pow(m.rules.RapidDecay, float64(extra)), m.rules.RapidFloor)
+		}
+	}
+	return duplicate, rapid
+}
+
+func (m *Module) pruneSpamLocked(now time.Time) {
+	keep := m.rules.DuplicateWindow
+	if m.rules.RapidWindow > keep {
+		keep = m.rules.RapidWindow
+	}
+	for id, st := range m.spam.users {
+		last := time.Time{}
+		if n := len(st.recent); n > 0 {
+			last = st.recent[n-1]
+		}
+		if n := len(st.hashes); n > 0 && st.hashes[n-1].at.After(last) {
+			last = st.hashes[n-1].at
+		}
+		if now.Sub(last) > keep {
+			delete(m.spam.users, id)
+		}
+	}
+}
+
+/* =========================
+   Blocklist (main.go + xp_blocklist)
+   ========================= */
+
+// xpBlocked reports whether a user (or one of their roles) is on the no-XP list.
+func (m *Module) xpBlocked(userID string, roles []string) bool {
+	for _, id := range m.rules.BlockedUsers {
+		if id == userID {
+			return true
+		}
+	}
+	for _, r := range roles {
+		for _, id := range m.rules.BlockedRoles {
+			if id == r {
+				return true
+			}
+		}
+	}
+
+	if m.db == nil {
+		return false
+	}
+	args := []any{blockKindUser, userID}
+	q := `SELECT 1 FROM xp_blocklist WHERE (kind = ? AND target_id = ?)`
+	if len(roles) > 0 {
+		q += ` OR (kind = ? AND target_id IN (?` + strings.Repeat(",?", len(roles)-1) + `))`
+		args = append(args, blockKindRole)
+		for _, r := range roles {
+			args = append(args, r)
+		}
+	}
+	var one int
+	return m.db.QueryRow(q+` LIMIT 1`, args...).Scan(&one) == nil
+}
+
+type blockRow struct {
+	Kind     string
+	TargetID string
+	AddedBy  string
+	AddedAt  int64
+}
+
+func (m *Module) addXPBlock(kind, targetID, addedBy string) error {
+	_, err := m.db.Exec(
+		`INSERT INTO xp_blocklist(kind, target_id, added_by, added_at)
+		 VALUES(?,?,?,?)
+		 ON CONFLICT(kind, target_id) DO NOTHING`,
+		kind, targetID, addedBy, time.Now().Unix(),
+	)
+	return err
+}
+
+func (m *Module) removeXPBlock(kind, targetID string) (bool, error) {
+	res, err := m.db.Exec(`DELETE FROM xp_blocklist WHERE kind = ? AND target_id = ?`, kind, targetID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func (m *Module) listXPBlocks() ([]blockRow, error) {
+	rows, err := m.db.Query(`SELECT kind, target_id, added_by, added_at FROM xp_blocklist ORDER BY added_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []blockRow
+	for rows.Next() {
+		var r blockRow
+		if err := rows.Scan(&r.Kind, &r.TargetID, &r.AddedBy, &r.AddedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+/* =========================
+   /xpblock add|remove|list
+   ========================= */
+
+func (m *Module) handleXPBlock(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isLevellingAdmin(i) {
+		m.respondEphemeral(s, i, "You need **Manage Server** (or Administrator) to use this.")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 || data.Options[0] == nil {
+		m.respondEphemeral(s, i, "Missing subcommand.")
+		return
+	}
+	sub := data.Options[0]
+
+	if sub.Name == "list" {
+		m.respondXPBlockList(s, i)
+		return
+	}
+
+	kind, targetID := "", ""
+	for _, opt := range sub.Options {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "user":
+			if u := opt.UserValue(nil); u != nil {
+				kind, targetID = blockKindUser, u.ID
+			}
+		case "role":
+			if r := opt.RoleValue(nil, ""); r != nil {
+				kind, targetID = blockKindRole, r.ID
+			}
+		}
+	}
+	if targetID == "" {
+		m.respondEphemeral(s, i, "Pick a **user** or a **role**.")
+		return
+	}
+
+	mention := "<@" + targetID + ">"
+	if kind == blockKindRole {
+		mention = "<@&" + targetID + ">"
+	}
+
+	switch sub.Name {
+	case "add":
+		if err := m.addXPBlock(kind, targetID, interactionUserID(i)); err != nil {
+			log.Printf("[levelling] add xp block failed: %v", err)
+			m.respondEphemeral(s, i, "DB error saving the block.")
+			return
+		}
+		m.respondEphemeral(s, i, fmt.Sprintf("🚫 %s no longer earns XP.", mention))
+
+	case "remove":
+		removed, err := m.removeXPBlock(kind, targetID)
+		if err != nil {
+			log.Printf("[levelling] remove xp block failed: %v", err)
+			m.respondEphemeral(s, i, "DB error removing the block.")
+			return
+		}
+		if !removed {
+			m.respondEphemeral(s, i, fmt.Sprintf("%s isn't on the /xpblock list (blocks set in the bot config can't be removed here).", mention))
+			return
+		}
+		m.respondEphemeral(s, i, fmt.Sprintf("✅ %s can earn XP again.", mention))
+
+	default:
+		m.respondEphemeral(s, i, "Unknown subcommand.")
+	}
+}
+
+func (m *Module) respondXPBlockList(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	rows, err := m.listXPBlocks()
+	if err != nil {
+		log.Printf("[levelling] list xp blocks failed: %v", err)
+		m.respondEphemeral(s, i, "DB error reading the block list.")
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("**Blocked (bot config)**\n")
+	if len(m.rules.BlockedRoles)+len(m.rules.BlockedUsers) == 0 {
+		b.WriteString("None\n")
+	}
+	for _, id := range m.rules.BlockedRoles {
+		fmt.Fprintf(&b, "• <@&%s>\n", id)
+	}
+	for _, id := range m.rules.BlockedUsers {
+		fmt.Fprintf(&b, "• <@%s>\n", id)
+	}
+
+	b.WriteString("\n**Blocked (/xpblock)**\n")
+	if len(rows) == 0 {
+		b.WriteString("None\n")
+	}
+	for n, r := range rows {
+		if n == 30 {
+			fmt.Fprintf(&b, "…and %d more\n", len(rows)-n)
+			break
+		}
+		mention := "<@" + r.TargetID + ">"
+		if r.Kind == blockKindRole {
+			mention = "<@&" + r.TargetID + ">"
+		}
+		fmt.Fprintf(&b, "• %s — by <@%s> <t:%d:R>\n", mention, r.AddedBy, r.AddedAt)
+	}
+
+	b.WriteString("\n**Message rules**\n")
+	fmt.Fprintf(&b, "Min length: **%d** chars, **%d** words\n", m.rules.MinChars, m.rules.MinWords)
+	if m.rules.DuplicateWindow > 0 {
+		fmt.Fprintf(&b, "Duplicates ignored within **%s**\n", m.rules.DuplicateWindow)
+	} else {
+		b.WriteString("Duplicate check: off\n")
+	}
+	if m.rules.RapidWindow > 0 {
+		fmt.Fprintf(&b, "After **%d** msgs in **%s**: ×%s each extra (min ×%s)\n",
+			m.rules.RapidFree, m.rules.RapidWindow, fmtFloat(m.rules.RapidDecay), fmtFloat(m.rules.RapidFloor))
+	} else {
+		b.WriteString("Rapid-message decay: off\n")
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "No-XP list & anti-spam rules",
+				Description: b.String(),
+				Color:       0x5865F2,
+			}},
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
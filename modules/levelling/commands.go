@@ -20,18 +20,84 @@ func (m *Module) onReady(s *discordgo.Session, r *discordgo.Ready) {
 		{
 			Name:        "rank",
 			Description: "Show a user's level and XP",
-			Options: []*discordgo.ApplicationCommandOption{
+			Options: append([]*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionUser,
 					Name:        "user",
 					Description: "User to look up (defaults to you)",
 					Required:    false,
 				},
-			},
+			}, scopeOptions()...),
 		},
 		{
 			Name:        "leaderboard",
 			Description: "Show the top XP users",
+			Options:     scopeOptions(),
+		},
+		{
+			Name:        "rankcard",
+			Description: "Customise the card /rank shows for you",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "set",
+					Description: "Change your card's colours or background image",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "background",
+							Description: "Background colour, e.g. #2B2D31 (or \"default\")",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "accent",
+							Description: "XP bar / level colour, e.g. #5865F2 (or \"default\")",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "text",
+							Description: "Name / rank colour, e.g. #FFFFFF (or \"default\")",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionAttachment,
+							Name:        "image",
+							Description: "Background image (cropped to fit; max 8 MB)",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "clear_image",
+							Description: "Remove your background image",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "preview",
+					Description: "Show your current card (only you can see it)",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "reset",
+					Description: "Go back to the default theme",
+				},
+			},
+		},
+		{
+			Name:        "levelnotify",
+			Description: "Turn announcements of your level-ups on or off",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "enabled",
+					Description: "Announce my level-ups (leave empty to see your current setting)",
+					Required:    false,
+				},
+			},
 		},
 		{
 			Name:        "joins",
@@ -48,6 +114,36 @@ func (m *Module) onReady(s *discordgo.Session, r *discordgo.Ready) {
 						{Name: "monthly", Value: "monthly"},
 					},
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "invite",
+					Description: "Only joins through this invite (code or link)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "inviter",
+					Description: "Only joins through this member's invites",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "invites",
+			Description: "Show who has brought the most people into the server",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "range",
+					Description: "Time range (default: monthly)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "daily", Value: "daily"},
+						{Name: "weekly", Value: "weekly"},
+						{Name: "monthly", Value: "monthly"},
+						{Name: "all time", Value: "all"},
+					},
+				},
 			},
 		},
 
@@ -72,6 +168,31 @@ func (m *Module) onReady(s *discordgo.Session, r *discordgo.Ready) {
 			},
 		},
 
+		{
+			Name:        "retention",
+			Description: "Admin: how many of each week's joiners stay, and who never finished onboarding",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "view",
+					Description: "What to show (default: weekly retention)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "weekly retention", Value: "weeks"},
+						{Name: "never finished onboarding", Value: "not_onboarded"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "weeks",
+					Description: "How many weeks back (default 8, max 12)",
+					Required:    false,
+					MinValue:    float64Ptr(1),
+					MaxValue:    retentionMaxWeeks,
+				},
+			},
+		},
+
 		// REQUIRED options must come first
 		{
 			Name:        "levelupmsg",
@@ -99,6 +220,64 @@ func (m *Module) onReady(s *discordgo.Session, r *discordgo.Ready) {
 			},
 		},
 
+		{
+			Name:        "levelupmsgs",
+			Description: "Page through every saved level-up message for a user",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "User to look up (defaults to you)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "visible",
+					Description: "If true, makes the response visible to everyone (default: only you)",
+					Required:    false,
+				},
+			},
+		},
+
+		{
+			Name:        "levelupmsgsearch",
+			Description: "Search the text of saved level-up messages",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "query",
+					Description: "Words to look for (the last one can be the start of a word)",
+					Required:    true,
+					MaxLength:   lumSearchMaxLen,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Only search this user's messages",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "visible",
+					Description: "If true, makes the response visible to everyone (default: only you)",
+					Required:    false,
+				},
+			},
+		},
+
+		{
+			Name:        "throwback",
+			Description: "Show a random saved level-up message from the past",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Only pick from this user's messages",
+					Required:    false,
+				},
+			},
+		},
+
 		{
 			Name:        "levelupmsgset",
 			Description: "Admin: set the message for a user's level-up (backfill older levels)",
@@ -147,14 +326,25 @@ func (m *Module) onReady(s *discordgo.Session, r *discordgo.Ready) {
 
 		{
 			Name:        "milestonesync",
-			Description: "Admin: backfill milestone roles based on current XP (stack roles; no removals)",
+			Description: "Admin: bring milestone roles in line with current XP (dry run lists every change)",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionBoolean,
 					Name:        "dry_run",
-					Description: "If true, shows what would happen without changing roles",
+					Description: "If true, lists each add/remove without changing roles",
 					Required:    false,
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "Role mode to sync with (default: the configured one)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "stack (add only)", Value: string(MilestoneStack)},
+						{Name: "replace (highest only)", Value: string(MilestoneReplace)},
+						{Name: "strict (remove above level)", Value: string(MilestoneStrict)},
+					},
+				},
 				{
 					Type:        discordgo.ApplicationCommandOptionInteger,
 					Name:        "limit",
@@ -164,6 +354,287 @@ func (m *Module) onReady(s *discordgo.Session, r *discordgo.Ready) {
 				},
 			},
 		},
+
+		{
+			Name:        "xpcurve",
+			Description: "Admin: view, preview or switch the XP level curve",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "show",
+					Description: "Show the current curve for levels 1–100",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "preview",
+					Description: "Compare a new curve with the current one (changes nothing)",
+					Options:     curveOptions(),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "set",
+					Description: "Switch to a new curve and recompute everyone's level",
+					Options:     curveOptions(),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "reset",
+					Description: "Go back to the default curve from the bot config",
+				},
+			},
+		},
+
+		{
+			Name:        "xpboost",
+			Description: "Show XP multipliers, or start/stop a timed global XP boost (admin)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "start",
+					Description: "Admin: multiply all XP for a limited time (replaces any running boost)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionNumber,
+							Name:        "multiplier",
+							Description: "e.g. 2 for double XP",
+							Required:    true,
+							MinValue:    float64Ptr(0.1),
+							MaxValue:    boostMaxMultiplier,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionNumber,
+							Name:        "hours",
+							Description: "How long the boost lasts (e.g. 48 for a weekend)",
+							Required:    true,
+							MinValue:    float64Ptr(0.1),
+							MaxValue:    boostMaxHours,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "reason",
+							Description: "Optional name shown in the announcement (e.g. Double XP weekend)",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "stop",
+					Description: "Admin: end the running boost early",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "status",
+					Description: "Show the running boost and channel/role multipliers",
+				},
+			},
+		},
+
+		{
+			Name:        "xpblock",
+			Description: "Admin: stop users or roles from earning XP",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Block a user or role from earning XP",
+					Options:     blockTargetOptions(),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Let a user or role earn XP again",
+					Options:     blockTargetOptions(),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show blocked users/roles and the anti-spam rules",
+				},
+			},
+		},
+
+		{
+			Name:        "xp",
+			Description: "Admin: adjust or reset XP (changes are logged)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "give",
+					Description: "Add XP to a user",
+					Options:     xpAdjustOptions("XP to add"),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "take",
+					Description: "Remove XP from a user (won't go below 0)",
+					Options:     xpAdjustOptions("XP to remove"),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "set",
+					Description: "Set a user's total XP",
+					Options:     xpAdjustOptions("New total XP"),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "reset",
+					Description: "Reset one user's XP, or everyone's if no user is given (asks to confirm)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        "user",
+							Description: "User to reset (leave empty for everyone)",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "remove_roles",
+							Description: "Also remove milestone roles (stack mode; replace/strict always do)",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "history",
+					Description: "Show recent XP changes made to a user",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        "user",
+							Description: "User to look up",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+
+		{
+			Name:        "xpimport",
+			Description: "Admin: import XP from another levelling bot's export (preview first)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "file",
+					Description: "Preview an import from a JSON or CSV file (user id + xp or level)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionAttachment,
+							Name:        "file",
+							Description: "Exported leaderboard (JSON or CSV, max 10 MB)",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "source",
+							Description: "Which bot it came from (decides how XP maps onto our curve)",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "MEE6", Value: xpImportSourceMEE6},
+								{Name: "Arcane", Value: xpImportSourceArcane},
+								{Name: "Tatsu", Value: xpImportSourceTatsu},
+								{Name: "Other (level, else XP as-is)", Value: xpImportSourceGeneric},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "mode",
+							Description: "How imported XP combines with current XP (default: max)",
+							Required:    false,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "max (keep the higher)", Value: xpImportModeMax},
+								{Name: "sum (add them)", Value: xpImportModeSum},
+								{Name: "replace (use imported)", Value: xpImportModeReplace},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "rollback",
+					Description: "Undo the most recent import (asks to confirm)",
+				},
+			},
+		},
+		{
+			Name:        "season",
+			Description: "XP seasons: view the current or past seasons, or start/end one (admin)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "start",
+					Description: "Admin: start a season that ends (and archives XP) after N days",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "days",
+							Description: "Season length in days",
+							Required:    true,
+							MinValue:    float64Ptr(1),
+							MaxValue:    366,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "name",
+							Description: "Season name (default: Season <number>)",
+							Required:    false,
+						},
+						{
+							Type:         discordgo.ApplicationCommandOptionChannel,
+							Name:         "channel",
+							Description:  "Where to post the final standings (default: here)",
+							Required:     false,
+							ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "top_n",
+							Description: "How many top users get the reward role",
+							Required:    false,
+							MinValue:    float64Ptr(0),
+							MaxValue:    25,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionRole,
+							Name:        "reward_role",
+							Description: "Role for the top N (moves from last season's winners)",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "keep_percent",
+							Description: "Soft reset: % of XP everyone keeps (default 0 = full reset)",
+							Required:    false,
+							MinValue:    float64Ptr(0),
+							MaxValue:    100,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "renew",
+							Description: "Start another season of the same length when this one ends",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "end",
+					Description: "Admin: end the current season now (asks to confirm)",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "status",
+					Description: "Show the current season",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "List finished seasons",
+				},
+			},
+		},
 	}
 
 	created, err := s.ApplicationCommandBulkOverwrite(appID, m.guildID, cmds)
@@ -182,12 +653,25 @@ func (m *Module) onReady(s *discordgo.Session, r *discordgo.Ready) {
 	for _, name := range []string{
 		"rank",
 		"leaderboard",
+		"rankcard",
+		"levelnotify",
 		"joins",
+		"invites",
 		"joinsbackfill",
+		"retention",
 		"levelupmsg",
+		"levelupmsgs",
+		"levelupmsgsearch",
+		"throwback",
 		"levelupmsgset",
 		"levelupmsgdelete",
 		"milestonesync",
+		"xpcurve",
+		"xpboost",
+		"xpblock",
+		"xp",
+		"xpimport",
+		"season",
 	} {
 		if _, ok := createdNames[name]; ok {
 			log.Printf("[levelling] registered /%s", name)
@@ -199,18 +683,140 @@ func (m *Module) onReady(s *discordgo.Session, r *discordgo.Ready) {
 		_ = m.deleteGlobalDuplicatesOnce(s, appID, map[string]struct{}{
 			"rank":             {},
 			"leaderboard":      {},
+			"rankcard":         {},
+			"levelnotify":      {},
 			"joins":            {},
+			"invites":          {},
 			"joinsbackfill":    {},
+			"retention":        {},
 			"levelupmsg":       {},
+			"levelupmsgs":      {},
+			"levelupmsgsearch": {},
+			"throwback":        {},
 			"levelupmsgset":    {},
 			"levelupmsgdelete": {},
 			"milestonesync":    {},
+			"xpcurve":          {},
+			"xpboost":          {},
+			"xpblock":          {},
+			"xp":               {},
+			"xpimport":         {},
+			"season":           {},
 		})
 	}
 }
 
 func float64Ptr(v float64) *float64 { return &v }
 
+// curveOptions are shared by /xpcurve preview and /xpcurve set.
+func curveOptions() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "kind",
+			Description: "Curve type",
+			Required:    true,
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "polynomial (c0,c1,c2,… → c0 + c1·L + c2·L²…)", Value: CurvePolynomial},
+				{Name: "exponential (base,growth → base × growth^L)", Value: CurveExponential},
+				{Name: "table (XP per level: 100,170,250,…)", Value: CurveTable},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "values",
+			Description: "Comma-separated numbers, e.g. 100,60,7 (the default curve)",
+			Required:    true,
+		},
+	}
+}
+
+// scopeOptions are shared by /rank and /leaderboard (time period or past season).
+func scopeOptions() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "scope",
+			Description: "Time period (default: all time)",
+			Required:    false,
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "all time", Value: xpScopeAll},
+				{Name: "this week", Value: xpScopeWeek},
+				{Name: "this month", Value: xpScopeMonth},
+				{Name: "custom (use from/to)", Value: xpScopeCustom},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "from",
+			Description: "Custom scope start date, YYYY-MM-DD (UK time)",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "to",
+			Description: "Custom scope end date, inclusive (default: today)",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "season",
+			Description: "Show a finished season's final standings (see /season list)",
+			Required:    false,
+			MinValue:    float64Ptr(1),
+		},
+	}
+}
+
+// xpAdjustOptions are shared by /xp give, take and set.
+func xpAdjustOptions(amountDesc string) []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "User to change",
+			Required:    true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "amount",
+			Description: amountDesc,
+			Required:    true,
+			MinValue:    float64Ptr(0),
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "remove_roles",
+			Description: "If the level drops, remove roles above it (stack mode; replace/strict always do)",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "reason",
+			Description: "Optional note for the XP history",
+			Required:    false,
+		},
+	}
+}
+
+// blockTargetOptions are shared by /xpblock add and /xpblock remove.
+func blockTargetOptions() []*discordgo.ApplicationCommandOption {
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "User to block/unblock",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionRole,
+			Name:        "role",
+			Description: "Role to block/unblock",
+			Required:    false,
+		},
+	}
+}
+
 func (m *Module) deleteGlobalDuplicatesOnce(s *discordgo.Session, appID string, names map[string]struct{}) error {
 	cmds, err := s.ApplicationCommands(appID, "")
 	if err != nil {
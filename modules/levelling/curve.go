@@ -0,0 +1,206 @@
+package levelling
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	CurvePolynomial  = "polynomial"
+	CurveExponential = "exponential"
+	CurveTable       = "table"
+
+	// Levels precomputed in the cumulative table; anything above stays at the cap.
+	curveMaxLevel = 1000
+)
+
+// Curve describes how much XP it takes to go from level L to L+1.
+type Curve struct {
+	Kind string `json:"kind"`
+
+	// polynomial: Coeffs[0] + Coeffs[1]·L + Coeffs[2]·L² + …
+	Coeffs []float64 `json:"coeffs,omitempty"`
+
+	// exponential: Base · Growth^L
+	Base   float64 `json:"base,omitempty"`
+	Growth float64 `json:"growth,omitempty"`
+
+	// table: explicit XP per level (Steps[0] = level 0 -> 1); the last step repeats.
+	Steps []int64 `json:"steps,omitempty"`
+}
+
+// DefaultCurve is the original 7L² + 60L + 100.
+var DefaultCurve = Curve{Kind: CurvePolynomial, Coeffs: []float64{100, 60, 7}}
+
+// step returns the XP needed to go from level to level+1 (before validation; may be <1 or huge).
+func (c Curve) step(level int) float64 {
+	l := float64(level)
+	switch c.Kind {
+	case CurveExponential:
+		return c.Base * math.Pow(c.Growth, l)
+	case CurveTable:
+		if len(c.Steps) == 0 {
+			return 0
+		}
+		if level >= len(c.Steps) {
+			return float64(c.Steps[len(c.Steps)-1])
+		}
+		return float64(c.Steps[level])
+	default:
+		v, p := 0.0, 1.0
+		for _, k := range c.Coeffs {
+			v += k * p
+			p *= l
+		}
+		return v
+	}
+}
+
+func (c Curve) String() string {
+	switch c.Kind {
+	case CurveExponential:
+		return fmt.Sprintf("exponential: %s × %s^L", fmtFloat(c.Base), fmtFloat(c.Growth))
+	case CurveTable:
+		parts := make([]string, 0, len(c.Steps))
+		for _, v := range c.Steps {
+			parts = append(parts, strconv.FormatInt(v, 10))
+		}
+		s := strings.Join(parts, ", ")
+		if len(s) > 120 {
+			s = s[:120] + "…"
+		}
+		return fmt.Sprintf("table (%d levels): %s", len(c.Steps), s)
+	default:
+		terms := make([]string, 0, len(c.Coeffs))
+		for p := len(c.Coeffs) - 1; p >= 0; p-- {
+			k := c.Coeffs[p]
+			if k == 0 {
+				continue
+			}
+			switch p {
+			case 0:
+				terms = append(terms, fmtFloat(k))
+			case 1:
+				terms = append(terms, fmtFloat(k)+"L")
+			default:
+				terms = append(terms, fmt.Sprintf("%sL^%d", fmtFloat(k), p))
+			}
+		}
+		return "polynomial: " + strings.Join(terms, " + ")
+	}
+}
+
+func fmtFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// parseCurve builds a curve from the /xpcurve options.
+//
+//	polynomial  "100,60,7"        (c0, c1, c2, …)
+//	exponential "100,1.15"        (base, growth)
+//	table       "100,170,250,…"   (XP for level 0->1, 1->2, …)
+func parseCurve(kind, values string) (Curve, error) {
+	var nums []float64
+	for _, part := range strings.FieldsFunc(values, func(r rune) bool { return r == ',' || r == ' ' || r == ';' }) {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return Curve{}, fmt.Errorf("%q is not a number", part)
+		}
+		nums = append(nums, v)
+	}
+	if len(nums) == 0 {
+		return Curve{}, errors.New("no values given")
+	}
+
+	c := Curve{Kind: kind}
+	switch kind {
+	case CurvePolynomial:
+		c.Coeffs = nums
+	case CurveExponential:
+		if len(nums) != 2 {
+			return Curve{}, errors.New("exponential needs exactly 2 values: base, growth")
+		}
+		c.Base, c.Growth = nums[0], nums[1]
+	case CurveTable:
+		for _, v := range nums {
+			c.Steps = append(c.Steps, int64(math.Round(v)))
+		}
+	default:
+		return Curve{}, fmt.Errorf("unknown curve kind %q", kind)
+	}
+	return c, nil
+}
+
+func marshalCurve(c Curve) (string, error) {
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+func unmarshalCurve(s string) (Curve, error) {
+	var c Curve
+	err := json.Unmarshal([]byte(s), &c)
+	return c, err
+}
+
+/* =========================
+   Precomputed level table
+   ========================= */
+
+// levelCurve caches cumulative XP per level so lookups are a binary search.
+type levelCurve struct {
+	curve Curve
+	cum   []int64 // cum[L] = total XP needed to reach level L (cum[0] = 0)
+}
+
+func newLevelCurve(c Curve) (*levelCurve, error) {
+	cum := make([]int64, 1, curveMaxLevel+1)
+	for lvl := 0; lvl < curveMaxLevel; lvl++ {
+		step := c.step(lvl)
+		if math.IsNaN(step) || step < 1 {
+			return nil, fmt.Errorf("level %d -> %d would need %s XP (must be at least 1)", lvl, lvl+1, fmtFloat(math.Round(step)))
+		}
+		next := float64(cum[lvl]) + math.Round(step)
+		if next >= math.MaxInt64/2 {
+			break // stop before overflow; very steep curves just cap lower
+		}
+		cum = append(cum, int64(next))
+	}
+	if len(cum) < 2 {
+		return nil, errors.New("curve doesn't reach level 1")
+	}
+	return &levelCurve{curve: c, cum: cum}, nil
+}
+
+// maxLevel is the highest level the table reaches.
+func (lc *levelCurve) maxLevel() int { return len(lc.cum) - 1 }
+
+// totalFor returns the total XP needed to reach level.
+func (lc *levelCurve) totalFor(level int) int64 {
+	if level <= 0 {
+		return 0
+	}
+	if level > lc.maxLevel() {
+		level = lc.maxLevel()
+	}
+	return lc.cum[level]
+}
+
+// breakdown returns level, xp into the current level, and xp needed for the next one.
+func (lc *levelCurve) breakdown(totalXP int64) (int, int64, int64) {
+	if totalXP <= 0 {
+		return 0, 0, lc.cum[1]
+	}
+	lvl := sort.Search(len(lc.cum), func(i int) bool { return lc.cum[i] > totalXP }) - 1
+	if lvl >= lc.maxLevel() {
+		lvl = lc.maxLevel()
+		// Maxed out: show a full bar, however much XP is past the cap
+		last := lc.cum[lvl] - lc.cum[lvl-1]
+		return lvl, min(totalXP-lc.cum[lvl], last), last
+	}
+	return lvl, totalXP - lc.cum[lvl], lc.cum[lvl+1] - lc.cum[lvl]
+}
@@ -21,16 +21,42 @@ func (m *Module) onInteractionCreate(s *discordgo.Session, i *discordgo.Interact
 			m.handleLeaderboard(s, i)
 		case "joins":
 			m.handleJoins(s, i)
+		case "invites":
+			m.handleInvites(s, i)
 		case "joinsbackfill":
 			m.handleJoinsBackfill(s, i)
+		case "retention":
+			m.handleRetention(s, i)
 		case "levelupmsg":
 			m.handleLevelUpMsg(s, i)
+		case "levelupmsgs":
+			m.handleLevelUpMsgs(s, i)
+		case "levelupmsgsearch":
+			m.handleLevelUpMsgSearch(s, i)
+		case "throwback":
+			m.handleThrowback(s, i)
 		case "levelupmsgset":
 			m.handleLevelUpMsgSet(s, i)
 		case "levelupmsgdelete":
 			m.handleLevelUpMsgDelete(s, i)
 		case "milestonesync":
 			m.handleMilestoneSync(s, i)
+		case "xpcurve":
+			m.handleXPCurve(s, i)
+		case "xpboost":
+			m.handleXPBoost(s, i)
+		case "xpblock":
+			m.handleXPBlock(s, i)
+		case "xp":
+			m.handleXPAdmin(s, i)
+		case "season":
+			m.handleSeason(s, i)
+		case "rankcard":
+			m.handleRankCard(s, i)
+		case "levelnotify":
+			m.handleLevelNotify(s, i)
+		case "xpimport":
+			m.handleXPImport(s, i)
 		}
 
 	case discordgo.InteractionMessageComponent:
@@ -43,6 +69,22 @@ func (m *Module) onInteractionCreate(s *discordgo.Session, i *discordgo.Interact
 			m.handleJoinsComponent(s, i)
 			return
 		}
+		if strings.HasPrefix(cid, lumGalleryCustomBase+":") || strings.HasPrefix(cid, lumSearchCustomBase+":") || strings.HasPrefix(cid, throwbackCustomBase+":") {
+			m.handleLevelUpMsgsComponent(s, i)
+			return
+		}
+		if strings.HasPrefix(cid, xpResetCustomBase+":") {
+			m.handleXPResetComponent(s, i)
+			return
+		}
+		if strings.HasPrefix(cid, xpImportCustomBase+":") {
+			m.handleXPImportComponent(s, i)
+			return
+		}
+		if strings.HasPrefix(cid, seasonCustomBase+":") {
+			m.handleSeasonComponent(s, i)
+			return
+		}
 	}
 }
 
@@ -59,6 +101,14 @@ func interactionUserID(i *discordgo.InteractionCreate) string {
 	return ""
 }
 
+// isLevellingAdmin: Manage Server or Administrator
+func isLevellingAdmin(i *discordgo.InteractionCreate) bool {
+	if i == nil || i.Member == nil {
+		return false
+	}
+	return i.Member.Permissions&(discordgo.PermissionManageGuild|discordgo.PermissionAdministrator) != 0
+}
+
 func (m *Module) respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
 	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
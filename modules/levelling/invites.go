@@ -0,0 +1,210 @@
+package levelling
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long a deleted invite can still be credited: Discord deletes an invite the moment it
+// reaches max uses, and the delete can arrive before the join it was used for.
+const inviteDeletedGrace = 30 * time.Second
+
+// inviteSnapshot is an invite's use count as last seen.
+type inviteSnapshot struct {
+	Uses      int
+	MaxUses   int
+	InviterID string
+	deletedAt time.Time
+}
+
+// joinSource is the invite a join came through; empty when it couldn't be worked out.
+type joinSource struct {
+	Code      string
+	InviterID string
+}
+
+// inviteTracker works out which invite each join used by diffing use counts before and after.
+// Discord doesn't say, so a join is only credited when exactly one invite moved.
+type inviteTracker struct {
+	// Held across the GuildInvites fetch so joins are diffed one at a time
+	mu      sync.Mutex
+	guildID string
+	ready   bool
+	current map[string]inviteSnapshot
+	deleted map[string]inviteSnapshot
+
+	// Uses seen on an invite beyond the join that was credited (two joins landed before one
+	// fetch); a later join that sees no change is credited to it.
+	unclaimed map[string]int
+}
+
+func (m *Module) onGuildCreateInvites(s *discordgo.Session, e *discordgo.GuildCreate) {
+	if e == nil || e.Guild == nil || e.Guild.Unavailable || !m.isOurGuild(e.Guild.ID) {
+		return
+	}
+
+	invites, err := s.GuildInvites(e.Guild.ID)
+	if err != nil {
+		log.Printf("[levelling] read invites failed (join sources need Manage Server): %v", err)
+		return
+	}
+
+	t := &m.invites
+	t.mu.Lock()
+	t.guildID = e.Guild.ID
+	t.replace(invites)
+	t.deleted = map[string]inviteSnapshot{}
+	t.unclaimed = map[string]int{}
+	t.ready = true
+	t.mu.Unlock()
+}
+
+func (m *Module) onInviteCreate(s *discordgo.Session, e *discordgo.InviteCreate) {
+	if e == nil || e.Invite == nil || !m.isOurGuild(e.GuildID) {
+		return
+	}
+	t := &m.invites
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.ready || e.GuildID != t.guildID {
+		return
+	}
+	t.current[e.Code] = snapshotOf(e.Invite)
+}
+
+func (m *Module) onInviteDelete(s *discordgo.Session, e *discordgo.InviteDelete) {
+	if e == nil || !m.isOurGuild(e.GuildID) {
+		return
+	}
+	t := &m.invites
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.ready || e.GuildID != t.guildID {
+		return
+	}
+	if snap, ok := t.current[e.Code]; ok {
+		snap.deletedAt = time.Now()
+		t.deleted[e.Code] = snap
+		delete(t.current, e.Code)
+	}
+}
+
+// joinSourceFor re-reads the guild's invites after a join and credits the one whose uses went up.
+func (m *Module) joinSourceFor(s *discordgo.Session, guildID string) joinSource {
+	t := &m.invites
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.ready || guildID != t.guildID {
+		return joinSource{}
+	}
+
+	invites, err := s.GuildInvites(guildID)
+	if err != nil {
+		log.Printf("[levelling] read invites after join failed: %v", err)
+		return joinSource{}
+	}
+	return t.diff(invites, time.Now())
+}
+
+// diff compares invites with the snapshot, takes them as the new snapshot, and returns the source
+// if it's unambiguous.
+func (t *inviteTracker) diff(invites []*discordgo.Invite, now time.Time) joinSource {
+	type moved struct {
+		code  string
+		snap  inviteSnapshot
+		delta int
+	}
+	var candidates []moved
+
+	seen := make(map[string]struct{}, len(invites))
+	for _, inv := range invites {
+		if inv == nil {
+			continue
+		}
+		seen[inv.Code] = struct{}{}
+		prev := t.current[inv.Code] // missing = created since the snapshot with no uses yet
+		if inv.Uses > prev.Uses {
+			candidates = append(candidates, moved{inv.Code, snapshotOf(inv), inv.Uses - prev.Uses})
+		}
+	}
+
+	// Invites deleted since: one sitting a use short of its limit was used up by this join
+	for code, snap := range t.deleted {
+		if _, back := seen[code]; back || now.Sub(snap.deletedAt) > inviteDeletedGrace {
+			delete(t.deleted, code)
+			continue
+		}
+		if snap.MaxUses > 0 && snap.Uses+1 >= snap.MaxUses {
+			candidates = append(candidates, moved{code, snap, 1})
+		}
+	}
+	// Vanished without a delete event (expired) while we weren't looking: same check
+	for code, snap := range t.current {
+		if _, ok := seen[code]; !ok && snap.MaxUses > 0 && snap.Uses+1 >= snap.MaxUses {
+			candidates = append(candidates, moved{code, snap, 1})
+		}
+	}
+
+	t.replace(invites)
+
+	switch len(candidates) {
+	case 0:
+		// Another join may already have seen this one's use
+		if len(t.unclaimed) == 1 {
+			for code, n := range t.unclaimed {
+				src := joinSource{Code: code, InviterID: t.inviterOf(code)}
+				if n <= 1 {
+					delete(t.unclaimed, code)
+				} else {
+					t.unclaimed[code] = n - 1
+				}
+				return src
+			}
+		}
+		return joinSource{}
+	case 1:
+		c := candidates[0]
+		if c.delta > 1 {
+			t.unclaimed[c.code] += c.delta - 1
+		}
+		delete(t.deleted, c.code)
+		return joinSource{Code: c.code, InviterID: c.snap.InviterID}
+	}
+
+	// Several invites moved at once: can't tell who used which
+	t.unclaimed = map[string]int{}
+	return joinSource{}
+}
+
+// replace takes invites as the snapshot.
+func (t *inviteTracker) replace(invites []*discordgo.Invite) {
+	t.current = make(map[string]inviteSnapshot, len(invites))
+	for _, inv := range invites {
+		if inv != nil {
+			t.current[inv.Code] = snapshotOf(inv)
+		}
+	}
+}
+
+func (t *inviteTracker) inviterOf(code string) string {
+	if snap, ok := t.current[code]; ok {
+		return snap.InviterID
+	}
+	return t.deleted[code].InviterID
+}
+
+func snapshotOf(inv *discordgo.Invite) inviteSnapshot {
+	snap := inviteSnapshot{Uses: inv.Uses, MaxUses: inv.MaxUses}
+	if inv.Inviter != nil {
+		snap.InviterID = inv.Inviter.ID
+	}
+	return snap
+}
+
+// isOurGuild: levelling is single-server; with no guild configured, any guild counts.
+func (m *Module) isOurGuild(guildID string) bool {
+	return m.guildID == "" || guildID == m.guildID
+}
@@ -0,0 +1,136 @@
+package levelling
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const invitesBoardSize = 15
+
+// inviterTotal is one row of /invites.
+type inviterTotal struct {
+	InviterID string
+	Joins     int
+	Stayed    int
+	Codes     map[string]struct{}
+}
+
+func (m *Module) handleInvites(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if strings.TrimSpace(i.GuildID) == "" {
+		m.respondEphemeral(s, i, "This command only works in a server.")
+		return
+	}
+
+	rangeOpt := "monthly"
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt != nil && opt.Name == "range" {
+			rangeOpt = opt.StringValue()
+		}
+	}
+
+	now := time.Now().In(ukLocation())
+	var from int64
+	if rangeOpt != "all" {
+		from = startOfRange(now, rangeOpt).Unix()
+	}
+
+	rows, err := m.listInvitedJoins(from)
+	if err != nil {
+		log.Printf("[levelling] invites read failed: %v", err)
+		m.respondEphemeral(s, i, "DB error reading joins.")
+		return
+	}
+	unknown, err := m.countUnknownJoins(from)
+	if err != nil {
+		log.Printf("[levelling] invites read failed: %v", err)
+	}
+
+	stayedKnown := m.tracker != nil && m.tracker.Ready()
+	board := inviterTotals(rows, func(userID string) bool {
+		return stayedKnown && m.tracker.IsMember(userID)
+	})
+	if len(board) == 0 {
+		m.respondEphemeral(s, i, "No joins with a known invite for this range yet. Invites are tracked from when the bot can see them (it needs **Manage Server**).")
+		return
+	}
+
+	var b strings.Builder
+	for idx, t := range board {
+		if idx == invitesBoardSize {
+			break
+		}
+		fmt.Fprintf(&b, "%d. <@%s> — **%d** joined", idx+1, t.InviterID, t.Joins)
+		if stayedKnown {
+			fmt.Fprintf(&b, ", **%d** still here", t.Stayed)
+		}
+		if len(t.Codes) > 1 {
+			fmt.Fprintf(&b, " · %d invites", len(t.Codes))
+		}
+		b.WriteString("\n")
+	}
+
+	footer := fmt.Sprintf("%d joins from a known invite · %d unknown (vanity link, or the bot was offline)", len(rows), unknown)
+	if stayedKnown {
+		footer = "Ranked by who's still here · " + footer
+	}
+
+	title := "Top inviters — All time"
+	if rangeOpt != "all" {
+		title = fmt.Sprintf("Top inviters — %s", strings.ToUpper(rangeOpt[:1])+rangeOpt[1:])
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "📨 " + title,
+				Description: b.String(),
+				Color:       0x5865F2,
+				Footer:      &discordgo.MessageEmbedFooter{Text: footer},
+				Timestamp:   now.Format(time.RFC3339),
+			}},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// inviterTotals groups joins by inviter, best first: most still here (so alts that leave
+// don't count), then most joins.
+func inviterTotals(rows []joinRow, stillHere func(userID string) bool) []inviterTotal {
+	byInviter := map[string]*inviterTotal{}
+	for _, r := range rows {
+		if r.UserID == r.InviterID {
+			continue // rejoined through their own invite
+		}
+		t := byInviter[r.InviterID]
+		if t == nil {
+			t = &inviterTotal{InviterID: r.InviterID, Codes: map[string]struct{}{}}
+			byInviter[r.InviterID] = t
+		}
+		t.Joins++
+		if stillHere(r.UserID) {
+			t.Stayed++
+		}
+		t.Codes[r.InviteCode] = struct{}{}
+	}
+
+	out := make([]inviterTotal, 0, len(byInviter))
+	for _, t := range byInviter {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].Stayed != out[b].Stayed {
+			return out[a].Stayed > out[b].Stayed
+		}
+		if out[a].Joins != out[b].Joins {
+			return out[a].Joins > out[b].Joins
+		}
+		return out[a].InviterID < out[b].InviterID
+	})
+	return out
+}
@@ -25,8 +25,9 @@ func (m *Module) onGuildMemberAdd(s *discordgo.Session, e *discordgo.GuildMember
 
 	joinedAt := time.Now().Unix()
 	username := e.Member.User.Username
+	src := m.joinSourceFor(s, e.GuildID)
 
-	if err := m.upsertUserJoin(e.Member.User.ID, username, joinedAt); err != nil {
+	if err := m.recordUserJoin(e.Member.User.ID, username, joinedAt, src); err != nil {
 		log.Printf("[levelling] upsert join failed: %v", err)
 	}
 }
@@ -38,11 +39,22 @@ func (m *Module) handleJoins(s *discordgo.Session, i *discordgo.InteractionCreat
 	}
 
 	rangeOpt := ""
+	var filter joinFilter
 	for _, opt := range i.ApplicationCommandData().Options {
-		if opt != nil && opt.Name == "range" {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "range":
 			if v, ok := opt.Value.(string); ok {
 				rangeOpt = strings.ToLower(strings.TrimSpace(v))
 			}
+		case "invite":
+			filter.Code = normalizeInviteCode(opt.StringValue())
+		case "inviter":
+			if u := opt.UserValue(nil); u != nil {
+				filter.InviterID = u.ID
+			}
 		}
 	}
 	if rangeOpt != "daily" && rangeOpt != "weekly" && rangeOpt != "monthly" {
@@ -56,12 +68,16 @@ func (m *Module) handleJoins(s *discordgo.Session, i *discordgo.InteractionCreat
 		return
 	}
 
-	content, embed, comps, err := m.buildJoinsPage(rangeOpt, ownerID, 0)
+	content, embed, comps, err := m.buildJoinsPage(rangeOpt, ownerID, 0, filter)
 	if err != nil {
 		m.respondEphemeral(s, i, "DB error reading joins.")
 		return
 	}
 	if embed == nil {
+		if filter != (joinFilter{}) {
+			m.respondEphemeral(s, i, "No joins through that invite for this range.")
+			return
+		}
 		m.respondEphemeral(s, i, "No joins recorded for this range yet.")
 		return
 	}
@@ -82,15 +98,19 @@ func (m *Module) handleJoinsComponent(s *discordgo.Session, i *discordgo.Interac
 	}
 	data := i.MessageComponentData()
 
-	// expected: jn:<ownerID>:<range>:<action>:<page>
+	// expected: jn:<ownerID>:<range>:<action>:<page>[:<invite>:<inviterID>]
 	parts := strings.Split(data.CustomID, ":")
-	if len(parts) != 5 || parts[0] != jnCustomID {
+	if (len(parts) != 5 && len(parts) != 7) || parts[0] != jnCustomID {
 		return
 	}
 	ownerID := parts[1]
 	rangeOpt := parts[2]
 	action := parts[3]
 	pageStr := parts[4]
+	var filter joinFilter
+	if len(parts) == 7 {
+		filter = joinFilter{Code: parts[5], InviterID: parts[6]}
+	}
 
 	clickerID := interactionUserID(i)
 	if clickerID == "" {
@@ -119,7 +139,7 @@ func (m *Module) handleJoinsComponent(s *discordgo.Session, i *discordgo.Interac
 	})
 
 	// Load all rows for this range (cap to 1000 so the bot can't be forced into huge responses)
-	rows, note, err := m.getJoinsRows(rangeOpt, 1000)
+	rows, note, err := m.getJoinsRows(rangeOpt, 1000, filter)
 	if err != nil {
 		msg := "DB error reading joins."
 		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
@@ -159,7 +179,7 @@ func (m *Module) handleJoinsComponent(s *discordgo.Session, i *discordgo.Interac
 		targetPage = maxPage
 	}
 
-	content, embed, comps := buildJoinsPageFromRows(rangeOpt, ownerID, targetPage, rows, note)
+	content, embed, comps := m.buildJoinsPageFromRows(rangeOpt, ownerID, targetPage, rows, note, filter)
 	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 		Content:    &content,
 		Embeds:     &[]*discordgo.MessageEmbed{embed},
@@ -167,26 +187,26 @@ func (m *Module) handleJoinsComponent(s *discordgo.Session, i *discordgo.Interac
 	})
 }
 
-func (m *Module) buildJoinsPage(rangeOpt, ownerID string, page int) (string, *discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
-	rows, note, err := m.getJoinsRows(rangeOpt, 1000)
+func (m *Module) buildJoinsPage(rangeOpt, ownerID string, page int, filter joinFilter) (string, *discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	rows, note, err := m.getJoinsRows(rangeOpt, 1000, filter)
 	if err != nil {
 		return "", nil, nil, err
 	}
 	if len(rows) == 0 {
 		return "", nil, nil, nil
 	}
-	content, embed, comps := buildJoinsPageFromRows(rangeOpt, ownerID, page, rows, note)
+	content, embed, comps := m.buildJoinsPageFromRows(rangeOpt, ownerID, page, rows, note, filter)
 	return content, embed, comps, nil
 }
 
-func (m *Module) getJoinsRows(rangeOpt string, capLimit int) ([]joinRow, string, error) {
+func (m *Module) getJoinsRows(rangeOpt string, capLimit int, filter joinFilter) ([]joinRow, string, error) {
 	loc, _ := time.LoadLocation("Europe/London")
 	now := time.Now().In(loc)
 
 	start := startOfRange(now, rangeOpt)
 	end := now
 
-	rows, err := m.listJoinsBetween(start.Unix(), end.Unix(), capLimit)
+	rows, err := m.listJoinsBetween(start.Unix(), end.Unix(), capLimit, filter)
 	if err != nil {
 		return nil, "", err
 	}
@@ -198,7 +218,7 @@ func (m *Module) getJoinsRows(rangeOpt string, capLimit int) ([]joinRow, string,
 	return rows, note, nil
 }
 
-func buildJoinsPageFromRows(rangeOpt, ownerID string, page int, rows []joinRow, note string) (string, *discordgo.MessageEmbed, []discordgo.MessageComponent) {
+func (m *Module) buildJoinsPageFromRows(rangeOpt, ownerID string, page int, rows []joinRow, note string, filter joinFilter) (string, *discordgo.MessageEmbed, []discordgo.MessageComponent) {
 	total := len(rows)
 	maxPage := (total - 1) / jnPageSize
 	if page < 0 {
@@ -218,15 +238,22 @@ func buildJoinsPageFromRows(rangeOpt, ownerID string, page int, rows []joinRow,
 	endRank := end
 
 	loc, _ := time.LoadLocation("Europe/London")
+	status := m.joinStatus(rows[offset:end])
 
 	var b strings.Builder
 	for idx := offset; idx < end; idx++ {
 		r := rows[idx]
 		t := time.Unix(r.JoinedAt, 0).In(loc)
-		fmt.Fprintf(&b, "%d. <@%s> — %s\n", startRank+(idx-offset), r.UserID, t.Format("02 Jan 15:04"))
+		fmt.Fprintf(&b, "%d. <@%s> — %s%s%s\n", startRank+(idx-offset), r.UserID, t.Format("02 Jan 15:04"), joinVia(r, filter), status[r.UserID])
 	}
 
 	title := fmt.Sprintf("Joins — %s", strings.ToUpper(rangeOpt[:1])+rangeOpt[1:])
+	if filter.Code != "" {
+		title += fmt.Sprintf(" · invite %s", filter.Code)
+	}
+	if filter.InviterID != "" {
+		fmt.Fprintf(&b, "\nInvited by <@%s>", filter.InviterID)
+	}
 
 	embed := &discordgo.MessageEmbed{
 		Title:       title,
@@ -238,13 +265,45 @@ func buildJoinsPageFromRows(rangeOpt, ownerID string, page int, rows []joinRow,
 	}
 
 	content := strings.TrimSpace(note)
-	comps := joinsButtons(ownerID, rangeOpt, page, maxPage)
+	comps := joinsButtons(ownerID, rangeOpt, page, maxPage, filter)
 	return content, embed, comps
 }
 
-func joinsButtons(ownerID, rangeOpt string, page, maxPage int) []discordgo.MessageComponent {
+// joinStatus is a suffix per user: rejoin count, and whether they've left since (from the member tracker).
+func (m *Module) joinStatus(rows []joinRow) map[string]string {
+	out := map[string]string{}
+	if m.tracker == nil {
+		return out
+	}
+	ids := make([]string, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.UserID)
+	}
+	counts, err := m.tracker.JoinCounts(ids)
+	if err != nil {
+		log.Printf("[levelling] read join counts failed: %v", err)
+	}
+	ready := m.tracker.Ready()
+	for _, id := range ids {
+		var suffix string
+		if n := counts[id]; n > 1 {
+			suffix += fmt.Sprintf(" · ↻ joined %d times", n)
+		}
+		if ready && !m.tracker.IsMember(id) {
+			suffix += " · 🚪 left"
+		}
+		out[id] = suffix
+	}
+	return out
+}
+
+func joinsButtons(ownerID, rangeOpt string, page, maxPage int, filter joinFilter) []discordgo.MessageComponent {
 	makeID := func(action string) string {
-		return fmt.Sprintf("%s:%s:%s:%s:%d", jnCustomID, ownerID, rangeOpt, action, page)
+		id := fmt.Sprintf("%s:%s:%s:%s:%d", jnCustomID, ownerID, rangeOpt, action, page)
+		if filter != (joinFilter{}) {
+			id += fmt.Sprintf(":%s:%s", filter.Code, filter.InviterID)
+		}
+		return id
 	}
 	row := discordgo.ActionsRow{
 		Components: []discordgo.MessageComponent{
@@ -257,6 +316,37 @@ func joinsButtons(ownerID, rangeOpt string, page, maxPage int) []discordgo.Messa
 	return []discordgo.MessageComponent{row}
 }
 
+// joinVia names the invite a join came through, leaving out what the filter already says.
+func joinVia(r joinRow, filter joinFilter) string {
+	showCode := r.InviteCode != "" && filter.Code == ""
+	showBy := r.InviterID != "" && filter.InviterID == ""
+	switch {
+	case showCode && showBy:
+		return fmt.Sprintf(" · via `%s` (<@%s>)", r.InviteCode, r.InviterID)
+	case showCode:
+		return fmt.Sprintf(" · via `%s`", r.InviteCode)
+	case showBy:
+		return fmt.Sprintf(" · by <@%s>", r.InviterID)
+	}
+	return ""
+}
+
+// normalizeInviteCode accepts a bare code or a full invite link.
+func normalizeInviteCode(v string) string {
+	v = strings.TrimSpace(v)
+	v = strings.TrimSuffix(v, "/")
+	if idx := strings.LastIndex(v, "/"); idx >= 0 {
+		v = v[idx+1:]
+	}
+	// Codes are letters, digits and dashes; dropping anything else keeps the button IDs parseable
+	return strings.Map(func(r rune) rune {
+		if r == '-' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return r
+		}
+		return -1
+	}, v)
+}
+
 // renamed to avoid colliding with rank_leaderboard.go's loadingButtons()
 func (m *Module) joinsLoadingButtons() []discordgo.MessageComponent {
 	return []discordgo.MessageComponent{
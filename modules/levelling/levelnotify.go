@@ -0,0 +1,227 @@
+package levelling
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// LevelUpMode is where level-up announcements go.
+type LevelUpMode string
+
+const (
+	// The channel the XP was earned in (voice XP: the voice channel's text chat)
+	LevelUpHere LevelUpMode = "here"
+	// LevelUpNotify.ChannelID
+	LevelUpChannel LevelUpMode = "channel"
+	// A DM to the user (falls back to "here" if their DMs are closed)
+	LevelUpDM LevelUpMode = "dm"
+	// No announcements at all
+	LevelUpSilent LevelUpMode = "silent"
+)
+
+// LevelUpNotify configures level-up announcements. Users can still opt out with /levelnotify.
+type LevelUpNotify struct {
+	Mode      LevelUpMode
+	ChannelID string
+
+	// Only announce levels that have a milestone role
+	MilestonesOnly bool
+
+	// Ping the user (the embed alone never pings)
+	Mention bool
+}
+
+func normalizeLevelUpNotify(n LevelUpNotify) LevelUpNotify {
+	n.Mode = LevelUpMode(strings.ToLower(strings.TrimSpace(string(n.Mode))))
+	n.ChannelID = strings.TrimSpace(n.ChannelID)
+
+	switch n.Mode {
+	case "":
+		n.Mode = LevelUpHere
+	case LevelUpHere, LevelUpDM, LevelUpSilent:
+	case LevelUpChannel:
+		if n.ChannelID == "" {
+			log.Printf("[levelling] level-up mode %q needs a ChannelID; announcing in place", n.Mode)
+			n.Mode = LevelUpHere
+		}
+	default:
+		log.Printf("[levelling] unknown level-up mode %q; announcing in place", n.Mode)
+		n.Mode = LevelUpHere
+	}
+	return n
+}
+
+/* =========================
+   Opt-outs (DB)
+   ========================= */
+
+func (m *Module) levelUpOptedOut(userID string) (bool, error) {
+	var one int
+	err := m.db.QueryRow(`SELECT 1 FROM levelup_notify_optout WHERE user_id = ?`, userID).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *Module) setLevelUpOptOut(userID string, optOut bool) error {
+	var err error
+	if optOut {
+		_, err = m.db.Exec(
+			`INSERT INTO levelup_notify_optout(user_id, created_at) VALUES(?,?)
+			 ON CONFLICT(user_id) DO NOTHING`,
+			userID, time.Now().Unix(),
+		)
+	} else {
+		_, err = m.db.Exec(`DELETE FROM levelup_notify_optout WHERE user_id = ?`, userID)
+	}
+	return err
+}
+
+/* =========================
+   Announcing
+   ========================= */
+
+// milestoneBetween reports whether any milestone role level is in (oldLevel, newLevel].
+func (m *Module) milestoneBetween(oldLevel, newLevel int) bool {
+	for lvl := range m.levelRoles {
+		if lvl > oldLevel && lvl <= newLevel {
+			return true
+		}
+	}
+	return false
+}
+
+// sendLevelUp announces a level-up according to LevelUpNotify and the user's /levelnotify choice.
+// channelID is where the XP was earned.
+func (m *Module) sendLevelUp(s *discordgo.Session, channelID string, user *discordgo.User, oldLevel, newLevel int, footer string) {
+	n := m.notify
+	if n.Mode == LevelUpSilent {
+		return
+	}
+	if n.MilestonesOnly && !m.milestoneBetween(oldLevel, newLevel) {
+		return
+	}
+	if out, err := m.levelUpOptedOut(user.ID); err != nil {
+		log.Printf("[levelling] read level-up opt-out failed: %v", err)
+	} else if out {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "🎉 Level Up!",
+		Description: fmt.Sprintf(
+			"<@%s> just reached\n**Level %d**!",
+			user.ID,
+			newLevel,
+		),
+		Color: 0x5865F2,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: user.AvatarURL("128"),
+		},
+		Footer:    &discordgo.MessageEmbedFooter{Text: footer},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	msg := &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if n.Mention {
+		msg.Content = "<@" + user.ID + ">"
+		msg.AllowedMentions.Users = []string{user.ID}
+	}
+
+	target := channelID
+	switch n.Mode {
+	case LevelUpChannel:
+		target = n.ChannelID
+	case LevelUpDM:
+		// No ping needed in a DM
+		msg.Content = ""
+		embed.Footer.Text += " • /levelnotify to turn these off"
+		if ch, err := s.UserChannelCreate(user.ID); err == nil {
+			if _, err := s.ChannelMessageSendComplex(ch.ID, msg); err == nil {
+				return
+			}
+		}
+		// DMs closed: announce where they earned it instead
+		if n.Mention {
+			msg.Content = "<@" + user.ID + ">"
+		}
+	}
+
+	if _, err := s.ChannelMessageSendComplex(target, msg); err != nil {
+		log.Printf("[levelling] level-up announcement failed (channel=%s): %v", target, err)
+	}
+}
+
+/* =========================
+   /levelnotify
+   ========================= */
+
+func (m *Module) handleLevelNotify(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := interactionUserID(i)
+	if userID == "" {
+		m.respondEphemeral(s, i, "Could not determine user.")
+		return
+	}
+
+	var enabled *bool
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt != nil && opt.Name == "enabled" {
+			v := opt.BoolValue()
+			enabled = &v
+		}
+	}
+
+	if enabled != nil {
+		if err := m.setLevelUpOptOut(userID, !*enabled); err != nil {
+			log.Printf("[levelling] save level-up opt-out failed: %v", err)
+			m.respondEphemeral(s, i, "DB error saving your choice.")
+			return
+		}
+	}
+
+	out, err := m.levelUpOptedOut(userID)
+	if err != nil {
+		m.respondEphemeral(s, i, "DB error reading your choice.")
+		return
+	}
+
+	var msg string
+	switch {
+	case m.notify.Mode == LevelUpSilent:
+		msg = "Level-up announcements are turned off for everyone on this server."
+	case out:
+		msg = "🔕 Your level-ups won't be announced. You still earn XP and milestone roles."
+	default:
+		msg = "🔔 Your level-ups will be announced " + m.levelUpWhere() + "."
+	}
+	if enabled == nil {
+		msg += "\nUse `/levelnotify enabled:false` (or `true`) to change this."
+	}
+	m.respondEphemeral(s, i, msg)
+}
+
+func (m *Module) levelUpWhere() string {
+	where := "in the channel you level up in"
+	switch m.notify.Mode {
+	case LevelUpChannel:
+		where = "in <#" + m.notify.ChannelID + ">"
+	case LevelUpDM:
+		where = "in your DMs"
+	}
+	if m.notify.MilestonesOnly {
+		where += " (milestone levels only)"
+	}
+	return where
+}
@@ -13,6 +13,7 @@ import (
 type levelUpMsgRow struct {
 	UserID    string
 	Username  string
+	Level     int
 	ChannelID string
 	MessageID string
 	Content   string
@@ -26,11 +27,11 @@ type levelUpMsgRow struct {
 func (m *Module) getLevelUpMessage(userID string, level int) (*levelUpMsgRow, error) {
 	var r levelUpMsgRow
 	err := m.db.QueryRow(
-		`SELECT user_id, username, channel_id, message_id, content, created_at
+		`SELECT user_id, username, level, channel_id, message_id, content, created_at
 		 FROM level_up_messages
 		 WHERE user_id = ? AND level = ?`,
 		userID, level,
-	).Scan(&r.UserID, &r.Username, &r.ChannelID, &r.MessageID, &r.Content, &r.CreatedAt)
+	).Scan(&r.UserID, &r.Username, &r.Level, &r.ChannelID, &r.MessageID, &r.Content, &r.CreatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -149,6 +150,13 @@ func (m *Module) handleLevelUpMsg(s *discordgo.Session, i *discordgo.Interaction
 		return
 	}
 
+	embed, components := m.levelUpMsgEmbed(s, guildID, target, row)
+	respondEmbed(embed, components)
+}
+
+// levelUpMsgEmbed renders a saved level-up message, preferring the live message (it may have been
+// edited since) and falling back to the stored copy.
+func (m *Module) levelUpMsgEmbed(s *discordgo.Session, guildID string, target *discordgo.User, row *levelUpMsgRow) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
 	jump := fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, row.ChannelID, row.MessageID)
 
 	// Try to fetch the live message
@@ -202,7 +210,7 @@ func (m *Module) handleLevelUpMsg(s *discordgo.Session, i *discordgo.Interaction
 		}
 	}
 
-	header := fmt.Sprintf("%s • %s • Level %d", displayName, msgTime.Local().Format("02/01/2006 15:04"), level)
+	header := fmt.Sprintf("%s • %s • Level %d", displayName, msgTime.Local().Format("02/01/2006 15:04"), row.Level)
 
 	embed := &discordgo.MessageEmbed{
 		Color: 0x2B2D31,
@@ -230,7 +238,7 @@ func (m *Module) handleLevelUpMsg(s *discordgo.Session, i *discordgo.Interaction
 		}},
 	}
 
-	respondEmbed(embed, components)
+	return embed, components
 }
 
 /* =========================
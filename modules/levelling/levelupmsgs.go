@@ -0,0 +1,527 @@
+package levelling
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	lumPageSize = 8
+
+	// Custom ID prefixes: gallery pages, search pages, throwback re-roll
+	lumGalleryCustomBase = "lug"
+	lumSearchCustomBase  = "lus"
+	throwbackCustomBase  = "tb"
+
+	// Search text rides along in the button custom ID (100 chars max)
+	lumSearchMaxLen = 40
+	lumSnippetRunes = 120
+)
+
+// levelUpMsgHit is a saved message plus, for searches, the matching excerpt.
+type levelUpMsgHit struct {
+	levelUpMsgRow
+	Snippet string
+}
+
+/* =========================
+   DB helpers
+   ========================= */
+
+func (m *Module) countLevelUpMessages(userID string) (int, error) {
+	var n int
+	err := m.db.QueryRow(`SELECT COUNT(*) FROM level_up_messages WHERE user_id = ?`, userID).Scan(&n)
+	return n, err
+}
+
+// listLevelUpMessages pages through userID's saved messages, lowest level first.
+func (m *Module) listLevelUpMessages(userID string, limit, offset int) ([]levelUpMsgHit, error) {
+	rows, err := m.db.Query(
+		`SELECT user_id, username, level, channel_id, message_id, content, created_at, ''
+		 FROM level_up_messages
+		 WHERE user_id = ?
+		 ORDER BY level
+		 LIMIT ? OFFSET ?`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanLevelUpMsgHits(rows)
+}
+
+func (m *Module) countLevelUpMatches(match, userID string) (int, error) {
+	var n int
+	err := m.db.QueryRow(
+		`SELECT COUNT(*) FROM level_up_messages_fts
+		 WHERE level_up_messages_fts MATCH ? AND (? = '' OR user_id = ?)`,
+		match, userID, userID,
+	).Scan(&n)
+	return n, err
+}
+
+// searchLevelUpMessages runs a full-text search (best match first), optionally for one user.
+func (m *Module) searchLevelUpMessages(match, userID string, limit, offset int) ([]levelUpMsgHit, error) {
+	rows, err := m.db.Query(
+		`SELECT l.user_id, l.username, l.level, l.channel_id, l.message_id, l.content, l.created_at,
+		        snippet(level_up_messages_fts, 2, '**', '**', '…', 16)
+		 FROM level_up_messages_fts
+		 JOIN level_up_messages l ON l.user_id = level_up_messages_fts.user_id AND l.level = level_up_messages_fts.level
+		 WHERE level_up_messages_fts MATCH ? AND (? = '' OR level_up_messages_fts.user_id = ?)
+		 ORDER BY rank
+		 LIMIT ? OFFSET ?`,
+		match, userID, userID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanLevelUpMsgHits(rows)
+}
+
+// randomLevelUpMessage picks any saved message (empty userID = anyone's); nil if there are none.
+func (m *Module) randomLevelUpMessage(userID string) (*levelUpMsgRow, error) {
+	var r levelUpMsgRow
+	err := m.db.QueryRow(
+		`SELECT user_id, username, level, channel_id, message_id, content, created_at
+		 FROM level_up_messages
+		 WHERE ? = '' OR user_id = ?
+		 ORDER BY RANDOM()
+		 LIMIT 1`,
+		userID, userID,
+	).Scan(&r.UserID, &r.Username, &r.Level, &r.ChannelID, &r.MessageID, &r.Content, &r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func scanLevelUpMsgHits(rows *sql.Rows) ([]levelUpMsgHit, error) {
+	defer rows.Close()
+	var out []levelUpMsgHit
+	for rows.Next() {
+		var h levelUpMsgHit
+		if err := rows.Scan(&h.UserID, &h.Username, &h.Level, &h.ChannelID, &h.MessageID, &h.Content, &h.CreatedAt, &h.Snippet); err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
+
+// ftsQuery turns what someone typed into an FTS5 query: every word must appear, the last one
+// as a prefix (so "congr" finds "congrats"). Quoting each word keeps FTS5 syntax out of it.
+func ftsQuery(text string) string {
+	var terms []string
+	for _, w := range strings.Fields(text) {
+		w = strings.ReplaceAll(w, `"`, "")
+		if w != "" {
+			terms = append(terms, `"`+w+`"`)
+		}
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+	terms[len(terms)-1] += "*"
+	return strings.Join(terms, " ")
+}
+
+/* =========================
+   /levelupmsgs (gallery)
+   ========================= */
+
+func (m *Module) handleLevelUpMsgs(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	guildID := strings.TrimSpace(i.GuildID)
+	if guildID == "" {
+		m.respondEphemeral(s, i, "This command only works in a server.")
+		return
+	}
+	ownerID := interactionUserID(i)
+	if ownerID == "" {
+		m.respondEphemeral(s, i, "Could not determine user.")
+		return
+	}
+
+	targetID := ownerID
+	visible := false
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "user":
+			if u := opt.UserValue(nil); u != nil {
+				targetID = u.ID
+			}
+		case "visible":
+			visible = opt.BoolValue()
+		}
+	}
+
+	embed, comps, err := m.levelUpGalleryPage(guildID, ownerID, targetID, 0)
+	if err != nil {
+		log.Printf("[levelling] levelupmsgs read failed: %v", err)
+		m.respondEphemeral(s, i, "DB error reading saved level-up messages.")
+		return
+	}
+	if embed == nil {
+		m.respondEphemeral(s, i, fmt.Sprintf("No saved level-up messages for <@%s> yet.", targetID))
+		return
+	}
+	m.respondLevelUpList(s, i, embed, comps, visible)
+}
+
+func (m *Module) levelUpGalleryPage(guildID, ownerID, targetID string, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	total, err := m.countLevelUpMessages(targetID)
+	if err != nil || total == 0 {
+		return nil, nil, err
+	}
+	maxPage := (total - 1) / lumPageSize
+	page = min(max(page, 0), maxPage)
+	hits, err := m.listLevelUpMessages(targetID, lumPageSize, page*lumPageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<@%s>\n\n", targetID)
+	for _, h := range hits {
+		b.WriteString(levelUpListLine(guildID, h, false))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🎉 Level-up messages",
+		Description: b.String(),
+		Color:       0x5865F2,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%d saved · Page %d/%d", total, page+1, maxPage+1),
+		},
+	}
+	comps := levelUpListButtons(func(action string) string {
+		return fmt.Sprintf("%s:%s:%s:%s:%d", lumGalleryCustomBase, ownerID, targetID, action, page)
+	}, page, maxPage)
+	return embed, comps, nil
+}
+
+/* =========================
+   /levelupmsgsearch
+   ========================= */
+
+func (m *Module) handleLevelUpMsgSearch(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	guildID := strings.TrimSpace(i.GuildID)
+	if guildID == "" {
+		m.respondEphemeral(s, i, "This command only works in a server.")
+		return
+	}
+	ownerID := interactionUserID(i)
+	if ownerID == "" {
+		m.respondEphemeral(s, i, "Could not determine user.")
+		return
+	}
+
+	query := ""
+	userID := ""
+	visible := false
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "query":
+			query = strings.Join(strings.Fields(opt.StringValue()), " ")
+		case "user":
+			if u := opt.UserValue(nil); u != nil {
+				userID = u.ID
+			}
+		case "visible":
+			visible = opt.BoolValue()
+		}
+	}
+	if r := []rune(query); len(r) > lumSearchMaxLen {
+		query = strings.TrimSpace(string(r[:lumSearchMaxLen]))
+	}
+	if ftsQuery(query) == "" {
+		m.respondEphemeral(s, i, "Give me some words to search for.")
+		return
+	}
+
+	embed, comps, err := m.levelUpSearchPage(guildID, ownerID, userID, query, 0)
+	if err != nil {
+		log.Printf("[levelling] levelupmsgsearch failed: %v", err)
+		m.respondEphemeral(s, i, "DB error searching level-up messages.")
+		return
+	}
+	if embed == nil {
+		m.respondEphemeral(s, i, fmt.Sprintf("No saved level-up messages match **%s**.", query))
+		return
+	}
+	m.respondLevelUpList(s, i, embed, comps, visible)
+}
+
+func (m *Module) levelUpSearchPage(guildID, ownerID, userID, query string, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	match := ftsQuery(query)
+	total, err := m.countLevelUpMatches(match, userID)
+	if err != nil || total == 0 {
+		return nil, nil, err
+	}
+	maxPage := (total - 1) / lumPageSize
+	page = min(max(page, 0), maxPage)
+	hits, err := m.searchLevelUpMessages(match, userID, lumPageSize, page*lumPageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var b strings.Builder
+	if userID != "" {
+		fmt.Fprintf(&b, "From <@%s>\n\n", userID)
+	}
+	for _, h := range hits {
+		b.WriteString(levelUpListLine(guildID, h, userID == ""))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🔎 Level-up messages matching “%s”", query),
+		Description: b.String(),
+		Color:       0x5865F2,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("%d found · Page %d/%d", total, page+1, maxPage+1),
+		},
+	}
+	comps := levelUpListButtons(func(action string) string {
+		// Query last: it may contain colons
+		return fmt.Sprintf("%s:%s:%s:%s:%d:%s", lumSearchCustomBase, ownerID, userID, action, page, query)
+	}, page, maxPage)
+	return embed, comps, nil
+}
+
+/* =========================
+   /throwback
+   ========================= */
+
+func (m *Module) handleThrowback(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	guildID := strings.TrimSpace(i.GuildID)
+	if guildID == "" {
+		m.respondEphemeral(s, i, "This command only works in a server.")
+		return
+	}
+	ownerID := interactionUserID(i)
+	if ownerID == "" {
+		m.respondEphemeral(s, i, "Could not determine user.")
+		return
+	}
+
+	userID := ""
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt != nil && opt.Name == "user" {
+			if u := opt.UserValue(nil); u != nil {
+				userID = u.ID
+			}
+		}
+	}
+
+	embed, comps, err := m.throwback(s, guildID, ownerID, userID)
+	if err != nil {
+		log.Printf("[levelling] throwback read failed: %v", err)
+		m.respondEphemeral(s, i, "DB error reading saved level-up messages.")
+		return
+	}
+	if embed == nil {
+		if userID != "" {
+			m.respondEphemeral(s, i, fmt.Sprintf("No saved level-up messages for <@%s> yet.", userID))
+			return
+		}
+		m.respondEphemeral(s, i, "No saved level-up messages yet.")
+		return
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          []*discordgo.MessageEmbed{embed},
+			Components:      comps,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// throwback renders a random saved level-up message with a re-roll button next to the jump link.
+func (m *Module) throwback(s *discordgo.Session, guildID, ownerID, userID string) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	row, err := m.randomLevelUpMessage(userID)
+	if err != nil || row == nil {
+		return nil, nil, err
+	}
+
+	target, err := s.User(row.UserID)
+	if err != nil || target == nil {
+		target = &discordgo.User{ID: row.UserID, Username: row.Username}
+	}
+
+	embed, comps := m.levelUpMsgEmbed(s, guildID, target, row)
+	embed.Title = "🕰️ Throwback"
+	if actions, ok := comps[0].(discordgo.ActionsRow); ok {
+		actions.Components = append(actions.Components, discordgo.Button{
+			Style:    discordgo.SecondaryButton,
+			Label:    "🎲 Another",
+			CustomID: fmt.Sprintf("%s:%s:%s", throwbackCustomBase, ownerID, userID),
+		})
+		comps[0] = actions
+	}
+	return embed, comps, nil
+}
+
+/* =========================
+   Shared list rendering + buttons
+   ========================= */
+
+func (m *Module) respondLevelUpList(s *discordgo.Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed, comps []discordgo.MessageComponent, visible bool) {
+	var flags discordgo.MessageFlags
+	if !visible {
+		flags = discordgo.MessageFlagsEphemeral
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:          []*discordgo.MessageEmbed{embed},
+			Components:      comps,
+			Flags:           flags,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// levelUpListLine is one gallery/search entry: level, date, jump link, then the text (or match).
+func levelUpListLine(guildID string, h levelUpMsgHit, showUser bool) string {
+	jump := fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, h.ChannelID, h.MessageID)
+	when := time.Unix(h.CreatedAt, 0).In(ukLocation()).Format("02 Jan 2006")
+
+	text := h.Snippet
+	if text == "" {
+		text = h.Content
+	}
+	text = truncateRunes(strings.Join(strings.Fields(text), " "), lumSnippetRunes)
+
+	who := ""
+	if showUser {
+		who = fmt.Sprintf("<@%s> · ", h.UserID)
+	}
+	return fmt.Sprintf("%s**Level %d** · %s · [jump](%s)\n> %s\n", who, h.Level, when, jump, text)
+}
+
+func levelUpListButtons(makeID func(action string) string, page, maxPage int) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.Button{Style: discordgo.PrimaryButton, Label: "⏮️", CustomID: makeID("top"), Disabled: page == 0},
+			discordgo.Button{Style: discordgo.SecondaryButton, Label: "⬅️", CustomID: makeID("prev"), Disabled: page == 0},
+			discordgo.Button{Style: discordgo.SecondaryButton, Label: "➡️", CustomID: makeID("next"), Disabled: page >= maxPage},
+			discordgo.Button{Style: discordgo.PrimaryButton, Label: "⏭️", CustomID: makeID("last"), Disabled: page >= maxPage},
+		},
+	}}
+}
+
+/* =========================
+   Buttons
+   ========================= */
+
+func (m *Module) handleLevelUpMsgsComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i == nil || i.Message == nil {
+		return
+	}
+	cid := i.MessageComponentData().CustomID
+
+	// lug:<ownerID>:<targetID>:<action>:<page>
+	// lus:<ownerID>:<userID>:<action>:<page>:<query>
+	// tb:<ownerID>:<userID>
+	parts := strings.SplitN(cid, ":", 6)
+	switch {
+	case parts[0] == lumGalleryCustomBase && len(parts) == 5:
+	case parts[0] == lumSearchCustomBase && len(parts) == 6:
+	case parts[0] == throwbackCustomBase && len(parts) == 3:
+	default:
+		return
+	}
+
+	if interactionUserID(i) != parts[1] {
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Only the person who ran this command can use these buttons.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+	guildID := strings.TrimSpace(i.GuildID)
+
+	var (
+		embed *discordgo.MessageEmbed
+		comps []discordgo.MessageComponent
+		err   error
+	)
+	if parts[0] == throwbackCustomBase {
+		// Fetching the live message can be slow; ACK first
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredMessageUpdate,
+		})
+		embed, comps, err = m.throwback(s, guildID, parts[1], parts[2])
+		if err != nil || embed == nil {
+			if err != nil {
+				log.Printf("[levelling] throwback read failed: %v", err)
+			}
+			return
+		}
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Embeds:     &[]*discordgo.MessageEmbed{embed},
+			Components: &comps,
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(parts[4])
+	switch parts[3] {
+	case "top":
+		page = 0
+	case "prev":
+		page--
+	case "next":
+		page++
+	case "last":
+		page = math.MaxInt32 // clamped to the last page
+	}
+
+	if parts[0] == lumGalleryCustomBase {
+		embed, comps, err = m.levelUpGalleryPage(guildID, parts[1], parts[2], page)
+	} else {
+		embed, comps, err = m.levelUpSearchPage(guildID, parts[1], parts[2], parts[5], page)
+	}
+	if err != nil || embed == nil {
+		if err != nil {
+			log.Printf("[levelling] level-up message page failed: %v", err)
+		}
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:    "Nothing to show any more.",
+				Embeds:     []*discordgo.MessageEmbed{},
+				Components: []discordgo.MessageComponent{},
+			},
+		})
+		return
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: comps,
+		},
+	})
+}
@@ -3,29 +3,57 @@ package levelling
 import (
 	"errors"
 	"strings"
-	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-// getGuildMemberIDSet returns a set of user IDs currently in the guild.
-// It caches results briefly to avoid hammering the API when paging leaderboards.
+// getGuildMemberIDSet returns a set of user IDs currently in the guild, from the shared member
+// tracker. Without a tracker (nil in main.go) it pages through the member list every call.
 func (m *Module) getGuildMemberIDSet(s *discordgo.Session, guildID string) (map[string]struct{}, error) {
 	guildID = strings.TrimSpace(guildID)
+	if m.tracker != nil {
+		if !m.tracker.Ready() {
+			return nil, errors.New("member list is still loading")
+		}
+		return m.tracker.IDs(), nil
+	}
 	if s == nil || guildID == "" {
 		return nil, errors.New("missing session or guildID")
 	}
 
-	m.members.mu.Lock()
-	// cache TTL
-	if m.members.guildID == guildID && m.members.ids != nil && time.Since(m.members.fetched) < 2*time.Minute {
-		ids := m.members.ids
-		m.members.mu.Unlock()
-		return ids, nil
+	members, err := fetchGuildMembers(s, guildID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]struct{}, len(members))
+	for _, mem := range members {
+		ids[mem.User.ID] = struct{}{}
+	}
+	return ids, nil
+}
+
+// guildMembers is every member with their roles: the tracker's copy when it has one,
+// otherwise fetched from Discord.
+func (m *Module) guildMembers(s *discordgo.Session, guildID string) ([]*discordgo.Member, error) {
+	if m.tracker == nil || !m.tracker.Ready() {
+		return fetchGuildMembers(s, guildID)
+	}
+	tracked := m.tracker.Members()
+	out := make([]*discordgo.Member, 0, len(tracked))
+	for _, mem := range tracked {
+		out = append(out, &discordgo.Member{
+			GuildID: guildID,
+			User:    &discordgo.User{ID: mem.ID, Username: mem.Username},
+			Nick:    mem.Nick,
+			Roles:   mem.Roles,
+		})
 	}
-	m.members.mu.Unlock()
+	return out, nil
+}
 
-	ids := map[string]struct{}{}
+// fetchGuildMembers pages through every member of the guild (entries without a user are dropped).
+func fetchGuildMembers(s *discordgo.Session, guildID string) ([]*discordgo.Member, error) {
+	var out []*discordgo.Member
 	after := ""
 	for {
 		members, err := s.GuildMembers(guildID, after, 1000)
@@ -39,19 +67,12 @@ func (m *Module) getGuildMemberIDSet(s *discordgo.Session, guildID string) (map[
 			if mem == nil || mem.User == nil || mem.User.ID == "" {
 				continue
 			}
-			ids[mem.User.ID] = struct{}{}
+			out = append(out, mem)
 			after = mem.User.ID
 		}
 		if len(members) < 1000 {
 			break
 		}
 	}
-
-	m.members.mu.Lock()
-	m.members.guildID = guildID
-	m.members.ids = ids
-	m.members.fetched = time.Now()
-	m.members.mu.Unlock()
-
-	return ids, nil
+	return out, nil
 }
@@ -2,11 +2,44 @@ package levelling
 
 import (
 	"log"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
+// MilestoneMode decides which milestone roles a member keeps.
+type MilestoneMode string
+
+const (
+	// Every milestone role up to the member's level; never removes any
+	MilestoneStack MilestoneMode = "stack"
+	// Only the highest milestone role the member has reached
+	MilestoneReplace MilestoneMode = "replace"
+	// Like stack, but roles above the member's level are taken away (after /xp take, resets, seasons)
+	MilestoneStrict MilestoneMode = "strict"
+)
+
+// milestoneSyncBatch + milestoneSyncPause throttle bulk role updates
+const (
+	milestoneSyncBatch = 25
+	milestoneSyncPause = 350 * time.Millisecond
+)
+
+func normalizeMilestoneMode(mode MilestoneMode) MilestoneMode {
+	switch MilestoneMode(strings.ToLower(strings.TrimSpace(string(mode)))) {
+	case "", MilestoneStack:
+		return MilestoneStack
+	case MilestoneReplace:
+		return MilestoneReplace
+	case MilestoneStrict:
+		return MilestoneStrict
+	}
+	log.Printf("[levelling] unknown milestone role mode %q; using stack", mode)
+	return MilestoneStack
+}
+
 // normalizeLevelRoles trims keys/values and drops empty entries
 func normalizeLevelRoles(in map[int]string) map[int]string {
 	out := map[int]string{}
@@ -23,8 +56,105 @@ func normalizeLevelRoles(in map[int]string) map[int]string {
 	return out
 }
 
+// milestoneChange is one role to add or remove, with the milestone level it belongs to.
+type milestoneChange struct {
+	Level  int
+	RoleID string
+}
+
+// milestoneRoleDiff works out which milestone roles a member at level should gain and lose,
+// given the roles they have now.
+func (m *Module) milestoneRoleDiff(mode MilestoneMode, level int, current []string) (add, remove []milestoneChange) {
+	levels := make([]int, 0, len(m.levelRoles))
+	for lvl := range m.levelRoles {
+		levels = append(levels, lvl)
+	}
+	sort.Ints(levels)
+
+	want := map[string]int{}
+	for _, lvl := range levels {
+		if lvl > level {
+			break
+		}
+		if mode == MilestoneReplace {
+			clear(want)
+		}
+		want[m.levelRoles[lvl]] = lvl
+	}
+
+	has := make(map[string]struct{}, len(current))
+	for _, id := range current {
+		has[id] = struct{}{}
+	}
+
+	seen := map[string]struct{}{}
+	for _, lvl := range levels {
+		roleID := m.levelRoles[lvl]
+		if _, dup := seen[roleID]; dup {
+			continue
+		}
+		seen[roleID] = struct{}{}
+
+		_, held := has[roleID]
+		_, wanted := want[roleID]
+		switch {
+		case wanted && !held:
+			add = append(add, milestoneChange{Level: want[roleID], RoleID: roleID})
+		case held && !wanted && mode != MilestoneStack:
+			remove = append(remove, milestoneChange{Level: lvl, RoleID: roleID})
+		}
+	}
+	return add, remove
+}
+
+// memberRoles reads a member's roles from the state cache, falling back to the API.
+func memberRoles(s *discordgo.Session, guildID, userID string) ([]string, error) {
+	if s.State != nil {
+		if mem, err := s.State.Member(guildID, userID); err == nil && mem != nil {
+			return mem.Roles, nil
+		}
+	}
+	mem, err := s.GuildMember(guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return mem.Roles, nil
+}
+
+// applyMilestoneChanges makes the role calls and returns how many failed.
+func applyMilestoneChanges(s *discordgo.Session, guildID, userID string, add, remove []milestoneChange) int {
+	failed := 0
+	for _, c := range remove {
+		if err := s.GuildMemberRoleRemove(guildID, userID, c.RoleID); err != nil {
+			failed++
+			log.Printf("[levelling] milestone role remove failed (user=%s level=%d role=%s): %v", userID, c.Level, c.RoleID, err)
+		}
+	}
+	for _, c := range add {
+		if err := s.GuildMemberRoleAdd(guildID, userID, c.RoleID); err != nil {
+			failed++
+			log.Printf("[levelling] milestone role add failed (user=%s level=%d role=%s): %v", userID, c.Level, c.RoleID, err)
+		}
+	}
+	return failed
+}
+
+// syncMilestoneRoles brings one member's milestone roles in line with level under mode.
+func (m *Module) syncMilestoneRoles(s *discordgo.Session, guildID, userID string, level int, mode MilestoneMode) {
+	if s == nil || guildID == "" || userID == "" || len(m.levelRoles) == 0 {
+		return
+	}
+	current, err := memberRoles(s, guildID, userID)
+	if err != nil {
+		log.Printf("[levelling] read member roles failed (user=%s): %v", userID, err)
+		return
+	}
+	add, remove := m.milestoneRoleDiff(mode, level, current)
+	applyMilestoneChanges(s, guildID, userID, add, remove)
+}
+
 // Grants any configured milestone roles for levels in (oldLevel, newLevel].
-// Add-only (stack roles). Never removes roles.
+// In stack mode this is add-only; replace/strict sync the member's whole milestone set.
 func (m *Module) applyMilestoneRoles(
 	s *discordgo.Session,
 	guildID string,
@@ -39,6 +169,11 @@ func (m *Module) applyMilestoneRoles(
 		return
 	}
 
+	if m.milestoneMode != MilestoneStack {
+		m.syncMilestoneRoles(s, guildID, userID, newLevel, m.milestoneMode)
+		return
+	}
+
 	for lvl := oldLevel + 1; lvl <= newLevel; lvl++ {
 		roleID := strings.TrimSpace(m.levelRoles[lvl])
 		if roleID == "" {
@@ -53,3 +188,21 @@ func (m *Module) applyMilestoneRoles(
 		}
 	}
 }
+
+// applyMilestoneDrop handles a member losing levels. Replace/strict always resync;
+// stack keeps roles unless force is set (e.g. /xp take remove_roles:true).
+// Reports whether roles were touched.
+func (m *Module) applyMilestoneDrop(s *discordgo.Session, guildID, userID string, newLevel int, force bool) bool {
+	if len(m.levelRoles) == 0 {
+		return false
+	}
+	mode := m.milestoneMode
+	if mode == MilestoneStack {
+		if !force {
+			return false
+		}
+		mode = MilestoneStrict
+	}
+	m.syncMilestoneRoles(s, guildID, userID, newLevel, mode)
+	return true
+}
@@ -1,123 +1,240 @@
-package levelling
-
-import (
-	"fmt"
-	"log"
-	"sort"
-	"strings"
-	"time"
-
-	"github.com/bwmarrin/discordgo"
-)
-
-func (m *Module) handleMilestoneSync(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	guildID := strings.TrimSpace(i.GuildID)
-	if guildID == "" {
-		m.respondEphemeral(s, i, "This command only works in a server.")
-		return
-	}
-
-	// Admin-only: Manage Server or Administrator
-	var perms int64
-	if i.Member != nil {
-		perms = i.Member.Permissions
-	}
-	if perms&(discordgo.PermissionManageGuild|discordgo.PermissionAdministrator) == 0 {
-		m.respondEphemeral(s, i, "You need **Manage Server** (or Administrator) to use this.")
-		return
-	}
-
-	if len(m.levelRoles) == 0 {
-		m.respondEphemeral(s, i, "No milestone roles are configured. Set env vars like `LEVEL_ROLE_5=...` (or `LEVEL_ROLES=...`) and restart.")
-		return
-	}
-
-	dryRun := false
-	limit := 0
-
-	for _, opt := range i.ApplicationCommandData().Options {
-		if opt == nil {
-			continue
-		}
-		switch opt.Name {
-		case "dry_run":
-			dryRun = opt.BoolValue()
-		case "limit":
-			limit = int(opt.IntValue())
-			if limit < 0 {
-				limit = 0
-			}
-		}
-	}
-
-	// Fast ACK (ephemeral) so Discord doesn't time out
-	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: "Running milestone sync…",
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
-	})
-
-	// Sort milestone levels for nicer reporting
-	levels := make([]int, 0, len(m.levelRoles))
-	for lvl := range m.levelRoles {
-		levels = append(levels, lvl)
-	}
-	sort.Ints(levels)
-
-	users, err := m.listAllXPUsers(limit)
-	if err != nil {
-		msg := "DB error reading users."
-		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
-		return
-	}
-
-	processed := 0
-	attemptedAdds := 0
-	addErrors := 0
-
-	// crude throttling to avoid slamming rate limits in huge servers
-	const batch = 25
-
-	for idx, u := range users {
-		processed++
-		lvl := levelForXP(u.XP)
-
-		for _, milestone := range levels {
-			roleID := strings.TrimSpace(m.levelRoles[milestone])
-			if roleID == "" {
-				continue
-			}
-			if lvl < milestone {
-				continue
-			}
-
-			attemptedAdds++
-			if dryRun {
-				continue
-			}
-
-			if err := s.GuildMemberRoleAdd(guildID, u.UserID, roleID); err != nil {
-				addErrors++
-				log.Printf("[levelling] milestonesync add failed (user=%s level=%d role=%s): %v", u.UserID, milestone, roleID, err)
-			}
-		}
-
-		if !dryRun && (idx+1)%batch == 0 {
-			time.Sleep(350 * time.Millisecond)
-		}
-	}
-
-	mode := "APPLIED"
-	if dryRun {
-		mode = "DRY RUN"
-	}
-
-	summary := fmt.Sprintf(
-		"✅ Milestone sync complete (%s)\nProcessed users: **%d**\nRole-add attempts: **%d**\nErrors: **%d**\nMilestones: **%v**",
-		mode, processed, attemptedAdds, addErrors, levels,
-	)
-
-	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &summary})
-}
+package levelling
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Diff lines shown in the reply; the full diff is attached as a file when longer
+	milestoneDiffInline = 15
+	milestoneDiffFile   = "milestonesync.txt"
+)
+
+// milestoneUserDiff is the planned change for one member.
+type milestoneUserDiff struct {
+	UserID   string
+	Username string
+	Level    int
+	Add      []milestoneChange
+	Remove   []milestoneChange
+}
+
+func (m *Module) handleMilestoneSync(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	guildID := strings.TrimSpace(i.GuildID)
+	if guildID == "" {
+		m.respondEphemeral(s, i, "This command only works in a server.")
+		return
+	}
+
+	// Admin-only: Manage Server or Administrator
+	var perms int64
+	if i.Member != nil {
+		perms = i.Member.Permissions
+	}
+	if perms&(discordgo.PermissionManageGuild|discordgo.PermissionAdministrator) == 0 {
+		m.respondEphemeral(s, i, "You need **Manage Server** (or Administrator) to use this.")
+		return
+	}
+
+	if len(m.levelRoles) == 0 {
+		m.respondEphemeral(s, i, "No milestone roles are configured. Add them to `LevelRoles` in main.go and restart.")
+		return
+	}
+
+	dryRun := false
+	limit := 0
+	mode := m.milestoneMode
+
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "dry_run":
+			dryRun = opt.BoolValue()
+		case "limit":
+			limit = int(opt.IntValue())
+			if limit < 0 {
+				limit = 0
+			}
+		case "mode":
+			mode = normalizeMilestoneMode(MilestoneMode(opt.StringValue()))
+		}
+	}
+
+	// Fast ACK (ephemeral) so Discord doesn't time out
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Running milestone sync…",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	editText := func(msg string) {
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+	}
+
+	users, err := m.listAllXPUsers(limit)
+	if err != nil {
+		editText("DB error reading users.")
+		return
+	}
+
+	members, err := m.guildMembers(s, guildID)
+	if err != nil {
+		log.Printf("[levelling] milestonesync member fetch failed: %v", err)
+		editText("Couldn't fetch the member list from Discord.")
+		return
+	}
+	byID := make(map[string]*discordgo.Member, len(members))
+	for _, mem := range members {
+		byID[mem.User.ID] = mem
+	}
+
+	diffs, processed, missing := m.planMilestoneSync(mode, users, byID, limit == 0)
+
+	adds, removes, errs := 0, 0, 0
+	for idx, d := range diffs {
+		adds += len(d.Add)
+		removes += len(d.Remove)
+		if dryRun {
+			continue
+		}
+		errs += applyMilestoneChanges(s, guildID, d.UserID, d.Add, d.Remove)
+		// crude throttling to avoid slamming rate limits in huge servers
+		if (idx+1)%milestoneSyncBatch == 0 {
+			time.Sleep(milestoneSyncPause)
+		}
+	}
+
+	status := "APPLIED"
+	if dryRun {
+		status = "DRY RUN"
+	}
+
+	levels := make([]int, 0, len(m.levelRoles))
+	for lvl := range m.levelRoles {
+		levels = append(levels, lvl)
+	}
+	sort.Ints(levels)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "✅ Milestone sync complete (%s, **%s** mode)\n", status, mode)
+	fmt.Fprintf(&b, "Processed users: **%d** (not in server: **%d**)\n", processed, missing)
+	fmt.Fprintf(&b, "Users changed: **%d** — adds: **%d**, removals: **%d**, errors: **%d**\n", len(diffs), adds, removes, errs)
+	fmt.Fprintf(&b, "Milestones: **%v**", levels)
+
+	lines := milestoneDiffLines(diffs, func(id string) string { return "<@&" + id + ">" }, func(d milestoneUserDiff) string { return "<@" + d.UserID + ">" })
+	if len(lines) > 0 {
+		b.WriteString("\n\n")
+		shown := lines
+		if len(shown) > milestoneDiffInline {
+			shown = shown[:milestoneDiffInline]
+		}
+		b.WriteString(strings.Join(shown, "\n"))
+		if len(lines) > len(shown) {
+			fmt.Fprintf(&b, "\n…and **%d** more (full list attached)", len(lines)-len(shown))
+		}
+	}
+	msg := b.String()
+
+	edit := &discordgo.WebhookEdit{
+		Content:         &msg,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if len(lines) > milestoneDiffInline {
+		full := milestoneDiffLines(diffs, func(id string) string { return roleLabel(s, guildID, id) }, func(d milestoneUserDiff) string {
+			return fmt.Sprintf("%s (%s)", d.Username, d.UserID)
+		})
+		edit.Files = []*discordgo.File{{
+			Name:        milestoneDiffFile,
+			ContentType: "text/plain",
+			Reader:      bytes.NewReader([]byte(strings.Join(full, "\n") + "\n")),
+		}}
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+		log.Printf("[levelling] milestonesync response failed: %v", err)
+	}
+}
+
+// planMilestoneSync diffs every XP user's milestone roles against their level. With everyone set,
+// members holding milestone roles but no XP row are checked too (as level 0).
+func (m *Module) planMilestoneSync(mode MilestoneMode, users []xpRow, members map[string]*discordgo.Member, everyone bool) (diffs []milestoneUserDiff, processed, missing int) {
+	seen := make(map[string]struct{}, len(users))
+	check := func(userID string, level int) {
+		processed++
+		mem, ok := members[userID]
+		if !ok {
+			missing++
+			return
+		}
+		add, remove := m.milestoneRoleDiff(mode, level, mem.Roles)
+		if len(add) == 0 && len(remove) == 0 {
+			return
+		}
+		diffs = append(diffs, milestoneUserDiff{UserID: userID, Username: mem.User.Username, Level: level, Add: add, Remove: remove})
+	}
+
+	for _, u := range users {
+		seen[u.UserID] = struct{}{}
+		check(u.UserID, m.levelForXP(u.XP))
+	}
+
+	if everyone && mode != MilestoneStack {
+		ids := make([]string, 0, len(members))
+		for id := range members {
+			if _, ok := seen[id]; !ok {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			if mem := members[id]; !mem.User.Bot && m.holdsMilestoneRole(mem.Roles) {
+				check(id, 0)
+			}
+		}
+	}
+	return diffs, processed, missing
+}
+
+func (m *Module) holdsMilestoneRole(roles []string) bool {
+	for _, id := range roles {
+		for _, roleID := range m.levelRoles {
+			if id == roleID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// milestoneDiffLines renders one "+"/"-" line per role change.
+func milestoneDiffLines(diffs []milestoneUserDiff, role func(id string) string, user func(d milestoneUserDiff) string) []string {
+	var out []string
+	for _, d := range diffs {
+		for _, c := range d.Remove {
+			out = append(out, fmt.Sprintf("➖ %s (Lvl %d): remove %s (milestone %d)", user(d), d.Level, role(c.RoleID), c.Level))
+		}
+		for _, c := range d.Add {
+			out = append(out, fmt.Sprintf("➕ %s (Lvl %d): add %s (milestone %d)", user(d), d.Level, role(c.RoleID), c.Level))
+		}
+	}
+	return out
+}
+
+// roleLabel is the role's name from the state cache, or its ID.
+func roleLabel(s *discordgo.Session, guildID, roleID string) string {
+	if s.State != nil {
+		if r, err := s.State.Role(guildID, roleID); err == nil && r != nil {
+			return "@" + r.Name
+		}
+	}
+	return roleID
+}
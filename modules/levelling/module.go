@@ -3,23 +3,16 @@ package levelling
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"log"
 	"math/rand"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Sentinaut/AuraBot/modules/members"
 	"github.com/bwmarrin/discordgo"
 )
 
-type memberCache struct {
-	mu      sync.Mutex
-	guildID string
-	fetched time.Time
-	ids     map[string]struct{}
-}
-
 type Module struct {
 	db *sql.DB
 
@@ -34,29 +27,89 @@ type Module struct {
 	// DB queries intentionally ignore guild_id (single-server design).
 	guildID string
 
-	// Milestone roles (configured from main.go now)
-	levelRoles map[int]string
+	// Milestone roles (configured from main.go now) + how they're kept (stack/replace/strict)
+	levelRoles    map[int]string
+	milestoneMode MilestoneMode
+
+	// Where/whether level-ups are announced (main.go); per-user opt-outs live in levelup_notify_optout
+	notify LevelUpNotify
+
+	// Per-channel / per-role XP multipliers (main.go); global boosts live in xp_boosts
+	multipliers Multipliers
+
+	// No-XP list + anti-spam heuristics (main.go); in-memory per-user message history
+	rules XPRules
+	spam  spamTracker
+
+	// Voice XP (main.go); live sessions are kept in voice_sessions so restarts don't lose them
+	voice         VoiceXP
+	voiceExcluded map[string]struct{}
+
+	// Shared member tracker (filters leaderboards to current members); nil = fetch from Discord
+	tracker *members.Tracker
+
+	// XP curve: main.go default, replaced at runtime by /xpcurve set (stored in levelling_settings)
+	defaultCurve Curve
+	curveMu      sync.RWMutex
+	curve        *levelCurve
+
+	// Serialises season endings (scheduler vs /season end)
+	seasonMu sync.Mutex
 
-	// Cached guild member IDs (used to filter leaderboards to current members)
-	members memberCache
+	// /xpimport previews waiting for confirmation
+	imports pendingImports
+
+	// Invite use counts, diffed on each join to record which invite it came through
+	invites inviteTracker
 
 	rngMu sync.Mutex
 	rng   *rand.Rand
 }
 
-// New now takes guildID + levelRoles from main.go (no env vars here anymore)
-func New(channelIDs []string, guildID string, levelRoles map[int]string, db *sql.DB) *Module {
+// Config is the levelling setup from main.go.
+type Config struct {
+	// Channels that earn message XP
+	Channels []string
+
+	LevelRoles     map[int]string
+	LevelRolesMode MilestoneMode
+	LevelUpNotify  LevelUpNotify
+
+	Curve       Curve
+	Multipliers Multipliers
+	Rules       XPRules
+	Voice       VoiceXP
+}
+
+// New builds the levelling module from cfg; tracker may be nil.
+func New(cfg Config, guildID string, tracker *members.Tracker, db *sql.DB) *Module {
+	curve := cfg.Curve
+	lc, err := newLevelCurve(curve)
+	if err != nil {
+		log.Printf("[levelling] invalid XP curve (%v); using default", err)
+		curve = DefaultCurve
+		lc, _ = newLevelCurve(curve)
+	}
+
 	m := &Module{
 		db:              db,
-		allowedChannels: make(map[string]struct{}, len(channelIDs)),
+		allowedChannels: make(map[string]struct{}, len(cfg.Channels)),
 		cooldown:        2 * time.Minute,
 		xpMin:           15,
 		xpMax:           25,
 		guildID:         strings.TrimSpace(guildID),
-		levelRoles:      normalizeLevelRoles(levelRoles),
+		levelRoles:      normalizeLevelRoles(cfg.LevelRoles),
+		milestoneMode:   normalizeMilestoneMode(cfg.LevelRolesMode),
+		notify:          normalizeLevelUpNotify(cfg.LevelUpNotify),
+		multipliers:     normalizeMultipliers(cfg.Multipliers),
+		rules:           normalizeXPRules(cfg.Rules),
+		defaultCurve:    curve,
+		curve:           lc,
+		tracker:         tracker,
 	}
+	m.voice, m.voiceExcluded = normalizeVoiceXP(cfg.Voice)
 
-	for _, id := range channelIDs {
+	for _, id := range cfg.Channels {
 		id = strings.TrimSpace(id)
 		if id != "" {
 			m.allowedChannels[id] = struct{}{}
@@ -72,15 +125,35 @@ func (m *Module) Register(s *discordgo.Session) error {
 	s.AddHandler(m.onInteractionCreate)
 	s.AddHandler(m.onMessageCreate)
 	s.AddHandler(m.onGuildMemberAdd) // ✅ needed for join tracking
+	s.AddHandler(m.onGuildCreateInvites)
+	s.AddHandler(m.onInviteCreate)
+	s.AddHandler(m.onInviteDelete)
+	s.AddHandler(m.onVoiceStateUpdate)
 	return nil
 }
 
 func (m *Module) Start(ctx context.Context, s *discordgo.Session) error {
 	// NOTE: DB schema (including user_joins) is handled by internal/db/migrate.go
 
+	// A curve chosen with /xpcurve set wins over the main.go default
+	m.loadStoredCurve()
+
 	m.rngMu.Lock()
 	m.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
 	m.rngMu.Unlock()
+
+	// Ends timed /xpboost boosts and announces it
+	go m.runBoostExpiry(ctx, s)
+
+	if m.voice.Enabled {
+		go m.runVoiceTicker(ctx, s)
+	}
+
+	// Folds old xp_events into daily totals
+	go m.runXPRollups(ctx)
+
+	// Ends seasons on their scheduled date
+	go m.runSeasonScheduler(ctx, s)
 	return nil
 }
 
@@ -103,6 +176,23 @@ func (m *Module) onMessageCreate(s *discordgo.Session, e *discordgo.MessageCreat
 	username := e.Author.Username
 	now := time.Now().Unix()
 
+	var roles []string
+	if e.Member != nil {
+		roles = e.Member.Roles
+	}
+	if m.xpBlocked(userID, roles) {
+		return
+	}
+
+	// Tracked before the cooldown so spam between awards still counts; rejected messages don't use up the cooldown
+	duplicate, rapid := m.trackMessage(userID, e.Content, time.Unix(now, 0))
+	if duplicate || m.tooShort(e.Content) {
+		return
+	}
+
+	// Read before the tx: the boost lookup uses m.db, and the pool has a single connection
+	mult := m.xpMultiplier(e.ChannelID, roles) * rapid
+
 	tx, err := m.db.Begin()
 	if err != nil {
 		log.Printf("[levelling] begin tx failed: %v", err)
@@ -123,16 +213,23 @@ func (m *Module) onMessageCreate(s *discordgo.Session, e *discordgo.MessageCreat
 		}
 	}
 
-	gain := m.randomXP()
+	gain := scaleXP(m.randomXP(), mult)
+	if gain <= 0 {
+		return // 0x channel/role: no XP and no cooldown
+	}
 	newXP := curXP + gain
 
-	oldLevel := levelForXP(curXP)
-	newLevel := levelForXP(newXP)
+	oldLevel := m.levelForXP(curXP)
+	newLevel := m.levelForXP(newXP)
 
 	if err := m.txUpsertUserXP(tx, userID, username, newXP, now); err != nil {
 		log.Printf("[levelling] upsert xp failed: %v", err)
 		return
 	}
+	if err := txRecordXPEvent(tx, userID, gain, e.ChannelID, xpSourceText, now); err != nil {
+		log.Printf("[levelling] record xp event failed: %v", err)
+		return
+	}
 
 	if err := tx.Commit(); err != nil {
 		log.Printf("[levelling] commit failed: %v", err)
@@ -169,22 +266,7 @@ func (m *Module) onMessageCreate(s *discordgo.Session, e *discordgo.MessageCreat
 			log.Printf("[levelling] save level-up msg failed: %v", err)
 		}
 
-		embed := &discordgo.MessageEmbed{
-			Title: "🎉 Level Up!",
-			Description: fmt.Sprintf(
-				"<@%s> just reached\n**Level %d**!",
-				userID,
-				newLevel,
-			),
-			Color: 0x5865F2,
-			Thumbnail: &discordgo.MessageEmbedThumbnail{
-				URL: e.Author.AvatarURL("128"),
-			},
-			Footer:    &discordgo.MessageEmbedFooter{Text: "Keep chatting to earn more XP!"},
-			Timestamp: time.Now().Format(time.RFC3339),
-		}
-
-		_, _ = s.ChannelMessageSendEmbed(e.ChannelID, embed)
+		m.sendLevelUp(s, e.ChannelID, e.Author, oldLevel, newLevel, "Keep chatting to earn more XP!")
 	}
 }
 
@@ -0,0 +1,366 @@
+package levelling
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	boostCheckInterval = time.Minute
+	boostMaxMultiplier = 10
+	boostMaxHours      = 14 * 24
+)
+
+// Multipliers scale the random 15–25 XP per message.
+// Channel and role multipliers combine (channel × best role); a global boost multiplies on top.
+type Multipliers struct {
+	// channel ID -> multiplier (missing = 1x, 0 = no XP there)
+	Channels map[string]float64
+
+	// role ID -> multiplier; only the member's highest matching role applies
+	Roles map[string]float64
+}
+
+type xpBoost struct {
+	ID         int64
+	Multiplier float64
+	Reason     string
+	StartedBy  string
+	ChannelID  string
+	StartedAt  int64
+	EndsAt     int64
+}
+
+func normalizeMultipliers(in Multipliers) Multipliers {
+	out := Multipliers{
+		Channels: map[string]float64{},
+		Roles:    map[string]float64{},
+	}
+	for id, v := range in.Channels {
+		id = strings.TrimSpace(id)
+		if id != "" && v >= 0 {
+			out.Channels[id] = v
+		}
+	}
+	for id, v := range in.Roles {
+		id = strings.TrimSpace(id)
+		if id != "" && v >= 0 {
+			out.Roles[id] = v
+		}
+	}
+	return out
+}
+
+// xpMultiplier returns the combined channel × role × boost multiplier for a message.
+func (m *Module) xpMultiplier(channelID string, roles []string) float64 {
+	mult := 1.0
+	if v, ok := m.multipliers.Channels[channelID]; ok {
+		mult *= v
+	}
+
+	best, found := 0.0, false
+	for _, r := range roles {
+		if v, ok := m.multipliers.Roles[r]; ok && (!found || v > best) {
+			best, found = v, true
+		}
+	}
+	if found {
+		mult *= best
+	}
+
+	if b, ok := m.activeBoost(); ok {
+		mult *= b.Multiplier
+	}
+	return mult
+}
+
+// scaleXP applies a multiplier; anything above 0 still earns at least 1 XP.
+func scaleXP(base int64, mult float64) int64 {
+	if mult <= 0 || base <= 0 {
+		return 0
+	}
+	v := int64(math.Round(float64(base) * mult))
+	if v < 1 {
+		v = 1
+	}
+	return v
+}
+
+/* =========================
+   Global boosts (DB)
+   ========================= */
+
+// activeBoost is the running boost, if any. Boosts stop counting as soon as ends_at passes,
+// even before the expiry loop has marked them ended.
+func (m *Module) activeBoost() (xpBoost, bool) {
+	if m.db == nil {
+		return xpBoost{}, false
+	}
+	var b xpBoost
+	err := m.db.QueryRow(
+		`SELECT id, multiplier, reason, started_by, channel_id, started_at, ends_at
+		 FROM xp_boosts
+		 WHERE ended_at = 0 AND ends_at > ?
+		 ORDER BY id DESC
+		 LIMIT 1`,
+		time.Now().Unix(),
+	).Scan(&b.ID, &b.Multiplier, &b.Reason, &b.StartedBy, &b.ChannelID, &b.StartedAt, &b.EndsAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("[levelling] read xp boost failed: %v", err)
+		}
+		return xpBoost{}, false
+	}
+	return b, true
+}
+
+// startBoost ends any running boost and starts a new one.
+func (m *Module) startBoost(b xpBoost) (int64, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(`UPDATE xp_boosts SET ended_at = ? WHERE ended_at = 0`, b.StartedAt); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(
+		`INSERT INTO xp_boosts(multiplier, reason, started_by, channel_id, started_at, ends_at, ended_at)
+		 VALUES(?,?,?,?,?,?,0)`,
+		b.Multiplier, b.Reason, b.StartedBy, b.ChannelID, b.StartedAt, b.EndsAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// stopBoosts ends every open boost early. Returns how many were stopped.
+func (m *Module) stopBoosts(now int64) (int64, error) {
+	res, err := m.db.Exec(`UPDATE xp_boosts SET ended_at = ? WHERE ended_at = 0 AND ends_at > ?`, now, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// expireBoosts marks boosts past ends_at as ended and announces it where they were started.
+func (m *Module) expireBoosts(s *discordgo.Session) {
+	if m.db == nil {
+		return
+	}
+	now := time.Now().Unix()
+
+	rows, err := m.db.Query(
+		`SELECT id, multiplier, channel_id FROM xp_boosts WHERE ended_at = 0 AND ends_at <= ?`,
+		now,
+	)
+	if err != nil {
+		log.Printf("[levelling] read expired boosts failed: %v", err)
+		return
+	}
+	var expired []xpBoost
+	for rows.Next() {
+		var b xpBoost
+		if err := rows.Scan(&b.ID, &b.Multiplier, &b.ChannelID); err != nil {
+			log.Printf("[levelling] scan expired boost failed: %v", err)
+			continue
+		}
+		expired = append(expired, b)
+	}
+	_ = rows.Close()
+
+	for _, b := range expired {
+		if _, err := m.db.Exec(`UPDATE xp_boosts SET ended_at = ? WHERE id = ?`, now, b.ID); err != nil {
+			log.Printf("[levelling] end boost %d failed: %v", b.ID, err)
+			continue
+		}
+		log.Printf("[levelling] xp boost %d (%sx) expired", b.ID, fmtFloat(b.Multiplier))
+		if b.ChannelID != "" {
+			_, _ = s.ChannelMessageSend(b.ChannelID, fmt.Sprintf("⏰ The **%sx XP** boost has ended. Back to normal XP!", fmtFloat(b.Multiplier)))
+		}
+	}
+}
+
+func (m *Module) runBoostExpiry(ctx context.Context, s *discordgo.Session) {
+	t := time.NewTicker(boostCheckInterval)
+	defer t.Stop()
+
+	m.expireBoosts(s)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			m.expireBoosts(s)
+		}
+	}
+}
+
+/* =========================
+   /xpboost start|stop|status
+   ========================= */
+
+func (m *Module) handleXPBoost(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 || data.Options[0] == nil {
+		m.respondEphemeral(s, i, "Missing subcommand.")
+		return
+	}
+	sub := data.Options[0]
+
+	if sub.Name != "status" && !isLevellingAdmin(i) {
+		m.respondEphemeral(s, i, "You need **Manage Server** (or Administrator) to use this.")
+		return
+	}
+
+	switch sub.Name {
+	case "start":
+		m.handleXPBoostStart(s, i, sub.Options)
+	case "stop":
+		n, err := m.stopBoosts(time.Now().Unix())
+		if err != nil {
+			log.Printf("[levelling] stop boost failed: %v", err)
+			m.respondEphemeral(s, i, "DB error stopping the boost.")
+			return
+		}
+		if n == 0 {
+			m.respondEphemeral(s, i, "There's no XP boost running.")
+			return
+		}
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "⏹️ The XP boost was ended early. Back to normal XP!",
+			},
+		})
+	case "status":
+		m.respondBoostStatus(s, i)
+	default:
+		m.respondEphemeral(s, i, "Unknown subcommand.")
+	}
+}
+
+func (m *Module) handleXPBoostStart(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	mult, hours, reason := 0.0, 0.0, ""
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "multiplier":
+			mult = opt.FloatValue()
+		case "hours":
+			hours = opt.FloatValue()
+		case "reason":
+			reason = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	if mult <= 0 || mult > boostMaxMultiplier {
+		m.respondEphemeral(s, i, fmt.Sprintf("Multiplier must be above 0 and at most %d.", boostMaxMultiplier))
+		return
+	}
+	if hours <= 0 || hours > boostMaxHours {
+		m.respondEphemeral(s, i, fmt.Sprintf("Hours must be above 0 and at most %d.", boostMaxHours))
+		return
+	}
+
+	now := time.Now()
+	b := xpBoost{
+		Multiplier: mult,
+		Reason:     reason,
+		StartedBy:  interactionUserID(i),
+		ChannelID:  i.ChannelID,
+		StartedAt:  now.Unix(),
+		EndsAt:     now.Add(time.Duration(hours * float64(time.Hour))).Unix(),
+	}
+	if _, err := m.startBoost(b); err != nil {
+		log.Printf("[levelling] start boost failed: %v", err)
+		m.respondEphemeral(s, i, "DB error starting the boost.")
+		return
+	}
+
+	desc := fmt.Sprintf("All XP is multiplied by **%s** until <t:%d:f> (<t:%d:R>).", fmtFloat(mult), b.EndsAt, b.EndsAt)
+	if reason != "" {
+		desc = "**" + reason + "**\n" + desc
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       fmt.Sprintf("🚀 %sx XP boost started!", fmtFloat(mult)),
+				Description: desc,
+				Color:       0x57F287,
+				Timestamp:   now.Format(time.RFC3339),
+			}},
+		},
+	})
+}
+
+func (m *Module) respondBoostStatus(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var b strings.Builder
+
+	if boost, ok := m.activeBoost(); ok {
+		fmt.Fprintf(&b, "🚀 **%sx** global boost, ends <t:%d:R>", fmtFloat(boost.Multiplier), boost.EndsAt)
+		if boost.Reason != "" {
+			fmt.Fprintf(&b, " — %s", boost.Reason)
+		}
+		b.WriteString("\n")
+	} else {
+		b.WriteString("No global boost running.\n")
+	}
+
+	b.WriteString("\n**Channel multipliers**\n")
+	b.WriteString(formatMultiplierList(m.multipliers.Channels, "<#%s>"))
+	b.WriteString("\n**Role multipliers** (highest role applies)\n")
+	b.WriteString(formatMultiplierList(m.multipliers.Roles, "<@&%s>"))
+
+	if i.Member != nil && i.Member.User != nil {
+		fmt.Fprintf(&b, "\nYour multiplier here: **%sx**", fmtFloat(m.xpMultiplier(i.ChannelID, i.Member.Roles)))
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "XP multipliers",
+				Description: b.String(),
+				Color:       0x5865F2,
+			}},
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+func formatMultiplierList(in map[string]float64, mention string) string {
+	if len(in) == 0 {
+		return "None (1x)\n"
+	}
+	ids := make([]string, 0, len(in))
+	for id := range in {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool { return in[ids[a]] > in[ids[b]] })
+
+	var b strings.Builder
+	for _, id := range ids {
+		fmt.Fprintf(&b, "• "+mention+" — **%sx**\n", id, fmtFloat(in[id]))
+	}
+	return b.String()
+}
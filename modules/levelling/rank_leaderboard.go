@@ -1,6 +1,7 @@
 package levelling
 
 import (
+	"bytes"
 	"fmt"
 	"log"
 	"strconv"
@@ -33,22 +34,42 @@ func (m *Module) handleRank(s *discordgo.Session, i *discordgo.InteractionCreate
 		return
 	}
 
-	// optional user
+	// optional user + time scope / season
+	scopeKind, scopeFrom, scopeTo := "", "", ""
+	season := int64(0)
 	for _, opt := range i.ApplicationCommandData().Options {
-		if opt != nil && opt.Name == "user" {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "user":
 			if u := opt.UserValue(s); u != nil {
 				target = u
 			}
+		case "scope":
+			scopeKind = opt.StringValue()
+		case "from":
+			scopeFrom = opt.StringValue()
+		case "to":
+			scopeTo = opt.StringValue()
+		case "season":
+			season = opt.IntValue()
 		}
 	}
 
+	scope, err := m.resolveScope(scopeKind, scopeFrom, scopeTo, season)
+	if err != nil {
+		m.respondEphemeral(s, i, "Invalid scope: "+err.Error())
+		return
+	}
+
 	xp, err := m.getUserXP(i.GuildID, target.ID)
 	if err != nil {
 		m.respondEphemeral(s, i, "DB error reading XP.")
 		return
 	}
 
-	level, inLevel, needNext := breakdownXP(xp)
+	level, inLevel, needNext := m.breakdownXP(xp)
 
 	rankPos := int64(1)
 	if pos, err := m.getRankPosition(i.GuildID, xp); err == nil && pos > 0 {
@@ -61,6 +82,23 @@ func (m *Module) handleRank(s *discordgo.Session, i *discordgo.InteractionCreate
 	}
 	bar := progressBar(pct, 10)
 
+	voiceMinutes, err := m.getVoiceMinutes(target.ID)
+	if err != nil {
+		log.Printf("[levelling] read voice minutes failed: %v", err)
+	}
+
+	// Only shown when the rank card can't be rendered
+	statFields := []*discordgo.MessageEmbedField{
+		{Name: "Level", Value: fmt.Sprintf("**%d**", level), Inline: true},
+		{Name: "Rank", Value: fmt.Sprintf("**#%d**", rankPos), Inline: true},
+		{Name: "Total XP", Value: fmt.Sprintf("**%d**", xp), Inline: true},
+	}
+	progressField := &discordgo.MessageEmbedField{
+		Name:   "Progress to next level",
+		Value:  fmt.Sprintf("%s **%d%%**\n`%d / %d XP`", bar, pct, inLevel, needNext),
+		Inline: false,
+	}
+
 	embed := &discordgo.MessageEmbed{
 		Color:     0x5865F2,
 		Timestamp: time.Now().Format(time.RFC3339),
@@ -68,25 +106,71 @@ func (m *Module) handleRank(s *discordgo.Session, i *discordgo.InteractionCreate
 			Name:    "Rank — " + target.Username,
 			IconURL: target.AvatarURL("128"),
 		},
-		Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: target.AvatarURL("256")},
 		Description: fmt.Sprintf("<@%s>", target.ID),
 		Fields: []*discordgo.MessageEmbedField{
-			{Name: "Level", Value: fmt.Sprintf("**%d**", level), Inline: true},
-			{Name: "Rank", Value: fmt.Sprintf("**#%d**", rankPos), Inline: true},
-			{Name: "Total XP", Value: fmt.Sprintf("**%d**", xp), Inline: true},
-			{
-				Name:   "Progress to next level",
-				Value:  fmt.Sprintf("%s **%d%%**\n`%d / %d XP`", bar, pct, inLevel, needNext),
-				Inline: false,
-			},
+			{Name: "Voice time", Value: fmt.Sprintf("**%s**", formatVoiceMinutes(voiceMinutes)), Inline: true},
 		},
-		Footer: &discordgo.MessageEmbedFooter{Text: "Aura • Keep chatting to earn XP"},
+		Footer: &discordgo.MessageEmbedFooter{Text: "Aura • Keep chatting to earn XP • /rankcard to customise"},
 	}
 
-	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}},
-	})
+	if scope.Kind == xpScopeSeason {
+		sxp, slvl, spos, err := m.getSeasonStanding(scope.Season, target.ID)
+		if err != nil {
+			log.Printf("[levelling] read season standing failed: %v", err)
+		}
+		value := "Didn't place"
+		if spos > 0 {
+			value = fmt.Sprintf("**#%d** — Lvl **%d** — **%d XP**", spos, slvl, sxp)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: scope.Label, Value: value, Inline: false})
+	}
+	if scope.windowed() && scope.Kind != xpScopeWeek {
+		gained, pos, err := m.getXPGained(target.ID, scope)
+		if err != nil {
+			log.Printf("[levelling] read scoped xp failed: %v", err)
+		}
+		rankText := "unranked"
+		if pos > 0 {
+			rankText = fmt.Sprintf("#%d", pos)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   scope.Label,
+			Value:  fmt.Sprintf("**%d XP** gained (**%s**)", gained, rankText),
+			Inline: false,
+		})
+	}
+	week := m.weekGainedField(target.ID)
+	if scope.Kind == xpScopeWeek {
+		if _, pos, err := m.getXPGained(target.ID, scope); err == nil && pos > 0 {
+			week.Value += fmt.Sprintf(" (**#%d**)", pos)
+		}
+	}
+	embed.Fields = append(embed.Fields, week)
+
+	// Fetching the avatar + rendering can take a moment
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}); err != nil {
+		return
+	}
+
+	var files []*discordgo.File
+	card, err := m.buildRankCard(target, xp, rankPos)
+	if err == nil {
+		files = append(files, &discordgo.File{Name: rankCardFile, ContentType: "image/png", Reader: bytes.NewReader(card)})
+		embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + rankCardFile}
+	} else {
+		log.Printf("[levelling] render rank card failed: %v", err)
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: target.AvatarURL("256")}
+		// Level/rank/XP, voice time, progress, then the scoped extras (same order as before cards)
+		fields := append(statFields, embed.Fields[0], progressField)
+		embed.Fields = append(fields, embed.Fields[1:]...)
+	}
+
+	embeds := []*discordgo.MessageEmbed{embed}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Embeds: &embeds, Files: files}); err != nil {
+		log.Printf("[levelling] rank response failed: %v", err)
+	}
 }
 
 func progressBar(pct int, segments int) string {
@@ -126,12 +210,39 @@ func (m *Module) handleLeaderboard(s *discordgo.Session, i *discordgo.Interactio
 		return
 	}
 
-	content, embed, components, err := m.buildLeaderboardPageFiltered(s, i.GuildID, ownerID, 0)
+	scopeKind, scopeFrom, scopeTo := "", "", ""
+	season := int64(0)
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "scope":
+			scopeKind = opt.StringValue()
+		case "from":
+			scopeFrom = opt.StringValue()
+		case "to":
+			scopeTo = opt.StringValue()
+		case "season":
+			season = opt.IntValue()
+		}
+	}
+	scope, err := m.resolveScope(scopeKind, scopeFrom, scopeTo, season)
+	if err != nil {
+		m.respondEphemeral(s, i, "Invalid scope: "+err.Error())
+		return
+	}
+
+	content, embed, components, err := m.buildLeaderboardPageFiltered(s, i.GuildID, ownerID, scope, 0)
 	if err != nil {
 		m.respondEphemeral(s, i, "DB error reading leaderboard.")
 		return
 	}
 	if embed == nil {
+		if scope.Kind != xpScopeAll {
+			m.respondEphemeral(s, i, "Nobody gained XP in that period.")
+			return
+		}
 		m.respondEphemeral(s, i, "No XP recorded yet.")
 		return
 	}
@@ -152,14 +263,19 @@ func (m *Module) handleLeaderboardComponent(s *discordgo.Session, i *discordgo.I
 	}
 	data := i.MessageComponentData()
 
-	// expected: lb:<ownerID>:<action>:<page>
+	// expected: lb:<ownerID>:<action>:<page>[:<scope key>] (older messages have no scope = all-time)
 	parts := strings.Split(data.CustomID, ":")
-	if len(parts) != 4 || parts[0] != lbCustomID {
+	if (len(parts) != 4 && len(parts) != 5) || parts[0] != lbCustomID {
 		return
 	}
 	ownerID := parts[1]
 	action := parts[2]
 	pageStr := parts[3]
+	scopeKey := xpScopeAll
+	if len(parts) == 5 {
+		scopeKey = parts[4]
+	}
+	scope := m.xpScopeFromKey(scopeKey, time.Now())
 
 	clickerID := interactionUserID(i)
 	if clickerID == "" {
@@ -196,7 +312,7 @@ func (m *Module) handleLeaderboardComponent(s *discordgo.Session, i *discordgo.I
 		Data: &discordgo.InteractionResponseData{Components: m.loadingButtons()},
 	})
 
-	allRows, note, err := m.getLeaderboardRowsFiltered(s, guildID)
+	allRows, note, err := m.getLeaderboardRowsFiltered(s, guildID, scope)
 	if err != nil {
 		msg := "DB error reading leaderboard."
 		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
@@ -244,7 +360,7 @@ func (m *Module) handleLeaderboardComponent(s *discordgo.Session, i *discordgo.I
 		targetPage = maxPage
 	}
 
-	content, embed, comps := buildLeaderboardPageFromRows(allRows, note, ownerID, targetPage)
+	content, embed, comps := m.buildLeaderboardPageFromRows(allRows, note, ownerID, scope, targetPage)
 	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 		Content:    &content,
 		Embeds:     &[]*discordgo.MessageEmbed{embed},
@@ -255,8 +371,8 @@ func (m *Module) handleLeaderboardComponent(s *discordgo.Session, i *discordgo.I
 	}
 }
 
-func (m *Module) buildLeaderboardPageFiltered(s *discordgo.Session, guildID, ownerID string, page int) (string, *discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
-	rows, note, err := m.getLeaderboardRowsFiltered(s, guildID)
+func (m *Module) buildLeaderboardPageFiltered(s *discordgo.Session, guildID, ownerID string, scope xpScope, page int) (string, *discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	rows, note, err := m.getLeaderboardRowsFiltered(s, guildID, scope)
 	if err != nil {
 		return "", nil, nil, err
 	}
@@ -264,12 +380,24 @@ func (m *Module) buildLeaderboardPageFiltered(s *discordgo.Session, guildID, own
 		return "", nil, nil, nil
 	}
 
-	content, embed, comps := buildLeaderboardPageFromRows(rows, note, ownerID, page)
+	content, embed, comps := m.buildLeaderboardPageFromRows(rows, note, ownerID, scope, page)
 	return content, embed, comps, nil
 }
 
-func (m *Module) getLeaderboardRowsFiltered(s *discordgo.Session, guildID string) ([]xpRow, string, error) {
-	all, err := m.listAllXPUsers(0)
+// getLeaderboardRows returns total XP (all-time) or XP gained in the scope's window, unfiltered.
+func (m *Module) getLeaderboardRows(scope xpScope) ([]xpRow, error) {
+	switch {
+	case scope.Kind == xpScopeSeason:
+		return m.listSeasonStandings(scope.Season)
+	case scope.windowed():
+		return m.listXPGained(scope)
+	}
+	return m.listAllXPUsers(0)
+}
+
+// getLeaderboardRowsFiltered is getLeaderboardRows limited to current guild members.
+func (m *Module) getLeaderboardRowsFiltered(s *discordgo.Session, guildID string, scope xpScope) ([]xpRow, string, error) {
+	all, err := m.getLeaderboardRows(scope)
 	if err != nil {
 		return nil, "", err
 	}
@@ -280,7 +408,7 @@ func (m *Module) getLeaderboardRowsFiltered(s *discordgo.Session, guildID string
 	memberSet, err := m.getGuildMemberIDSet(s, guildID)
 	if err != nil || memberSet == nil {
 		// Fallback: still show a leaderboard, just not filtered.
-		return all, "⚠️ Couldn't fetch the server member list, so this is the all-time leaderboard.", nil
+		return all, "⚠️ Couldn't fetch the server member list, so this includes people who have left.", nil
 	}
 
 	filtered := make([]xpRow, 0, len(all))
@@ -293,7 +421,7 @@ func (m *Module) getLeaderboardRowsFiltered(s *discordgo.Session, guildID string
 	return filtered, "", nil
 }
 
-func buildLeaderboardPageFromRows(allRows []xpRow, note string, ownerID string, page int) (string, *discordgo.MessageEmbed, []discordgo.MessageComponent) {
+func (m *Module) buildLeaderboardPageFromRows(allRows []xpRow, note string, ownerID string, scope xpScope, page int) (string, *discordgo.MessageEmbed, []discordgo.MessageComponent) {
 	total := len(allRows)
 	maxPage := (total - 1) / lbPageSize
 	if page < 0 {
@@ -315,12 +443,21 @@ func buildLeaderboardPageFromRows(allRows []xpRow, note string, ownerID string,
 
 	var b strings.Builder
 	for idx, row := range rows {
-		lvl := levelForXP(row.XP)
+		if scope.windowed() {
+			fmt.Fprintf(&b, "%d. <@%s> — **+%d XP**\n", startRank+idx, row.UserID, row.XP)
+			continue
+		}
+		lvl := m.levelForXP(row.XP)
 		fmt.Fprintf(&b, "%d. <@%s> — **Lvl %d** — **%d XP**\n", startRank+idx, row.UserID, lvl, row.XP)
 	}
 
+	title := "XP Leaderboard"
+	if scope.Kind != xpScopeAll {
+		title += " — " + scope.Label
+	}
+
 	embed := &discordgo.MessageEmbed{
-		Title:       "XP Leaderboard",
+		Title:       title,
 		Description: b.String(),
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: fmt.Sprintf("Showing %d–%d of %d (Page %d/%d)", startRank, endRank, total, page+1, maxPage+1),
@@ -328,14 +465,14 @@ func buildLeaderboardPageFromRows(allRows []xpRow, note string, ownerID string,
 	}
 
 	content := strings.TrimSpace(note)
-	comps := leaderboardButtons(ownerID, page, maxPage)
+	comps := leaderboardButtons(ownerID, scope, page, maxPage)
 
 	return content, embed, comps
 }
 
-func leaderboardButtons(ownerID string, page, maxPage int) []discordgo.MessageComponent {
+func leaderboardButtons(ownerID string, scope xpScope, page, maxPage int) []discordgo.MessageComponent {
 	makeID := func(action string) string {
-		return fmt.Sprintf("%s:%s:%s:%d", lbCustomID, ownerID, action, page)
+		return fmt.Sprintf("%s:%s:%s:%d:%s", lbCustomID, ownerID, action, page, scope.key())
 	}
 	row := discordgo.ActionsRow{
 		Components: []discordgo.MessageComponent{
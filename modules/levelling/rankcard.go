@@ -0,0 +1,619 @@
+package levelling
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	rankCardWidth  = 934
+	rankCardHeight = 282
+	rankCardPad    = 20
+	rankCardAvatar = 180
+	rankCardFile   = "rank.png"
+
+	// Background uploads are cropped to the card size and stored as PNG
+	rankCardMaxUpload    = 8 << 20
+	rankCardMaxSide      = 4096 // pixels; checked before decoding so a tiny file can't expand to gigabytes
+	rankCardFetchTimeout = 10 * time.Second
+)
+
+var (
+	rankCardDefaultBG     = color.RGBA{0x2B, 0x2D, 0x31, 0xFF}
+	rankCardDefaultAccent = color.RGBA{0x58, 0x65, 0xF2, 0xFF}
+	rankCardDefaultText   = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	rankCardMuted         = color.RGBA{0xB5, 0xBA, 0xC1, 0xFF}
+	rankCardTrack         = color.RGBA{0x48, 0x4B, 0x52, 0xFF}
+	// Dark panel drawn over background images so the text stays readable
+	rankCardPanel = color.RGBA{0x00, 0x00, 0x00, 0xA0}
+
+	// Used for avatars, /rankcard backgrounds and /xpimport files
+	fetchHTTP = &http.Client{Timeout: rankCardFetchTimeout}
+)
+
+// rankCardTheme is a user's /rankcard customisation. Colours are "#RRGGBB" ("" = default).
+type rankCardTheme struct {
+	Background string
+	Accent     string
+	Text       string
+	Image      []byte
+}
+
+type rankCardData struct {
+	Username string
+	Avatar   image.Image // nil draws a plain circle
+	Level    int
+	Rank     int64
+	TotalXP  int64
+	InLevel  int64
+	NeedNext int64
+}
+
+/* =========================
+   Themes (DB)
+   ========================= */
+
+func (m *Module) getRankCardTheme(userID string) (rankCardTheme, error) {
+	var t rankCardTheme
+	err := m.db.QueryRow(
+		`SELECT bg_color, accent_color, text_color, bg_image FROM rank_card_themes WHERE user_id = ?`,
+		userID,
+	).Scan(&t.Background, &t.Accent, &t.Text, &t.Image)
+	if err == sql.ErrNoRows {
+		return rankCardTheme{}, nil
+	}
+	return t, err
+}
+
+func (m *Module) saveRankCardTheme(userID string, t rankCardTheme) error {
+	_, err := m.db.Exec(
+		`INSERT INTO rank_card_themes(user_id, bg_color, accent_color, text_color, bg_image, updated_at)
+		 VALUES(?,?,?,?,?,?)
+		 ON CONFLICT(user_id) DO UPDATE SET
+		   bg_color = excluded.bg_color,
+		   accent_color = excluded.accent_color,
+		   text_color = excluded.text_color,
+		   bg_image = excluded.bg_image,
+		   updated_at = excluded.updated_at`,
+		userID, t.Background, t.Accent, t.Text, t.Image, time.Now().Unix(),
+	)
+	return err
+}
+
+func (m *Module) deleteRankCardTheme(userID string) error {
+	_, err := m.db.Exec(`DELETE FROM rank_card_themes WHERE user_id = ?`, userID)
+	return err
+}
+
+// parseHexColor accepts "#5865F2" or "5865F2".
+func parseHexColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) != 6 {
+		return color.RGBA{}, fmt.Errorf("%q isn't a hex colour like #5865F2", s)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("%q isn't a hex colour like #5865F2", s)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xFF}, nil
+}
+
+func themeColor(hex string, def color.RGBA) color.RGBA {
+	if hex == "" {
+		return def
+	}
+	c, err := parseHexColor(hex)
+	if err != nil {
+		return def
+	}
+	return c
+}
+
+/* =========================
+   Images
+   ========================= */
+
+// fetchBytes downloads url, refusing anything over maxBytes.
+func fetchBytes(url string, maxBytes int64) ([]byte, error) {
+	resp, err := fetchHTTP.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: %s", url, resp.Status)
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(raw)) > maxBytes {
+		return nil, errors.New("file is too large")
+	}
+	return raw, nil
+}
+
+var errImageTooLarge = fmt.Errorf("image is larger than %dx%d pixels", rankCardMaxSide, rankCardMaxSide)
+
+// fetchImage downloads and decodes a PNG/JPEG/GIF/WebP (first frame for GIFs).
+func fetchImage(url string, maxBytes int64) (image.Image, error) {
+	raw, err := fetchBytes(url, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width > rankCardMaxSide || cfg.Height > rankCardMaxSide {
+		return nil, errImageTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	return img, err
+}
+
+// prepareRankCardBackground centre-crops an upload to the card's aspect ratio and scales it to fit.
+func prepareRankCardBackground(src image.Image) ([]byte, error) {
+	sb := src.Bounds()
+	if sb.Dx() < 1 || sb.Dy() < 1 {
+		return nil, errors.New("empty image")
+	}
+
+	crop := sb
+	if sb.Dx()*rankCardHeight > sb.Dy()*rankCardWidth {
+		w := sb.Dy() * rankCardWidth / rankCardHeight
+		crop.Min.X += (sb.Dx() - w) / 2
+		crop.Max.X = crop.Min.X + w
+	} else {
+		h := sb.Dx() * rankCardHeight / rankCardWidth
+		crop.Min.Y += (sb.Dy() - h) / 2
+		crop.Max.Y = crop.Min.Y + h
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, rankCardWidth, rankCardHeight))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// roundRect is an alpha mask for a rectangle with rounded corners
+// (a circle when the radius is half the side).
+type roundRect struct {
+	r      image.Rectangle
+	radius int
+}
+
+func (rr roundRect) ColorModel() color.Model { return color.AlphaModel }
+func (rr roundRect) Bounds() image.Rectangle { return rr.r }
+
+func (rr roundRect) At(x, y int) color.Color {
+	if !(image.Point{x, y}).In(rr.r) {
+		return color.Alpha{}
+	}
+	rad := rr.radius
+	cx, cy := x, y
+	switch {
+	case x < rr.r.Min.X+rad:
+		cx = rr.r.Min.X + rad
+	case x >= rr.r.Max.X-rad:
+		cx = rr.r.Max.X - rad - 1
+	}
+	switch {
+	case y < rr.r.Min.Y+rad:
+		cy = rr.r.Min.Y + rad
+	case y >= rr.r.Max.Y-rad:
+		cy = rr.r.Max.Y - rad - 1
+	}
+	dx, dy := x-cx, y-cy
+	if dx*dx+dy*dy <= rad*rad {
+		return color.Alpha{A: 0xFF}
+	}
+	return color.Alpha{}
+}
+
+func fillRoundRect(img draw.Image, r image.Rectangle, radius int, c color.Color) {
+	draw.DrawMask(img, r, image.NewUniform(c), image.Point{}, roundRect{r: r, radius: radius}, r.Min, draw.Over)
+}
+
+/* =========================
+   Fonts
+   ========================= */
+
+var (
+	rankCardFontsOnce sync.Once
+	rankCardRegular   *opentype.Font
+	rankCardBold      *opentype.Font
+)
+
+// rankCardFace returns a new face (faces aren't safe for concurrent use; the parsed fonts are).
+// Falls back to basicfont if the Go fonts can't be parsed.
+func rankCardFace(bold bool, size float64) font.Face {
+	rankCardFontsOnce.Do(func() {
+		var err error
+		if rankCardRegular, err = opentype.Parse(goregular.TTF); err != nil {
+			log.Printf("[levelling] parse rank card font failed: %v", err)
+		}
+		if rankCardBold, err = opentype.Parse(gobold.TTF); err != nil {
+			log.Printf("[levelling] parse rank card font failed: %v", err)
+		}
+	})
+
+	f := rankCardRegular
+	if bold {
+		f = rankCardBold
+	}
+	if f == nil {
+		return basicfont.Face7x13
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return basicfont.Face7x13
+	}
+	return face
+}
+
+func drawCardText(img draw.Image, face font.Face, x, y int, s string, c color.Color) {
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face, Dot: fixed.P(x, y)}
+	d.DrawString(s)
+}
+
+func cardTextWidth(face font.Face, s string) int {
+	return font.MeasureString(face, s).Ceil()
+}
+
+// fitCardText drops glyphs the font can't draw (emoji etc.) and shortens s to maxW pixels.
+func fitCardText(face font.Face, s string, maxW int) string {
+	var b strings.Builder
+	for _, r := range s {
+		if _, ok := face.GlyphAdvance(r); ok {
+			b.WriteRune(r)
+		}
+	}
+	out := strings.TrimSpace(b.String())
+	if cardTextWidth(face, out) <= maxW {
+		return out
+	}
+	runes := []rune(out)
+	for len(runes) > 0 && cardTextWidth(face, string(runes)+"…") > maxW {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
+
+// compactNumber renders 1234 as "1.2K" and 2500000 as "2.5M".
+func compactNumber(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1_000_000), ".0") + "M"
+	case n >= 10_000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1_000), ".0") + "K"
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+/* =========================
+   Rendering (pure Go)
+   ========================= */
+
+func renderRankCard(d rankCardData, t rankCardTheme) ([]byte, error) {
+	bounds := image.Rect(0, 0, rankCardWidth, rankCardHeight)
+	img := image.NewRGBA(bounds)
+
+	accent := themeColor(t.Accent, rankCardDefaultAccent)
+	text := themeColor(t.Text, rankCardDefaultText)
+
+	draw.Draw(img, bounds, image.NewUniform(themeColor(t.Background, rankCardDefaultBG)), image.Point{}, draw.Src)
+	if len(t.Image) > 0 {
+		bg, err := png.Decode(bytes.NewReader(t.Image))
+		if err != nil {
+			log.Printf("[levelling] decode rank card background failed: %v", err)
+		} else {
+			draw.Draw(img, bounds, bg, bg.Bounds().Min, draw.Src)
+			fillRoundRect(img, bounds.Inset(rankCardPad), 18, rankCardPanel)
+		}
+	}
+
+	// Avatar (circle)
+	avTop := (rankCardHeight - rankCardAvatar) / 2
+	avRect := image.Rect(rankCardPad*2, avTop, rankCardPad*2+rankCardAvatar, avTop+rankCardAvatar)
+	ring := image.Rect(avRect.Min.X-4, avRect.Min.Y-4, avRect.Max.X+4, avRect.Max.Y+4)
+	fillRoundRect(img, ring, ring.Dx()/2, accent)
+	if d.Avatar != nil {
+		av := image.NewRGBA(avRect)
+		xdraw.CatmullRom.Scale(av, avRect, d.Avatar, d.Avatar.Bounds(), draw.Src, nil)
+		draw.DrawMask(img, avRect, av, avRect.Min, roundRect{r: avRect, radius: rankCardAvatar / 2}, avRect.Min, draw.Over)
+	} else {
+		fillRoundRect(img, avRect, rankCardAvatar/2, rankCardTrack)
+	}
+
+	nameFace := rankCardFace(true, 40)
+	bigFace := rankCardFace(true, 44)
+	smallFace := rankCardFace(false, 24)
+	for _, f := range []font.Face{nameFace, bigFace, smallFace} {
+		defer f.Close()
+	}
+
+	left := avRect.Max.X + 40
+	right := rankCardWidth - rankCardPad*2 - 10
+
+	// Rank + level, right-aligned on the top row
+	x := right
+	drawRight := func(face font.Face, s string, c color.Color, gap int) {
+		x -= cardTextWidth(face, s)
+		drawCardText(img, face, x, 92, s, c)
+		x -= gap
+	}
+	drawRight(bigFace, strconv.Itoa(d.Level), accent, 8)
+	drawRight(smallFace, "LEVEL", accent, 30)
+	drawRight(bigFace, fmt.Sprintf("#%d", d.Rank), text, 8)
+	drawRight(smallFace, "RANK", rankCardMuted, 0)
+
+	// Name + progress numbers above the bar
+	progress := fmt.Sprintf("%s / %s XP", compactNumber(d.InLevel), compactNumber(d.NeedNext))
+	progressW := cardTextWidth(smallFace, progress)
+	drawCardText(img, smallFace, right-progressW, 168, progress, rankCardMuted)
+
+	name := d.Username
+	if name == "" {
+		name = "Unknown"
+	}
+	drawCardText(img, nameFace, left, 168, fitCardText(nameFace, name, right-progressW-24-left), text)
+
+	// XP bar
+	bar := image.Rect(left, 186, right, 222)
+	fillRoundRect(img, bar, bar.Dy()/2, rankCardTrack)
+	if d.NeedNext > 0 && d.InLevel > 0 {
+		w := int(int64(bar.Dx()) * d.InLevel / d.NeedNext)
+		if w < bar.Dy() {
+			w = bar.Dy()
+		}
+		if w > bar.Dx() {
+			w = bar.Dx()
+		}
+		fill := image.Rect(bar.Min.X, bar.Min.Y, bar.Min.X+w, bar.Max.Y)
+		fillRoundRect(img, fill, bar.Dy()/2, accent)
+	}
+
+	drawCardText(img, smallFace, left, 252, fmt.Sprintf("Total: %s XP", compactNumber(d.TotalXP)), rankCardMuted)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// buildRankCard renders u's card with their saved theme. A missing avatar isn't fatal.
+func (m *Module) buildRankCard(u *discordgo.User, totalXP, rankPos int64) ([]byte, error) {
+	theme, err := m.getRankCardTheme(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	level, inLevel, needNext := m.breakdownXP(totalXP)
+	d := rankCardData{
+		Username: u.Username,
+		Level:    level,
+		Rank:     rankPos,
+		TotalXP:  totalXP,
+		InLevel:  inLevel,
+		NeedNext: needNext,
+	}
+	if u.GlobalName != "" {
+		d.Username = u.GlobalName
+	}
+	if av, err := fetchImage(u.AvatarURL("256"), rankCardMaxUpload); err != nil {
+		log.Printf("[levelling] fetch avatar failed: %v", err)
+	} else {
+		d.Avatar = av
+	}
+	return renderRankCard(d, theme)
+}
+
+/* =========================
+   /rankcard set|preview|reset
+   ========================= */
+
+func (m *Module) handleRankCard(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 || data.Options[0] == nil {
+		m.respondEphemeral(s, i, "Missing subcommand.")
+		return
+	}
+	sub := data.Options[0]
+
+	user := i.User
+	if i.Member != nil && i.Member.User != nil {
+		user = i.Member.User
+	}
+	if user == nil || strings.TrimSpace(i.GuildID) == "" {
+		m.respondEphemeral(s, i, "This command only works in a server.")
+		return
+	}
+
+	switch sub.Name {
+	case "set":
+		m.handleRankCardSet(s, i, user, sub.Options)
+	case "preview":
+		m.respondRankCardPreview(s, i, user, "")
+	case "reset":
+		if err := m.deleteRankCardTheme(user.ID); err != nil {
+			log.Printf("[levelling] delete rank card theme failed: %v", err)
+			m.respondEphemeral(s, i, "DB error resetting your rank card.")
+			return
+		}
+		m.respondEphemeral(s, i, "✅ Your rank card is back to the default theme.")
+	default:
+		m.respondEphemeral(s, i, "Unknown subcommand.")
+	}
+}
+
+func (m *Module) handleRankCardSet(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	theme, err := m.getRankCardTheme(user.ID)
+	if err != nil {
+		m.respondEphemeral(s, i, "DB error reading your rank card.")
+		return
+	}
+
+	changed := false
+	var upload *discordgo.MessageAttachment
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "background", "accent", "text":
+			v := strings.TrimSpace(opt.StringValue())
+			if strings.EqualFold(v, "default") {
+				v = ""
+			} else {
+				c, err := parseHexColor(v)
+				if err != nil {
+					m.respondEphemeral(s, i, "Invalid `"+opt.Name+"`: "+err.Error())
+					return
+				}
+				v = fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+			}
+			switch opt.Name {
+			case "background":
+				theme.Background = v
+			case "accent":
+				theme.Accent = v
+			case "text":
+				theme.Text = v
+			}
+			changed = true
+		case "image":
+			id, _ := opt.Value.(string)
+			if r := i.ApplicationCommandData().Resolved; r != nil {
+				upload = r.Attachments[id]
+			}
+			if upload == nil {
+				m.respondEphemeral(s, i, "Couldn't read that attachment.")
+				return
+			}
+			if !strings.HasPrefix(upload.ContentType, "image/") {
+				m.respondEphemeral(s, i, "The background has to be an image (PNG, JPEG, GIF or WebP).")
+				return
+			}
+			if int64(upload.Size) > rankCardMaxUpload {
+				m.respondEphemeral(s, i, fmt.Sprintf("That image is too large (max %d MB).", rankCardMaxUpload>>20))
+				return
+			}
+			changed = true
+		case "clear_image":
+			if opt.BoolValue() {
+				theme.Image = nil
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		m.respondEphemeral(s, i, "Give at least one option to change (or use `/rankcard preview`).")
+		return
+	}
+
+	// Downloading + rendering can take a moment
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	}); err != nil {
+		return
+	}
+	editText := func(msg string) {
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+	}
+
+	if upload != nil {
+		src, err := fetchImage(upload.URL, rankCardMaxUpload)
+		if err != nil {
+			log.Printf("[levelling] fetch rank card background failed: %v", err)
+			if errors.Is(err, errImageTooLarge) {
+				editText(fmt.Sprintf("That image is too big (max %d×%d pixels).", rankCardMaxSide, rankCardMaxSide))
+				return
+			}
+			editText("Couldn't read that image. Try a PNG or JPEG.")
+			return
+		}
+		if theme.Image, err = prepareRankCardBackground(src); err != nil {
+			log.Printf("[levelling] prepare rank card background failed: %v", err)
+			editText("Couldn't use that image.")
+			return
+		}
+	}
+
+	if err := m.saveRankCardTheme(user.ID, theme); err != nil {
+		log.Printf("[levelling] save rank card theme failed: %v", err)
+		editText("DB error saving your rank card.")
+		return
+	}
+	m.editRankCardPreview(s, i, user, "✅ Rank card saved.")
+}
+
+// respondRankCardPreview shows the user's own card (ephemeral).
+func (m *Module) respondRankCardPreview(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, msg string) {
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	}); err != nil {
+		return
+	}
+	m.editRankCardPreview(s, i, user, msg)
+}
+
+func (m *Module) editRankCardPreview(s *discordgo.Session, i *discordgo.InteractionCreate, user *discordgo.User, msg string) {
+	xp, err := m.getUserXP(i.GuildID, user.ID)
+	if err != nil {
+		log.Printf("[levelling] read xp failed: %v", err)
+	}
+	pos, err := m.getRankPosition(i.GuildID, xp)
+	if err != nil || pos < 1 {
+		pos = 1
+	}
+
+	card, err := m.buildRankCard(user, xp, pos)
+	if err != nil {
+		log.Printf("[levelling] render rank card failed: %v", err)
+		msg = strings.TrimSpace(msg + "\nFailed to render the preview.")
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+		return
+	}
+
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &msg,
+		Files:   []*discordgo.File{{Name: rankCardFile, ContentType: "image/png", Reader: bytes.NewReader(card)}},
+	})
+	if err != nil {
+		log.Printf("[levelling] rank card preview response failed: %v", err)
+	}
+}
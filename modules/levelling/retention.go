@@ -0,0 +1,266 @@
+package levelling
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sentinaut/AuraBot/modules/members"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	retentionDefaultWeeks = 8
+	retentionMaxWeeks     = 12
+
+	// Not-onboarded lines shown in the reply; the full list is attached when longer
+	retentionListInline = 20
+	retentionListFile   = "not_onboarded.txt"
+)
+
+// Days after joining that /retention checks someone is still here
+var retentionDays = [3]int{1, 7, 30}
+
+// retentionWeek is one Monday–Sunday (UK) cohort of joins.
+type retentionWeek struct {
+	Start   time.Time
+	Joins   int
+	Rejoins int
+
+	// Per retentionDays: joins old enough to judge, and how many of those stayed
+	Eligible [3]int
+	Stayed   [3]int
+
+	// Seconds from join to finishing onboarding, for those who did
+	VerifyWaits []int64
+}
+
+func (m *Module) handleRetention(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isLevellingAdmin(i) {
+		m.respondEphemeral(s, i, "You need **Manage Server** (or Administrator) to use this.")
+		return
+	}
+	if m.tracker == nil {
+		m.respondEphemeral(s, i, "Join tracking is off (no member tracker is set up in main.go).")
+		return
+	}
+
+	view := "weeks"
+	weeks := retentionDefaultWeeks
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "view":
+			view = opt.StringValue()
+		case "weeks":
+			weeks = int(opt.IntValue())
+		}
+	}
+	if weeks < 1 {
+		weeks = 1
+	}
+	if weeks > retentionMaxWeeks {
+		weeks = retentionMaxWeeks
+	}
+
+	now := time.Now().In(ukLocation())
+	from := startOfRange(now, "weekly").AddDate(0, 0, -7*(weeks-1))
+	joins, err := m.tracker.JoinsBetween(from.Unix(), now.Unix()+1)
+	if err != nil {
+		log.Printf("[levelling] retention read failed: %v", err)
+		m.respondEphemeral(s, i, "DB error reading joins.")
+		return
+	}
+	if len(joins) == 0 {
+		m.respondEphemeral(s, i, "No joins recorded in that period yet. Joins are tracked from when the member tracker was added.")
+		return
+	}
+
+	data := &discordgo.InteractionResponseData{
+		Flags:           discordgo.MessageFlagsEphemeral,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if view == "not_onboarded" {
+		data.Embeds, data.Files = notOnboardedReport(joins, from, now)
+	} else {
+		data.Embeds = []*discordgo.MessageEmbed{retentionEmbed(retentionByWeek(joins, from, weeks, now.Unix()), now)}
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	}); err != nil {
+		log.Printf("[levelling] retention response failed: %v", err)
+	}
+}
+
+// retentionByWeek buckets joins into weekly cohorts starting at from (a Monday).
+func retentionByWeek(joins []members.Join, from time.Time, weeks int, now int64) []retentionWeek {
+	out := make([]retentionWeek, weeks)
+	for w := range out {
+		out[w].Start = from.AddDate(0, 0, 7*w)
+	}
+
+	for _, j := range joins {
+		w := sort.Search(weeks, func(k int) bool { return out[k].Start.Unix() > j.JoinedAt }) - 1
+		if w < 0 {
+			continue
+		}
+		c := &out[w]
+		c.Joins++
+		if j.Number > 1 {
+			c.Rejoins++
+		}
+		for d, days := range retentionDays {
+			after := int64(days) * 86400
+			if now-j.JoinedAt < after {
+				continue
+			}
+			c.Eligible[d]++
+			if j.LeftAt == 0 || j.LeftAt-j.JoinedAt >= after {
+				c.Stayed[d]++
+			}
+		}
+		if j.VerifiedAt > 0 {
+			c.VerifyWaits = append(c.VerifyWaits, max(0, j.VerifiedAt-j.JoinedAt))
+		}
+	}
+	return out
+}
+
+func retentionEmbed(cohorts []retentionWeek, now time.Time) *discordgo.MessageEmbed {
+	var b strings.Builder
+	b.WriteString("```\n")
+	fmt.Fprintf(&b, "%-7s %6s %5s %5s %5s %8s\n", "Week", "Joins", "1d", "7d", "30d", "Verify")
+	for idx := len(cohorts) - 1; idx >= 0; idx-- {
+		c := cohorts[idx]
+		joins := fmt.Sprint(c.Joins)
+		if c.Rejoins > 0 {
+			joins = fmt.Sprintf("%d↻%d", c.Joins, c.Rejoins)
+		}
+		fmt.Fprintf(&b, "%-7s %6s", c.Start.Format("02 Jan"), joins)
+		for d := range retentionDays {
+			fmt.Fprintf(&b, " %5s", percentOf(c.Stayed[d], c.Eligible[d]))
+		}
+		verify := "—"
+		if c.Joins > 0 {
+			verify = fmt.Sprintf("%d%%", len(c.VerifyWaits)*100/c.Joins)
+			if len(c.VerifyWaits) > 0 {
+				verify += " " + formatWait(median(c.VerifyWaits))
+			}
+		}
+		fmt.Fprintf(&b, " %8s\n", verify)
+	}
+	b.WriteString("```")
+
+	return &discordgo.MessageEmbed{
+		Title:       "📉 Join retention by week",
+		Description: b.String(),
+		Color:       0x5865F2,
+		Fields: []*discordgo.MessageEmbedField{{
+			Name: "How to read this",
+			Value: "**1d/7d/30d**: share of that week's joins still here after that long (— = too recent).\n" +
+				"**↻**: how many of the joins were rejoins.\n" +
+				"**Verify**: share who finished onboarding, and their median time to do it.\n" +
+				"Use `view:not_onboarded` to list who didn't.",
+		}},
+		Timestamp: now.Format(time.RFC3339),
+	}
+}
+
+// notOnboardedReport lists joins in the window that never finished onboarding: who's still here first.
+func notOnboardedReport(joins []members.Join, from, now time.Time) ([]*discordgo.MessageEmbed, []*discordgo.File) {
+	var pending []members.Join
+	for _, j := range joins {
+		if j.VerifiedAt == 0 {
+			pending = append(pending, j)
+		}
+	}
+	sort.SliceStable(pending, func(a, b int) bool {
+		if (pending[a].LeftAt == 0) != (pending[b].LeftAt == 0) {
+			return pending[a].LeftAt == 0
+		}
+		return pending[a].JoinedAt > pending[b].JoinedAt
+	})
+
+	line := func(j members.Join, who string) string {
+		joined := time.Unix(j.JoinedAt, 0).In(ukLocation()).Format("02 Jan 15:04")
+		if j.LeftAt > 0 {
+			return fmt.Sprintf("%s — joined %s, left after %s", who, joined, formatWait(j.LeftAt-j.JoinedAt))
+		}
+		return fmt.Sprintf("%s — joined %s, waiting %s", who, joined, formatWait(now.Unix()-j.JoinedAt))
+	}
+
+	stillHere := 0
+	for _, j := range pending {
+		if j.LeftAt == 0 {
+			stillHere++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Since %s: **%d** of **%d** joins never finished onboarding (**%d** still here, **%d** left).\n\n",
+		from.Format("02 Jan"), len(pending), len(joins), stillHere, len(pending)-stillHere)
+	for idx, j := range pending {
+		if idx == retentionListInline {
+			fmt.Fprintf(&b, "…and **%d** more (full list attached)", len(pending)-idx)
+			break
+		}
+		b.WriteString(line(j, "<@"+j.UserID+">") + "\n")
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🚪 Joiners who never finished onboarding",
+		Description: b.String(),
+		Color:       0xE67E22,
+		Timestamp:   now.Format(time.RFC3339),
+	}
+	if len(pending) <= retentionListInline {
+		return []*discordgo.MessageEmbed{embed}, nil
+	}
+
+	var full bytes.Buffer
+	for _, j := range pending {
+		name := j.Username
+		if name == "" {
+			name = "unknown"
+		}
+		full.WriteString(line(j, fmt.Sprintf("%s (%s)", name, j.UserID)) + "\n")
+	}
+	return []*discordgo.MessageEmbed{embed}, []*discordgo.File{{
+		Name:        retentionListFile,
+		ContentType: "text/plain",
+		Reader:      &full,
+	}}
+}
+
+func percentOf(n, total int) string {
+	if total == 0 {
+		return "—"
+	}
+	return fmt.Sprintf("%d%%", n*100/total)
+}
+
+func median(v []int64) int64 {
+	s := append([]int64(nil), v...)
+	sort.Slice(s, func(a, b int) bool { return s[a] < s[b] })
+	return s[len(s)/2]
+}
+
+// formatWait renders seconds as the largest sensible unit: "40s", "12m", "5h", "3d".
+func formatWait(sec int64) string {
+	switch {
+	case sec < 60:
+		return fmt.Sprintf("%ds", sec)
+	case sec < 3600:
+		return fmt.Sprintf("%dm", sec/60)
+	case sec < 86400:
+		return fmt.Sprintf("%dh", sec/3600)
+	}
+	return fmt.Sprintf("%dd", sec/86400)
+}
@@ -0,0 +1,657 @@
+package levelling
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	seasonCheckInterval = time.Minute
+	seasonBoardSize     = 10
+	xpActionSeason      = "season"
+	xpScopeSeason       = "season"
+
+	// ssn:<ownerID>:<seasonID>:<ok|no>
+	seasonCustomBase = "ssn"
+)
+
+type xpSeason struct {
+	ID           int64
+	Name         string
+	StartedAt    int64
+	EndsAt       int64
+	EndedAt      int64
+	ChannelID    string
+	TopN         int
+	RewardRoleID string
+	KeepPercent  int
+	RenewDays    int
+}
+
+func (se xpSeason) label() string {
+	if se.Name != "" {
+		return se.Name
+	}
+	return fmt.Sprintf("Season %d", se.ID)
+}
+
+/* =========================
+   DB
+   ========================= */
+
+const seasonCols = `id, name, started_at, ends_at, ended_at, channel_id, top_n, reward_role_id, keep_percent, renew_days`
+
+func scanSeason(row interface{ Scan(...any) error }) (xpSeason, error) {
+	var se xpSeason
+	err := row.Scan(&se.ID, &se.Name, &se.StartedAt, &se.EndsAt, &se.EndedAt, &se.ChannelID, &se.TopN, &se.RewardRoleID, &se.KeepPercent, &se.RenewDays)
+	return se, err
+}
+
+// currentSeason is the running season, if any.
+func (m *Module) currentSeason() (xpSeason, bool, error) {
+	se, err := scanSeason(m.db.QueryRow(`SELECT ` + seasonCols + ` FROM xp_seasons WHERE ended_at = 0 ORDER BY id DESC LIMIT 1`))
+	if err == sql.ErrNoRows {
+		return xpSeason{}, false, nil
+	}
+	return se, err == nil, err
+}
+
+func (m *Module) getSeason(id int64) (xpSeason, error) {
+	return scanSeason(m.db.QueryRow(`SELECT `+seasonCols+` FROM xp_seasons WHERE id = ?`, id))
+}
+
+func (m *Module) listEndedSeasons(limit int) ([]xpSeason, error) {
+	rows, err := m.db.Query(`SELECT `+seasonCols+` FROM xp_seasons WHERE ended_at != 0 ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []xpSeason
+	for rows.Next() {
+		se, err := scanSeason(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, se)
+	}
+	return out, rows.Err()
+}
+
+func (m *Module) insertSeason(se xpSeason) (int64, error) {
+	res, err := m.db.Exec(
+		`INSERT INTO xp_seasons(name, started_at, ends_at, ended_at, channel_id, top_n, reward_role_id, keep_percent, renew_days)
+		 VALUES(?,?,?,0,?,?,?,?,?)`,
+		se.Name, se.StartedAt, se.EndsAt, se.ChannelID, se.TopN, se.RewardRoleID, se.KeepPercent, se.RenewDays,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// listSeasonStandings returns a finished season's archived standings, best first.
+func (m *Module) listSeasonStandings(seasonID int64) ([]xpRow, error) {
+	rows, err := m.db.Query(
+		`SELECT user_id, xp FROM xp_season_standings WHERE season_id = ? ORDER BY position`,
+		seasonID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []xpRow
+	for rows.Next() {
+		var r xpRow
+		if err := rows.Scan(&r.UserID, &r.XP); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func (m *Module) getSeasonStanding(seasonID int64, userID string) (xp int64, level int, pos int64, err error) {
+	err = m.db.QueryRow(
+		`SELECT xp, level, position FROM xp_season_standings WHERE season_id = ? AND user_id = ?`,
+		seasonID, userID,
+	).Scan(&xp, &level, &pos)
+	if err == sql.ErrNoRows {
+		return 0, 0, 0, nil
+	}
+	return xp, level, pos, err
+}
+
+// archiveAndReset snapshots user_xp into the season's standings, applies the (soft) reset,
+// logs it to xp_history and closes the season, all in one transaction.
+func (m *Module) archiveAndReset(se xpSeason) ([]xpRow, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.Query(`SELECT user_id, xp FROM user_xp WHERE xp > 0 ORDER BY xp DESC, user_id`)
+	if err != nil {
+		return nil, err
+	}
+	var standings []xpRow
+	for rows.Next() {
+		var r xpRow
+		if err := rows.Scan(&r.UserID, &r.XP); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		standings = append(standings, r)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	for idx, r := range standings {
+		if _, err := tx.Exec(
+			`INSERT INTO xp_season_standings(season_id, user_id, position, xp, level) VALUES(?,?,?,?,?)`,
+			se.ID, r.UserID, idx+1, r.XP, m.levelForXP(r.XP),
+		); err != nil {
+			return nil, err
+		}
+
+		after := r.XP * int64(se.KeepPercent) / 100
+		if err := txInsertXPHistory(tx, xpHistoryRow{
+			UserID:    r.UserID,
+			Action:    xpActionSeason,
+			XPBefore:  r.XP,
+			XPAfter:   after,
+			Reason:    se.label() + " ended",
+			CreatedAt: now,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if se.KeepPercent > 0 {
+		_, err = tx.Exec(`UPDATE user_xp SET xp = xp * ? / 100`, se.KeepPercent)
+	} else {
+		_, err = tx.Exec(`DELETE FROM user_xp`)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.Exec(`UPDATE xp_seasons SET ended_at = ? WHERE id = ? AND ended_at = 0`, now, se.ID)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("season %d already ended", se.ID)
+	}
+	return standings, tx.Commit()
+}
+
+/* =========================
+   Ending a season
+   ========================= */
+
+func (m *Module) runSeasonScheduler(ctx context.Context, s *discordgo.Session) {
+	t := time.NewTicker(seasonCheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			se, ok, err := m.currentSeason()
+			if err != nil {
+				log.Printf("[levelling] read current season failed: %v", err)
+				continue
+			}
+			if ok && se.EndsAt > 0 && se.EndsAt <= time.Now().Unix() {
+				_, _ = m.endSeason(s, se)
+			}
+		}
+	}
+}
+
+// endSeason archives standings, resets XP, posts the final board, hands out reward roles
+// and starts the next season if the season renews.
+func (m *Module) endSeason(s *discordgo.Session, se xpSeason) (int, error) {
+	m.seasonMu.Lock()
+	defer m.seasonMu.Unlock()
+
+	standings, err := m.archiveAndReset(se)
+	if err != nil {
+		log.Printf("[levelling] end season %d failed: %v", se.ID, err)
+		return 0, err
+	}
+	log.Printf("[levelling] %s ended: %d users archived", se.label(), len(standings))
+
+	m.grantSeasonRewards(s, se, standings)
+	m.syncSeasonMilestones(s, se, standings)
+
+	if se.ChannelID != "" {
+		_, _ = s.ChannelMessageSendEmbed(se.ChannelID, m.seasonFinalEmbed(se, standings))
+	}
+
+	if se.RenewDays > 0 {
+		now := time.Now()
+		next := se
+		next.Name = ""
+		next.StartedAt = now.Unix()
+		next.EndsAt = now.AddDate(0, 0, se.RenewDays).Unix()
+		if id, err := m.insertSeason(next); err != nil {
+			log.Printf("[levelling] start next season failed: %v", err)
+		} else if se.ChannelID != "" {
+			next.ID = id
+			_, _ = s.ChannelMessageSend(se.ChannelID, fmt.Sprintf("🏁 **%s** has begun! It ends <t:%d:R>.", next.label(), next.EndsAt))
+		}
+	}
+	return len(standings), nil
+}
+
+// grantSeasonRewards moves the reward role from last season's winners to this season's top N.
+func (m *Module) grantSeasonRewards(s *discordgo.Session, se xpSeason, standings []xpRow) {
+	if se.RewardRoleID == "" || se.TopN <= 0 || m.guildID == "" {
+		return
+	}
+
+	winners := map[string]struct{}{}
+	for idx := 0; idx < len(standings) && idx < se.TopN; idx++ {
+		winners[standings[idx].UserID] = struct{}{}
+	}
+
+	// Previous holders of the same role who didn't place again lose it
+	var prev []string
+	rows, err := m.db.Query(
+		`SELECT st.user_id FROM xp_season_standings st
+		 JOIN xp_seasons se ON se.id = st.season_id
+		 WHERE se.id = (SELECT MAX(id) FROM xp_seasons WHERE id < ? AND ended_at != 0)
+		   AND se.reward_role_id = ? AND st.position <= se.top_n`,
+		se.ID, se.RewardRoleID,
+	)
+	if err == nil {
+		for rows.Next() {
+			var id string
+			if rows.Scan(&id) == nil {
+				prev = append(prev, id)
+			}
+		}
+		_ = rows.Close()
+	} else {
+		log.Printf("[levelling] read previous season winners failed: %v", err)
+	}
+
+	for _, userID := range prev {
+		if _, ok := winners[userID]; ok {
+			continue
+		}
+		if err := s.GuildMemberRoleRemove(m.guildID, userID, se.RewardRoleID); err != nil {
+			log.Printf("[levelling] season reward remove failed (user=%s): %v", userID, err)
+		}
+	}
+	for userID := range winners {
+		if err := s.GuildMemberRoleAdd(m.guildID, userID, se.RewardRoleID); err != nil {
+			log.Printf("[levelling] season reward add failed (user=%s): %v", userID, err)
+		}
+	}
+}
+
+// syncSeasonMilestones takes away milestone roles people no longer qualify for after the reset
+// (replace/strict modes only; stack keeps them).
+func (m *Module) syncSeasonMilestones(s *discordgo.Session, se xpSeason, standings []xpRow) {
+	if m.milestoneMode == MilestoneStack || m.guildID == "" {
+		return
+	}
+	for n, r := range standings {
+		after := r.XP * int64(se.KeepPercent) / 100
+		m.applyMilestoneDrop(s, m.guildID, r.UserID, m.levelForXP(after), false)
+		if (n+1)%milestoneSyncBatch == 0 {
+			time.Sleep(milestoneSyncPause)
+		}
+	}
+}
+
+func (m *Module) seasonFinalEmbed(se xpSeason, standings []xpRow) *discordgo.MessageEmbed {
+	var b strings.Builder
+	medals := []string{"🥇", "🥈", "🥉"}
+	for idx, r := range standings {
+		if idx == seasonBoardSize {
+			break
+		}
+		place := fmt.Sprintf("%d.", idx+1)
+		if idx < len(medals) {
+			place = medals[idx]
+		}
+		fmt.Fprintf(&b, "%s <@%s> — **Lvl %d** — **%d XP**\n", place, r.UserID, m.levelForXP(r.XP), r.XP)
+	}
+	if len(standings) == 0 {
+		b.WriteString("Nobody earned XP this season.\n")
+	}
+
+	if se.RewardRoleID != "" && se.TopN > 0 {
+		fmt.Fprintf(&b, "\nThe top **%d** get <@&%s>!", se.TopN, se.RewardRoleID)
+	}
+	if se.KeepPercent > 0 {
+		fmt.Fprintf(&b, "\nEveryone keeps **%d%%** of their XP going into the next season.", se.KeepPercent)
+	} else {
+		b.WriteString("\nXP has been reset. Good luck next season!")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "🏆 " + se.label() + " — final standings",
+		Description: b.String(),
+		Color:       0xF1C40F,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Use /leaderboard season:%d to see the full board", se.ID)},
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+}
+
+/* =========================
+   Season scope (/leaderboard, /rank)
+   ========================= */
+
+func (m *Module) seasonScope(id int64) (xpScope, error) {
+	se, err := m.getSeason(id)
+	if err == sql.ErrNoRows {
+		return xpScope{}, fmt.Errorf("there's no season %d (see /season list)", id)
+	}
+	if err != nil {
+		return xpScope{}, err
+	}
+	if se.EndedAt == 0 {
+		return xpScope{}, fmt.Errorf("%s is still running; use the normal leaderboard", se.label())
+	}
+	return xpScope{Kind: xpScopeSeason, Season: se.ID, Label: se.label()}, nil
+}
+
+// resolveScope picks the /leaderboard and /rank scope; a season wins over the time options.
+func (m *Module) resolveScope(kind, from, to string, season int64) (xpScope, error) {
+	if season > 0 {
+		return m.seasonScope(season)
+	}
+	return parseXPScope(kind, from, to, time.Now())
+}
+
+/* =========================
+   /season start|end|status|list
+   ========================= */
+
+func (m *Module) handleSeason(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 || data.Options[0] == nil {
+		m.respondEphemeral(s, i, "Missing subcommand.")
+		return
+	}
+	sub := data.Options[0]
+
+	switch sub.Name {
+	case "status":
+		m.respondSeasonStatus(s, i)
+		return
+	case "list":
+		m.respondSeasonList(s, i)
+		return
+	}
+
+	if !isLevellingAdmin(i) {
+		m.respondEphemeral(s, i, "You need **Manage Server** (or Administrator) to use this.")
+		return
+	}
+
+	switch sub.Name {
+	case "start":
+		m.handleSeasonStart(s, i, sub.Options)
+	case "end":
+		m.respondSeasonEndConfirm(s, i)
+	default:
+		m.respondEphemeral(s, i, "Unknown subcommand.")
+	}
+}
+
+func (m *Module) handleSeasonStart(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	if cur, ok, err := m.currentSeason(); err != nil {
+		m.respondEphemeral(s, i, "DB error reading seasons.")
+		return
+	} else if ok {
+		m.respondEphemeral(s, i, fmt.Sprintf("**%s** is already running (ends <t:%d:R>). End it first with `/season end`.", cur.label(), cur.EndsAt))
+		return
+	}
+
+	se := xpSeason{ChannelID: i.ChannelID}
+	days := 0
+	renew := false
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "name":
+			se.Name = strings.TrimSpace(opt.StringValue())
+		case "days":
+			days = int(opt.IntValue())
+		case "channel":
+			if ch := opt.ChannelValue(nil); ch != nil {
+				se.ChannelID = ch.ID
+			}
+		case "top_n":
+			se.TopN = int(opt.IntValue())
+		case "reward_role":
+			if r := opt.RoleValue(nil, ""); r != nil {
+				se.RewardRoleID = r.ID
+			}
+		case "keep_percent":
+			se.KeepPercent = int(opt.IntValue())
+		case "renew":
+			renew = opt.BoolValue()
+		}
+	}
+
+	if days <= 0 {
+		m.respondEphemeral(s, i, "Days must be at least **1**.")
+		return
+	}
+	if se.KeepPercent < 0 || se.KeepPercent > 100 {
+		m.respondEphemeral(s, i, "Keep percent must be between **0** and **100**.")
+		return
+	}
+	if renew {
+		se.RenewDays = days
+	}
+
+	now := time.Now()
+	se.StartedAt = now.Unix()
+	se.EndsAt = now.AddDate(0, 0, days).Unix()
+
+	id, err := m.insertSeason(se)
+	if err != nil {
+		log.Printf("[levelling] start season failed: %v", err)
+		m.respondEphemeral(s, i, "DB error starting the season.")
+		return
+	}
+	se.ID = id
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Ends <t:%d:f> (<t:%d:R>). Final standings will be posted in <#%s>.\n", se.EndsAt, se.EndsAt, se.ChannelID)
+	if se.RewardRoleID != "" && se.TopN > 0 {
+		fmt.Fprintf(&b, "The top **%d** get <@&%s>.\n", se.TopN, se.RewardRoleID)
+	}
+	if se.KeepPercent > 0 {
+		fmt.Fprintf(&b, "At the end everyone keeps **%d%%** of their XP.\n", se.KeepPercent)
+	} else {
+		b.WriteString("At the end XP resets to 0.\n")
+	}
+	if se.RenewDays > 0 {
+		fmt.Fprintf(&b, "A new %d-day season starts automatically afterwards.", se.RenewDays)
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "🏁 " + se.label() + " has begun!",
+				Description: b.String(),
+				Color:       0xF1C40F,
+			}},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+func (m *Module) respondSeasonEndConfirm(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	se, ok, err := m.currentSeason()
+	if err != nil {
+		m.respondEphemeral(s, i, "DB error reading seasons.")
+		return
+	}
+	if !ok {
+		m.respondEphemeral(s, i, "No season is running.")
+		return
+	}
+
+	n, _ := m.countXPUsers(i.GuildID)
+	reset := "XP resets to **0**."
+	if se.KeepPercent > 0 {
+		reset = fmt.Sprintf("XP is cut to **%d%%**.", se.KeepPercent)
+	}
+
+	ownerID := interactionUserID(i)
+	makeID := func(action string) string {
+		return fmt.Sprintf("%s:%s:%d:%s", seasonCustomBase, ownerID, se.ID, action)
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title: "End " + se.label() + " now?",
+				Description: fmt.Sprintf(
+					"Archives the standings of **%d** user(s) and posts the final leaderboard.\n%s",
+					n, reset,
+				),
+				Color: 0xED4245,
+			}},
+			Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "End season", Style: discordgo.DangerButton, CustomID: makeID("ok")},
+				discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: makeID("no")},
+			}}},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+func (m *Module) handleSeasonComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) != 4 || parts[0] != seasonCustomBase {
+		return
+	}
+	if interactionUserID(i) != parts[1] {
+		m.respondEphemeral(s, i, "Only the person who ran this command can use these buttons.")
+		return
+	}
+
+	update := func(content string) {
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:    content,
+				Embeds:     []*discordgo.MessageEmbed{},
+				Components: []discordgo.MessageComponent{},
+			},
+		})
+	}
+
+	if parts[3] != "ok" {
+		update("Cancelled, the season keeps running.")
+		return
+	}
+
+	id, _ := strconv.ParseInt(parts[2], 10, 64)
+	se, err := m.getSeason(id)
+	if err != nil || se.EndedAt != 0 {
+		update("That season has already ended.")
+		return
+	}
+
+	update("Ending the season…")
+	n, err := m.endSeason(s, se)
+	msg := fmt.Sprintf("✅ %s ended; **%d** user(s) archived.", se.label(), n)
+	if err != nil {
+		msg = "Ending the season failed; nothing was changed."
+	}
+	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+}
+
+func (m *Module) respondSeasonStatus(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	se, ok, err := m.currentSeason()
+	if err != nil {
+		m.respondEphemeral(s, i, "DB error reading seasons.")
+		return
+	}
+	if !ok {
+		m.respondEphemeral(s, i, "No season is running.")
+		return
+	}
+
+	msg := fmt.Sprintf("**%s** started <t:%d:D> and ends <t:%d:R>.", se.label(), se.StartedAt, se.EndsAt)
+	if se.RewardRoleID != "" && se.TopN > 0 {
+		msg += fmt.Sprintf("\nTop **%d** win <@&%s>.", se.TopN, se.RewardRoleID)
+	}
+	if se.KeepPercent > 0 {
+		msg += fmt.Sprintf("\nEveryone keeps **%d%%** of their XP afterwards.", se.KeepPercent)
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         msg,
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+func (m *Module) respondSeasonList(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	seasons, err := m.listEndedSeasons(15)
+	if err != nil {
+		m.respondEphemeral(s, i, "DB error reading seasons.")
+		return
+	}
+	if len(seasons) == 0 {
+		m.respondEphemeral(s, i, "No seasons have finished yet.")
+		return
+	}
+
+	var b strings.Builder
+	for _, se := range seasons {
+		fmt.Fprintf(&b, "`#%d` **%s** — <t:%d:d> to <t:%d:d>", se.ID, se.label(), se.StartedAt, se.EndedAt)
+		var winner string
+		if m.db.QueryRow(`SELECT user_id FROM xp_season_standings WHERE season_id = ? AND position = 1`, se.ID).Scan(&winner) == nil {
+			fmt.Fprintf(&b, " — 🥇 <@%s>", winner)
+		}
+		b.WriteString("\n")
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "Past seasons",
+				Description: b.String(),
+				Color:       0xF1C40F,
+				Footer:      &discordgo.MessageEmbedFooter{Text: "View one with /leaderboard season:<#>"},
+			}},
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
@@ -1,6 +1,10 @@
 package levelling
 
-import "database/sql"
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
 
 type xpRow struct {
 	UserID string
@@ -11,6 +15,16 @@ type joinRow struct {
 	UserID   string
 	Username string
 	JoinedAt int64
+
+	// Invite the join came through ('' = unknown)
+	InviteCode string
+	InviterID  string
+}
+
+// joinFilter narrows /joins to one invite and/or one inviter (empty = any).
+type joinFilter struct {
+	Code      string
+	InviterID string
 }
 
 /* =========================
@@ -127,6 +141,36 @@ func (m *Module) listAllXPUsers(limit int) ([]xpRow, error) {
 	return out, rows.Err()
 }
 
+// xpUsernames returns the last seen username for each of ids that has an XP row.
+func (m *Module) xpUsernames(ids []string) (map[string]string, error) {
+	out := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := m.db.Query(
+		`SELECT user_id, username FROM user_xp
+		 WHERE user_id IN (?`+strings.Repeat(",?", len(ids)-1)+`)`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		out[id] = name
+	}
+	return out, rows.Err()
+}
+
 // rank position = 1 + number of users strictly above this XP
 func (m *Module) getRankPosition(guildID string, xp int64) (int64, error) {
 	_ = guildID
@@ -142,6 +186,7 @@ func (m *Module) getRankPosition(guildID string, xp int64) (int64, error) {
    Joins
    ========================= */
 
+// upsertUserJoin stores a join time (backfill); the recorded invite, if any, is kept.
 func (m *Module) upsertUserJoin(userID, username string, joinedAt int64) error {
 	_, err := m.db.Exec(
 		`INSERT INTO user_joins(user_id, username, joined_at)
@@ -154,17 +199,34 @@ func (m *Module) upsertUserJoin(userID, username string, joinedAt int64) error {
 	return err
 }
 
-func (m *Module) listJoinsBetween(startUnix, endUnix int64, limit int) ([]joinRow, error) {
+// recordUserJoin stores a live join with its source, replacing whatever an earlier join left.
+func (m *Module) recordUserJoin(userID, username string, joinedAt int64, src joinSource) error {
+	_, err := m.db.Exec(
+		`INSERT INTO user_joins(user_id, username, joined_at, invite_code, inviter_id)
+		 VALUES(?,?,?,?,?)
+		 ON CONFLICT(user_id) DO UPDATE SET
+		   username = excluded.username,
+		   joined_at = excluded.joined_at,
+		   invite_code = excluded.invite_code,
+		   inviter_id = excluded.inviter_id`,
+		userID, username, joinedAt, src.Code, src.InviterID,
+	)
+	return err
+}
+
+func (m *Module) listJoinsBetween(startUnix, endUnix int64, limit int, f joinFilter) ([]joinRow, error) {
 	if limit <= 0 {
 		limit = 50
 	}
 	rows, err := m.db.Query(
-		`SELECT user_id, username, joined_at
+		`SELECT user_id, username, joined_at, invite_code, inviter_id
 		 FROM user_joins
 		 WHERE joined_at >= ? AND joined_at < ?
+		   AND (? = '' OR invite_code = ?)
+		   AND (? = '' OR inviter_id = ?)
 		 ORDER BY joined_at DESC
 		 LIMIT ?`,
-		startUnix, endUnix, limit,
+		startUnix, endUnix, f.Code, f.Code, f.InviterID, f.InviterID, limit,
 	)
 	if err != nil {
 		return nil, err
@@ -174,7 +236,7 @@ func (m *Module) listJoinsBetween(startUnix, endUnix int64, limit int) ([]joinRo
 	out := make([]joinRow, 0, limit)
 	for rows.Next() {
 		var r joinRow
-		if err := rows.Scan(&r.UserID, &r.Username, &r.JoinedAt); err != nil {
+		if err := rows.Scan(&r.UserID, &r.Username, &r.JoinedAt, &r.InviteCode, &r.InviterID); err != nil {
 			return nil, err
 		}
 		out = append(out, r)
@@ -182,6 +244,41 @@ func (m *Module) listJoinsBetween(startUnix, endUnix int64, limit int) ([]joinRo
 	return out, rows.Err()
 }
 
+// listInvitedJoins returns joins since startUnix that have a known inviter, oldest first.
+func (m *Module) listInvitedJoins(startUnix int64) ([]joinRow, error) {
+	rows, err := m.db.Query(
+		`SELECT user_id, username, joined_at, invite_code, inviter_id
+		 FROM user_joins
+		 WHERE inviter_id != '' AND joined_at >= ?
+		 ORDER BY joined_at`,
+		startUnix,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []joinRow
+	for rows.Next() {
+		var r joinRow
+		if err := rows.Scan(&r.UserID, &r.Username, &r.JoinedAt, &r.InviteCode, &r.InviterID); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// countUnknownJoins counts joins since startUnix with no recorded invite.
+func (m *Module) countUnknownJoins(startUnix int64) (int, error) {
+	var n int
+	err := m.db.QueryRow(
+		`SELECT COUNT(*) FROM user_joins WHERE inviter_id = '' AND joined_at >= ?`,
+		startUnix,
+	).Scan(&n)
+	return n, err
+}
+
 /* =========================
    Level-up messages + XP curve
    ========================= */
@@ -200,15 +297,16 @@ func (m *Module) saveLevelUpMessage(userID, username string, level int, channelI
 /*
 XP curve / level math:
 
-XP needed to go from level L -> L+1
+The curve is configurable (see curve.go); these go through the precomputed table.
 */
-func xpNeededForNext(level int) int64 {
-	l := int64(level)
-	return 7*l*l + 60*l + 100
+func (m *Module) levelCurve() *levelCurve {
+	m.curveMu.RLock()
+	defer m.curveMu.RUnlock()
+	return m.curve
 }
 
-func levelForXP(totalXP int64) int {
-	lvl, _, _ := breakdownXP(totalXP)
+func (m *Module) levelForXP(totalXP int64) int {
+	lvl, _, _ := m.breakdownXP(totalXP)
 	return lvl
 }
 
@@ -216,18 +314,39 @@ func levelForXP(totalXP int64) int {
 // - level
 // - xp into current level
 // - xp needed to reach next level
-func breakdownXP(totalXP int64) (int, int64, int64) {
-	if totalXP <= 0 {
-		return 0, 0, xpNeededForNext(0)
-	}
-	level := 0
-	remaining := totalXP
-	for {
-		need := xpNeededForNext(level)
-		if remaining < need {
-			return level, remaining, need
-		}
-		remaining -= need
-		level++
+func (m *Module) breakdownXP(totalXP int64) (int, int64, int64) {
+	return m.levelCurve().breakdown(totalXP)
+}
+
+/* =========================
+   Settings
+   ========================= */
+
+func (m *Module) getSetting(key string) (string, bool, error) {
+	var v string
+	err := m.db.QueryRow(`SELECT value FROM levelling_settings WHERE key = ?`, key).Scan(&v)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
 	}
+	return v, true, nil
+}
+
+func (m *Module) setSetting(key, value string) error {
+	_, err := m.db.Exec(
+		`INSERT INTO levelling_settings(key, value, updated_at)
+		 VALUES(?,?,?)
+		 ON CONFLICT(key) DO UPDATE SET
+		   value = excluded.value,
+		   updated_at = excluded.updated_at`,
+		key, value, time.Now().Unix(),
+	)
+	return err
+}
+
+func (m *Module) deleteSetting(key string) error {
+	_, err := m.db.Exec(`DELETE FROM levelling_settings WHERE key = ?`, key)
+	return err
 }
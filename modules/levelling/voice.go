@@ -0,0 +1,361 @@
+package levelling
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// VoiceXP configures XP for time spent in voice channels.
+type VoiceXP struct {
+	Enabled bool
+
+	// XP per eligible minute (unmuted, undeafened, with at least one other non-bot user)
+	XPPerMinute int64
+
+	// Voice channels that never earn XP (the server's AFK channel is always excluded)
+	ExcludedChannels []string
+}
+
+const (
+	voiceTickInterval = time.Minute
+	// Time between ticks above this isn't credited (e.g. the bot was offline)
+	voiceMaxGap = voiceTickInterval + 30*time.Second
+)
+
+type voiceSession struct {
+	UserID       string
+	ChannelID    string
+	JoinedAt     int64
+	LastTickAt   int64
+	CarrySeconds int64
+}
+
+type voiceMember struct {
+	state *discordgo.VoiceState
+	user  *discordgo.User
+	roles []string
+}
+
+func voiceMuted(vs *discordgo.VoiceState) bool {
+	return vs.Mute || vs.SelfMute || vs.Deaf || vs.SelfDeaf || vs.Suppress
+}
+
+/* =========================
+   Sessions (DB)
+   ========================= */
+
+func (m *Module) loadVoiceSessions() (map[string]voiceSession, error) {
+	rows, err := m.db.Query(`SELECT user_id, channel_id, joined_at, last_tick_at, carry_seconds FROM voice_sessions`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]voiceSession{}
+	for rows.Next() {
+		var v voiceSession
+		if err := rows.Scan(&v.UserID, &v.ChannelID, &v.JoinedAt, &v.LastTickAt, &v.CarrySeconds); err != nil {
+			return nil, err
+		}
+		out[v.UserID] = v
+	}
+	return out, rows.Err()
+}
+
+func (m *Module) saveVoiceSession(v voiceSession) error {
+	_, err := m.db.Exec(
+		`INSERT INTO voice_sessions(user_id, channel_id, joined_at, last_tick_at, carry_seconds)
+		 VALUES(?,?,?,?,?)
+		 ON CONFLICT(user_id) DO UPDATE SET
+		   channel_id = excluded.channel_id,
+		   joined_at = excluded.joined_at,
+		   last_tick_at = excluded.last_tick_at,
+		   carry_seconds = excluded.carry_seconds`,
+		v.UserID, v.ChannelID, v.JoinedAt, v.LastTickAt, v.CarrySeconds,
+	)
+	return err
+}
+
+func (m *Module) deleteVoiceSession(userID string) error {
+	_, err := m.db.Exec(`DELETE FROM voice_sessions WHERE user_id = ?`, userID)
+	return err
+}
+
+// getVoiceMinutes returns a user's total credited voice minutes.
+func (m *Module) getVoiceMinutes(userID string) (int64, error) {
+	var n int64
+	err := m.db.QueryRow(`SELECT minutes FROM user_voice WHERE user_id = ?`, userID).Scan(&n)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return n, err
+}
+
+// awardVoiceXP adds voice minutes + XP without touching the text-chat cooldown.
+func (m *Module) awardVoiceXP(userID, username, channelID string, minutes, gain int64) (oldXP, newXP int64, err error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	oldXP, _, err = m.txGetUserXPAndLast(tx, userID)
+	if err != nil {
+		return 0, 0, err
+	}
+	newXP = oldXP + gain
+
+	if gain > 0 {
+		_, err = tx.Exec(
+			`INSERT INTO user_xp(user_id, username, xp, last_xp_at)
+			 VALUES(?,?,?,0)
+			 ON CONFLICT(user_id) DO UPDATE SET
+			   username = excluded.username,
+			   xp = user_xp.xp + ?`,
+			userID, username, gain, gain,
+		)
+		if err != nil {
+			return 0, 0, err
+		}
+		if err := txRecordXPEvent(tx, userID, gain, channelID, xpSourceVoice, time.Now().Unix()); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO user_voice(user_id, minutes, xp, updated_at)
+		 VALUES(?,?,?,?)
+		 ON CONFLICT(user_id) DO UPDATE SET
+		   minutes = user_voice.minutes + excluded.minutes,
+		   xp = user_voice.xp + excluded.xp,
+		   updated_at = excluded.updated_at`,
+		userID, minutes, gain, time.Now().Unix(),
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	return oldXP, newXP, tx.Commit()
+}
+
+/* =========================
+   Tracking
+   ========================= */
+
+// onVoiceStateUpdate opens/closes sessions as people join, switch or leave.
+// Minutes are credited by the ticker, which also picks up anyone already in voice at startup.
+func (m *Module) onVoiceStateUpdate(s *discordgo.Session, e *discordgo.VoiceStateUpdate) {
+	if !m.voice.Enabled || m.db == nil || e == nil || e.VoiceState == nil {
+		return
+	}
+	if m.guildID != "" && e.GuildID != m.guildID {
+		return
+	}
+	if e.Member != nil && e.Member.User != nil && e.Member.User.Bot {
+		return
+	}
+
+	// Leaving: whatever hasn't reached a tick yet is dropped
+	if e.ChannelID == "" {
+		if err := m.deleteVoiceSession(e.UserID); err != nil {
+			log.Printf("[levelling] close voice session failed: %v", err)
+		}
+		return
+	}
+
+	// Mute/deafen toggles keep the session; the ticker checks eligibility each minute
+	if e.BeforeUpdate != nil && e.BeforeUpdate.ChannelID == e.ChannelID {
+		return
+	}
+
+	now := time.Now().Unix()
+	if err := m.saveVoiceSession(voiceSession{
+		UserID:     e.UserID,
+		ChannelID:  e.ChannelID,
+		JoinedAt:   now,
+		LastTickAt: now,
+	}); err != nil {
+		log.Printf("[levelling] open voice session failed: %v", err)
+	}
+}
+
+func (m *Module) runVoiceTicker(ctx context.Context, s *discordgo.Session) {
+	t := time.NewTicker(voiceTickInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			m.tickVoice(s)
+		}
+	}
+}
+
+// voiceSnapshot returns who is in which voice channel right now (bots and excluded channels removed).
+func (m *Module) voiceSnapshot(s *discordgo.Session) map[string][]voiceMember {
+	byChannel := map[string][]voiceMember{}
+	if s.State == nil {
+		return byChannel
+	}
+
+	var guildIDs []string
+	if m.guildID != "" {
+		guildIDs = []string{m.guildID}
+	} else {
+		s.State.RLock()
+		for _, g := range s.State.Guilds {
+			guildIDs = append(guildIDs, g.ID)
+		}
+		s.State.RUnlock()
+	}
+
+	for _, guildID := range guildIDs {
+		g, err := s.State.Guild(guildID)
+		if err != nil || g == nil {
+			continue
+		}
+
+		s.State.RLock()
+		states := make([]*discordgo.VoiceState, len(g.VoiceStates))
+		copy(states, g.VoiceStates)
+		afk := g.AfkChannelID
+		s.State.RUnlock()
+
+		for _, vs := range states {
+			if vs == nil || vs.ChannelID == "" || vs.ChannelID == afk {
+				continue
+			}
+			if _, skip := m.voiceExcluded[vs.ChannelID]; skip {
+				continue
+			}
+
+			mem := vs.Member
+			if mem == nil || mem.User == nil {
+				mem, _ = s.State.Member(guildID, vs.UserID)
+			}
+			if mem == nil || mem.User == nil || mem.User.Bot {
+				continue
+			}
+
+			byChannel[vs.ChannelID] = append(byChannel[vs.ChannelID], voiceMember{state: vs, user: mem.User, roles: mem.Roles})
+		}
+	}
+	return byChannel
+}
+
+// tickVoice credits elapsed time to everyone currently eligible and awards XP per full minute.
+func (m *Module) tickVoice(s *discordgo.Session) {
+	if m.db == nil {
+		return
+	}
+
+	sessions, err := m.loadVoiceSessions()
+	if err != nil {
+		log.Printf("[levelling] load voice sessions failed: %v", err)
+		return
+	}
+
+	now := time.Now().Unix()
+	present := map[string]struct{}{}
+
+	for channelID, members := range m.voiceSnapshot(s) {
+		for _, vm := range members {
+			userID := vm.user.ID
+			present[userID] = struct{}{}
+
+			sess, ok := sessions[userID]
+			if !ok || sess.ChannelID != channelID {
+				// Unknown (joined while offline) or switched without us seeing it: start fresh
+				sess = voiceSession{UserID: userID, ChannelID: channelID, JoinedAt: now, LastTickAt: now}
+				if err := m.saveVoiceSession(sess); err != nil {
+					log.Printf("[levelling] save voice session failed: %v", err)
+				}
+				continue
+			}
+
+			elapsed := now - sess.LastTickAt
+			if elapsed < 0 || time.Duration(elapsed)*time.Second > voiceMaxGap {
+				elapsed = 0
+			}
+
+			eligible := len(members) >= 2 && !voiceMuted(vm.state) && !m.xpBlocked(userID, vm.roles)
+			if eligible {
+				sess.CarrySeconds += elapsed
+			}
+			minutes := sess.CarrySeconds / 60
+			sess.CarrySeconds %= 60
+			sess.LastTickAt = now
+
+			if err := m.saveVoiceSession(sess); err != nil {
+				log.Printf("[levelling] save voice session failed: %v", err)
+				continue
+			}
+			if minutes > 0 {
+				m.creditVoice(s, vm, channelID, minutes)
+			}
+		}
+	}
+
+	// Anyone we have a session for who isn't in voice anymore (missed leave event / restart)
+	for userID := range sessions {
+		if _, ok := present[userID]; !ok {
+			if err := m.deleteVoiceSession(userID); err != nil {
+				log.Printf("[levelling] close voice session failed: %v", err)
+			}
+		}
+	}
+}
+
+func (m *Module) creditVoice(s *discordgo.Session, vm voiceMember, channelID string, minutes int64) {
+	gain := scaleXP(m.voice.XPPerMinute*minutes, m.xpMultiplier(channelID, vm.roles))
+
+	oldXP, newXP, err := m.awardVoiceXP(vm.user.ID, vm.user.Username, channelID, minutes, gain)
+	if err != nil {
+		log.Printf("[levelling] award voice xp failed: %v", err)
+		return
+	}
+
+	oldLevel := m.levelForXP(oldXP)
+	newLevel := m.levelForXP(newXP)
+	if newLevel <= oldLevel {
+		return
+	}
+
+	guildID := vm.state.GuildID
+	if guildID == "" {
+		guildID = m.guildID
+	}
+	m.applyMilestoneRoles(s, guildID, vm.user.ID, oldLevel, newLevel)
+
+	// Voice channels have their own text chat
+	m.sendLevelUp(s, channelID, vm.user, oldLevel, newLevel, "Keep talking to earn more XP!")
+}
+
+// formatVoiceMinutes renders minutes as "3h 25m".
+func formatVoiceMinutes(minutes int64) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", minutes/60, minutes%60)
+}
+
+func normalizeVoiceXP(v VoiceXP) (VoiceXP, map[string]struct{}) {
+	if v.XPPerMinute < 0 {
+		v.XPPerMinute = 0
+	}
+	excluded := map[string]struct{}{}
+	for _, id := range v.ExcludedChannels {
+		id = strings.TrimSpace(id)
+		if id != "" {
+			excluded[id] = struct{}{}
+		}
+	}
+	return v, excluded
+}
@@ -0,0 +1,135 @@
+package levelling
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ErrBadScope wraps Leaderboard errors caused by the query's scope/season (not the DB).
+var ErrBadScope = errors.New("invalid leaderboard scope")
+
+// LeaderboardQuery picks a page of the XP leaderboard for the web leaderboard (modules/web).
+type LeaderboardQuery struct {
+	// all | week | month | custom (From/To as YYYY-MM-DD, like /leaderboard)
+	Scope string
+	From  string
+	To    string
+
+	// A finished season's standings; wins over Scope
+	Season int64
+
+	// Include people who have left the server
+	Everyone bool
+
+	Limit  int
+	Offset int
+}
+
+// LeaderboardEntry is one ranked row. Level is only set for totals (not XP gained in a window).
+type LeaderboardEntry struct {
+	Rank   int    `json:"rank"`
+	UserID string `json:"user_id"`
+	Name   string `json:"name"`
+	XP     int64  `json:"xp"`
+	Level  int    `json:"level,omitempty"`
+}
+
+// LeaderboardPage is a page of the XP leaderboard plus the size of the whole board.
+type LeaderboardPage struct {
+	Label    string             `json:"label"`
+	Windowed bool               `json:"windowed"`
+	Total    int                `json:"total"`
+	Note     string             `json:"note,omitempty"`
+	Entries  []LeaderboardEntry `json:"entries"`
+}
+
+// Leaderboard reads the XP leaderboard the same way /leaderboard does (filtered to current
+// members unless q.Everyone). The all-time board with everyone is paged in SQL.
+func (m *Module) Leaderboard(s *discordgo.Session, q LeaderboardQuery) (LeaderboardPage, error) {
+	scope, err := m.resolveScope(q.Scope, q.From, q.To, q.Season)
+	if err != nil {
+		return LeaderboardPage{}, fmt.Errorf("%w: %v", ErrBadScope, err)
+	}
+	if q.Limit <= 0 {
+		q.Limit = lbPageSize
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+
+	page := LeaderboardPage{Label: scope.Label, Windowed: scope.windowed()}
+
+	var rows []xpRow
+	switch {
+	case q.Everyone && scope.Kind == xpScopeAll:
+		if page.Total, err = m.countXPUsers(m.guildID); err != nil {
+			return LeaderboardPage{}, err
+		}
+		if rows, err = m.queryTopXPPage(m.guildID, q.Limit, q.Offset); err != nil {
+			return LeaderboardPage{}, err
+		}
+	case q.Everyone:
+		all, err := m.getLeaderboardRows(scope)
+		if err != nil {
+			return LeaderboardPage{}, err
+		}
+		page.Total = len(all)
+		rows = pageOf(all, q.Limit, q.Offset)
+	default:
+		all, note, err := m.getLeaderboardRowsFiltered(s, m.guildID, scope)
+		if err != nil {
+			return LeaderboardPage{}, err
+		}
+		page.Total = len(all)
+		page.Note = note
+		rows = pageOf(all, q.Limit, q.Offset)
+	}
+
+	names := m.displayNames(rows)
+	page.Entries = make([]LeaderboardEntry, 0, len(rows))
+	for idx, r := range rows {
+		e := LeaderboardEntry{Rank: q.Offset + idx + 1, UserID: r.UserID, Name: names[r.UserID], XP: r.XP}
+		if !page.Windowed {
+			e.Level = m.levelForXP(r.XP)
+		}
+		page.Entries = append(page.Entries, e)
+	}
+	return page, nil
+}
+
+func pageOf(rows []xpRow, limit, offset int) []xpRow {
+	if offset >= len(rows) {
+		return nil
+	}
+	end := offset + limit
+	if end > len(rows) {
+		end = len(rows)
+	}
+	return rows[offset:end]
+}
+
+// displayNames prefers the member tracker, then the username saved with their XP.
+func (m *Module) displayNames(rows []xpRow) map[string]string {
+	out := make(map[string]string, len(rows))
+	var missing []string
+	for _, r := range rows {
+		if m.tracker != nil {
+			if mem, ok := m.tracker.Get(r.UserID); ok {
+				out[r.UserID] = mem.DisplayName()
+				continue
+			}
+		}
+		missing = append(missing, r.UserID)
+	}
+
+	saved, err := m.xpUsernames(missing)
+	if err != nil {
+		return out
+	}
+	for id, name := range saved {
+		out[id] = name
+	}
+	return out
+}
@@ -0,0 +1,451 @@
+package levelling
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	xpActionGive  = "give"
+	xpActionTake  = "take"
+	xpActionSet   = "set"
+	xpActionReset = "reset"
+
+	// xpr:<ownerID>:<userID|all>:<0|1 remove roles>:<ok|no>
+	xpResetCustomBase = "xpr"
+	xpResetAll        = "all"
+
+	xpHistoryPageSize = 15
+)
+
+type xpHistoryRow struct {
+	UserID    string
+	Action    string
+	XPBefore  int64
+	XPAfter   int64
+	ActorID   string
+	Reason    string
+	CreatedAt int64
+}
+
+/* =========================
+   DB
+   ========================= */
+
+func txInsertXPHistory(tx *sql.Tx, r xpHistoryRow) error {
+	_, err := tx.Exec(
+		`INSERT INTO xp_history(user_id, action, delta, xp_before, xp_after, actor_id, reason, created_at)
+		 VALUES(?,?,?,?,?,?,?,?)`,
+		r.UserID, r.Action, r.XPAfter-r.XPBefore, r.XPBefore, r.XPAfter, r.ActorID, r.Reason, r.CreatedAt,
+	)
+	return err
+}
+
+// adjustUserXP applies give/take/set to one user and logs it. XP never goes below 0.
+// The text-chat cooldown (last_xp_at) is left alone.
+func (m *Module) adjustUserXP(userID, username, action string, amount int64, actorID, reason string) (before, after int64, err error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	before, _, err = m.txGetUserXPAndLast(tx, userID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch action {
+	case xpActionGive:
+		after = before + amount
+	case xpActionTake:
+		after = before - amount
+	default:
+		after = amount
+	}
+	if after < 0 {
+		after = 0
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO user_xp(user_id, username, xp, last_xp_at)
+		 VALUES(?,?,?,0)
+		 ON CONFLICT(user_id) DO UPDATE SET
+		   username = CASE WHEN excluded.username != '' THEN excluded.username ELSE user_xp.username END,
+		   xp = excluded.xp`,
+		userID, username, after,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	now := time.Now().Unix()
+	if err := txInsertXPHistory(tx, xpHistoryRow{
+		UserID:    userID,
+		Action:    action,
+		XPBefore:  before,
+		XPAfter:   after,
+		ActorID:   actorID,
+		Reason:    reason,
+		CreatedAt: now,
+	}); err != nil {
+		return 0, 0, err
+	}
+	// Counts toward weekly/monthly boards too (resets don't; they aren't gains or losses in a period)
+	if err := txRecordXPEvent(tx, userID, after-before, "", xpSourceAdmin, now); err != nil {
+		return 0, 0, err
+	}
+	return before, after, tx.Commit()
+}
+
+// resetXP wipes one user (or everyone when userID is empty) and logs each reset.
+// Returns the users that had XP, with their XP before the reset.
+func (m *Module) resetXP(userID, actorID string) ([]xpRow, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	q := `SELECT user_id, xp FROM user_xp WHERE xp > 0`
+	var args []any
+	if userID != "" {
+		q += ` AND user_id = ?`
+		args = append(args, userID)
+	}
+
+	rows, err := tx.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	var reset []xpRow
+	for rows.Next() {
+		var r xpRow
+		if err := rows.Scan(&r.UserID, &r.XP); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		reset = append(reset, r)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	for _, r := range reset {
+		if err := txInsertXPHistory(tx, xpHistoryRow{
+			UserID:    r.UserID,
+			Action:    xpActionReset,
+			XPBefore:  r.XP,
+			XPAfter:   0,
+			ActorID:   actorID,
+			CreatedAt: now,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if userID != "" {
+		_, err = tx.Exec(`DELETE FROM user_xp WHERE user_id = ?`, userID)
+	} else {
+		_, err = tx.Exec(`DELETE FROM user_xp`)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return reset, tx.Commit()
+}
+
+func (m *Module) listXPHistory(userID string, limit int) ([]xpHistoryRow, error) {
+	rows, err := m.db.Query(
+		`SELECT user_id, action, xp_before, xp_after, actor_id, reason, created_at
+		 FROM xp_history
+		 WHERE user_id = ?
+		 ORDER BY id DESC
+		 LIMIT ?`,
+		userID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]xpHistoryRow, 0, limit)
+	for rows.Next() {
+		var r xpHistoryRow
+		if err := rows.Scan(&r.UserID, &r.Action, &r.XPBefore, &r.XPAfter, &r.ActorID, &r.Reason, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+/* =========================
+   /xp give|take|set|reset|history
+   ========================= */
+
+func (m *Module) handleXPAdmin(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if strings.TrimSpace(i.GuildID) == "" {
+		m.respondEphemeral(s, i, "This command only works in a server.")
+		return
+	}
+	if !isLevellingAdmin(i) {
+		m.respondEphemeral(s, i, "You need **Manage Server** (or Administrator) to use this.")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 || data.Options[0] == nil {
+		m.respondEphemeral(s, i, "Missing subcommand.")
+		return
+	}
+	sub := data.Options[0]
+
+	var (
+		target      *discordgo.User
+		amount      int64 = -1
+		removeRoles bool
+		reason      string
+	)
+	for _, opt := range sub.Options {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "user":
+			target = opt.UserValue(s)
+		case "amount":
+			amount = opt.IntValue()
+		case "remove_roles":
+			removeRoles = opt.BoolValue()
+		case "reason":
+			reason = strings.TrimSpace(opt.StringValue())
+		}
+	}
+	// Only stack mode lets milestone roles outlive the level that earned them
+	if m.milestoneMode != MilestoneStack {
+		removeRoles = true
+	}
+
+	switch sub.Name {
+	case xpActionGive, xpActionTake, xpActionSet:
+		if target == nil {
+			m.respondEphemeral(s, i, "Pick a user.")
+			return
+		}
+		if amount < 0 {
+			m.respondEphemeral(s, i, "Amount must be **0 or higher**.")
+			return
+		}
+		m.handleXPAdjust(s, i, sub.Name, target, amount, removeRoles, reason)
+
+	case xpActionReset:
+		m.respondXPResetConfirm(s, i, target, removeRoles)
+
+	case "history":
+		if target == nil {
+			m.respondEphemeral(s, i, "Pick a user.")
+			return
+		}
+		m.respondXPHistory(s, i, target)
+
+	default:
+		m.respondEphemeral(s, i, "Unknown subcommand.")
+	}
+}
+
+func (m *Module) handleXPAdjust(s *discordgo.Session, i *discordgo.InteractionCreate, action string, target *discordgo.User, amount int64, removeRoles bool, reason string) {
+	before, after, err := m.adjustUserXP(target.ID, target.Username, action, amount, interactionUserID(i), reason)
+	if err != nil {
+		log.Printf("[levelling] /xp %s failed: %v", action, err)
+		m.respondEphemeral(s, i, "DB error updating XP.")
+		return
+	}
+
+	oldLevel := m.levelForXP(before)
+	newLevel := m.levelForXP(after)
+
+	roles := "unchanged"
+	switch {
+	case newLevel > oldLevel:
+		m.applyMilestoneRoles(s, i.GuildID, target.ID, oldLevel, newLevel)
+		roles = "milestone roles added"
+	case newLevel < oldLevel && removeRoles:
+		m.applyMilestoneDrop(s, i.GuildID, target.ID, newLevel, true)
+		roles = "milestone roles above the new level removed"
+	case newLevel < oldLevel:
+		roles = "kept (use `remove_roles` to take them away)"
+	}
+
+	msg := fmt.Sprintf(
+		"✅ <@%s>: **%d** → **%d** XP (level **%d** → **%d**)\nRoles: %s",
+		target.ID, before, after, oldLevel, newLevel, roles,
+	)
+	if reason != "" {
+		msg += "\nReason: " + reason
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         msg,
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+func xpResetCustomID(ownerID, targetID string, removeRoles bool, action string) string {
+	rr := "0"
+	if removeRoles {
+		rr = "1"
+	}
+	return strings.Join([]string{xpResetCustomBase, ownerID, targetID, rr, action}, ":")
+}
+
+func (m *Module) respondXPResetConfirm(s *discordgo.Session, i *discordgo.InteractionCreate, target *discordgo.User, removeRoles bool) {
+	targetID, who := xpResetAll, "**everyone**"
+	if target != nil {
+		targetID, who = target.ID, "<@"+target.ID+">"
+	}
+
+	desc := fmt.Sprintf("This sets XP to **0** for %s.", who)
+	if target == nil {
+		if n, err := m.countXPUsers(i.GuildID); err == nil {
+			desc += fmt.Sprintf("\n**%d** user(s) will be reset.", n)
+		}
+	} else if xp, err := m.getUserXP(i.GuildID, target.ID); err == nil {
+		desc += fmt.Sprintf("\nCurrent XP: **%d** (level **%d**).", xp, m.levelForXP(xp))
+	}
+	if removeRoles {
+		desc += "\nMilestone roles will be **removed**."
+	} else {
+		desc += "\nMilestone roles will be kept."
+	}
+	desc += "\nEvery reset is recorded in the XP history."
+
+	ownerID := interactionUserID(i)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "Reset XP?",
+				Description: desc,
+				Color:       0xED4245,
+			}},
+			Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Reset", Style: discordgo.DangerButton, CustomID: xpResetCustomID(ownerID, targetID, removeRoles, "ok")},
+				discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: xpResetCustomID(ownerID, targetID, removeRoles, "no")},
+			}}},
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+func (m *Module) handleXPResetComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) != 5 || parts[0] != xpResetCustomBase {
+		return
+	}
+
+	ownerID, targetID, removeRoles, action := parts[1], parts[2], parts[3] == "1", parts[4]
+	if interactionUserID(i) != ownerID {
+		m.respondEphemeral(s, i, "Only the person who ran this command can use these buttons.")
+		return
+	}
+
+	update := func(content string) {
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:         content,
+				Embeds:          []*discordgo.MessageEmbed{},
+				Components:      []discordgo.MessageComponent{},
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+			},
+		})
+	}
+
+	if action != "ok" {
+		update("Cancelled, nothing was changed.")
+		return
+	}
+
+	userID := targetID
+	if targetID == xpResetAll {
+		userID = ""
+	}
+
+	update("Resetting XP…")
+
+	reset, err := m.resetXP(userID, ownerID)
+	if err != nil {
+		log.Printf("[levelling] xp reset failed: %v", err)
+		msg := "DB error resetting XP; nothing was changed."
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+		return
+	}
+
+	if removeRoles && len(m.levelRoles) > 0 {
+		for n, r := range reset {
+			m.applyMilestoneDrop(s, i.GuildID, r.UserID, 0, true)
+			if (n+1)%milestoneSyncBatch == 0 {
+				time.Sleep(milestoneSyncPause)
+			}
+		}
+	}
+
+	who := "everyone"
+	if userID != "" {
+		who = "<@" + userID + ">"
+	}
+	msg := fmt.Sprintf("✅ Reset XP for %s (**%d** user(s) had XP).", who, len(reset))
+	if removeRoles {
+		msg += " Milestone roles removed."
+	}
+	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+}
+
+func (m *Module) respondXPHistory(s *discordgo.Session, i *discordgo.InteractionCreate, target *discordgo.User) {
+	rows, err := m.listXPHistory(target.ID, xpHistoryPageSize)
+	if err != nil {
+		log.Printf("[levelling] read xp history failed: %v", err)
+		m.respondEphemeral(s, i, "DB error reading XP history.")
+		return
+	}
+	if len(rows) == 0 {
+		m.respondEphemeral(s, i, fmt.Sprintf("No XP changes recorded for <@%s>.", target.ID))
+		return
+	}
+
+	var b strings.Builder
+	for _, r := range rows {
+		fmt.Fprintf(&b, "<t:%d:d> **%s** %d → %d (%+d) by <@%s>", r.CreatedAt, r.Action, r.XPBefore, r.XPAfter, r.XPAfter-r.XPBefore, r.ActorID)
+		if r.Reason != "" {
+			fmt.Fprintf(&b, " — %s", r.Reason)
+		}
+		b.WriteString("\n")
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "XP history — " + target.Username,
+				Description: b.String(),
+				Color:       0x5865F2,
+				Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Latest %d changes", len(rows))},
+			}},
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
@@ -0,0 +1,254 @@
+package levelling
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	settingXPCurve    = "xp_curve"
+	curvePreviewLevel = 100
+)
+
+// loadStoredCurve switches to the curve saved by /xpcurve set, if any.
+func (m *Module) loadStoredCurve() {
+	if m.db == nil {
+		return
+	}
+	raw, ok, err := m.getSetting(settingXPCurve)
+	if err != nil {
+		log.Printf("[levelling] read stored XP curve failed: %v", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	c, err := unmarshalCurve(raw)
+	if err != nil {
+		log.Printf("[levelling] stored XP curve is invalid (%v); keeping default", err)
+		return
+	}
+	lc, err := newLevelCurve(c)
+	if err != nil {
+		log.Printf("[levelling] stored XP curve is invalid (%v); keeping default", err)
+		return
+	}
+
+	m.curveMu.Lock()
+	m.curve = lc
+	m.curveMu.Unlock()
+	log.Printf("[levelling] using stored XP curve (%s)", c)
+}
+
+/* =========================
+   /xpcurve show|preview|set|reset
+   ========================= */
+
+func (m *Module) handleXPCurve(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isLevellingAdmin(i) {
+		m.respondEphemeral(s, i, "You need **Manage Server** (or Administrator) to use this.")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 || data.Options[0] == nil {
+		m.respondEphemeral(s, i, "Missing subcommand.")
+		return
+	}
+	sub := data.Options[0]
+
+	kind, values := "", ""
+	for _, opt := range sub.Options {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "kind":
+			kind = opt.StringValue()
+		case "values":
+			values = opt.StringValue()
+		}
+	}
+
+	current := m.levelCurve()
+
+	switch sub.Name {
+	case "show":
+		m.respondCurvePreview(s, i, "Current XP curve", current, nil)
+
+	case "preview":
+		c, err := parseCurve(kind, values)
+		if err != nil {
+			m.respondEphemeral(s, i, "Invalid curve: "+err.Error())
+			return
+		}
+		lc, err := newLevelCurve(c)
+		if err != nil {
+			m.respondEphemeral(s, i, "Invalid curve: "+err.Error())
+			return
+		}
+		m.respondCurvePreview(s, i, "XP curve preview (not applied)", current, lc)
+
+	case "set":
+		c, err := parseCurve(kind, values)
+		if err != nil {
+			m.respondEphemeral(s, i, "Invalid curve: "+err.Error())
+			return
+		}
+		m.switchCurve(s, i, c, true)
+
+	case "reset":
+		m.switchCurve(s, i, m.defaultCurve, false)
+
+	default:
+		m.respondEphemeral(s, i, "Unknown subcommand.")
+	}
+}
+
+// respondCurvePreview shows levels 1–100 for cur, side by side with next if given.
+func (m *Module) respondCurvePreview(s *discordgo.Session, i *discordgo.InteractionCreate, title string, cur, next *levelCurve) {
+	table := func(from, to int) string {
+		var b strings.Builder
+		b.WriteString("```\n")
+		if next == nil {
+			fmt.Fprintf(&b, "%3s %12s %10s\n", "Lv", "total XP", "step")
+		} else {
+			fmt.Fprintf(&b, "%3s %12s %12s %10s\n", "Lv", "now total", "new total", "new step")
+		}
+		for lvl := from; lvl <= to; lvl++ {
+			if next == nil {
+				fmt.Fprintf(&b, "%3d %12d %10d\n", lvl, cur.totalFor(lvl), cur.totalFor(lvl)-cur.totalFor(lvl-1))
+			} else {
+				fmt.Fprintf(&b, "%3d %12d %12d %10d\n", lvl, cur.totalFor(lvl), next.totalFor(lvl), next.totalFor(lvl)-next.totalFor(lvl-1))
+			}
+		}
+		b.WriteString("```")
+		return b.String()
+	}
+
+	desc := "**Current:** " + cur.curve.String()
+	if next != nil {
+		desc += "\n**New:** " + next.curve.String()
+		if users, err := m.listAllXPUsers(0); err == nil {
+			up, down := 0, 0
+			for _, u := range users {
+				a, _, _ := cur.breakdown(u.XP)
+				b, _, _ := next.breakdown(u.XP)
+				switch {
+				case b > a:
+					up++
+				case b < a:
+					down++
+				}
+			}
+			desc += fmt.Sprintf("\nSwitching would move **%d** user(s) up and **%d** down a level or more.", up, down)
+		}
+	}
+
+	half := curvePreviewLevel / 2
+	embeds := []*discordgo.MessageEmbed{
+		{
+			Title:       title,
+			Description: desc + "\n" + table(1, half),
+			Color:       0x5865F2,
+		},
+		{
+			Description: table(half+1, curvePreviewLevel),
+			Color:       0x5865F2,
+			Footer:      &discordgo.MessageEmbedFooter{Text: "Total XP = XP needed to reach that level from 0"},
+			Timestamp:   time.Now().Format(time.RFC3339),
+		},
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: embeds,
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// switchCurve applies a new curve, stores (or clears) it, and recomputes everyone's level.
+// Users who moved up get any milestone roles they now qualify for; users who moved down
+// only lose roles in replace/strict mode.
+func (m *Module) switchCurve(s *discordgo.Session, i *discordgo.InteractionCreate, c Curve, store bool) {
+	lc, err := newLevelCurve(c)
+	if err != nil {
+		m.respondEphemeral(s, i, "Invalid curve: "+err.Error())
+		return
+	}
+
+	if store {
+		raw, err := marshalCurve(c)
+		if err == nil {
+			err = m.setSetting(settingXPCurve, raw)
+		}
+		if err != nil {
+			log.Printf("[levelling] store XP curve failed: %v", err)
+			m.respondEphemeral(s, i, "DB error saving the curve.")
+			return
+		}
+	} else if err := m.deleteSetting(settingXPCurve); err != nil {
+		log.Printf("[levelling] clear XP curve failed: %v", err)
+		m.respondEphemeral(s, i, "DB error resetting the curve.")
+		return
+	}
+
+	// Fast ACK (ephemeral); recomputing roles can take a while
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Switching XP curve and recomputing levels…",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	m.curveMu.Lock()
+	old := m.curve
+	m.curve = lc
+	m.curveMu.Unlock()
+
+	users, err := m.listAllXPUsers(0)
+	if err != nil {
+		msg := "Curve switched, but reading users failed so no roles were updated."
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+		return
+	}
+
+	up, down, synced := 0, 0, 0
+	for _, u := range users {
+		a, _, _ := old.breakdown(u.XP)
+		b, _, _ := lc.breakdown(u.XP)
+		touched := false
+		switch {
+		case b > a:
+			up++
+			m.applyMilestoneRoles(s, i.GuildID, u.UserID, a, b)
+			touched = true
+		case b < a:
+			down++
+			touched = m.applyMilestoneDrop(s, i.GuildID, u.UserID, b, false)
+		}
+		if touched {
+			synced++
+			if synced%milestoneSyncBatch == 0 {
+				time.Sleep(milestoneSyncPause)
+			}
+		}
+	}
+
+	roles := "Milestone roles were added for users who moved up; none were removed."
+	if m.milestoneMode != MilestoneStack {
+		roles = fmt.Sprintf("Milestone roles were re-synced (**%s** mode) for everyone who moved.", m.milestoneMode)
+	}
+	msg := fmt.Sprintf(
+		"✅ XP curve is now **%s**\nUsers recomputed: **%d** (up: **%d**, down: **%d**)\n%s",
+		lc.curve.String(), len(users), up, down, roles,
+	)
+	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+}
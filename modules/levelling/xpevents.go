@@ -0,0 +1,296 @@
+package levelling
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	xpSourceText  = "text"
+	xpSourceVoice = "voice"
+	xpSourceAdmin = "admin"
+
+	// Raw xp_events are kept this long, then folded into xp_daily (one row per user per UK day)
+	xpEventsKeepDays = 35
+	xpRollupInterval = time.Hour
+
+	xpScopeAll    = "all"
+	xpScopeWeek   = "week"
+	xpScopeMonth  = "month"
+	xpScopeCustom = "custom"
+
+	xpScopeDateLayout = "2006-01-02"
+)
+
+// xpScope is a time window for /leaderboard and /rank ([From, To) in unix seconds),
+// or a finished season's archived standings.
+type xpScope struct {
+	Kind   string
+	From   int64
+	To     int64
+	Season int64
+	Label  string
+}
+
+// windowed reports whether the scope counts XP gained in a period (not totals).
+func (sc xpScope) windowed() bool { return sc.Kind != xpScopeAll && sc.Kind != xpScopeSeason }
+
+// key is the compact form stored in leaderboard button IDs.
+func (sc xpScope) key() string {
+	switch sc.Kind {
+	case xpScopeCustom:
+		return fmt.Sprintf("c%d-%d", sc.From, sc.To)
+	case xpScopeSeason:
+		return fmt.Sprintf("s%d", sc.Season)
+	}
+	return sc.Kind
+}
+
+func ukLocation() *time.Location {
+	loc, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+func ukDayStart(t time.Time) time.Time {
+	t = t.In(ukLocation())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// parseXPScope turns the /leaderboard and /rank options into a window.
+// Custom ranges are whole UK days; "to" is inclusive.
+func parseXPScope(kind, from, to string, now time.Time) (xpScope, error) {
+	now = now.In(ukLocation())
+	if kind == "" && strings.TrimSpace(from) != "" {
+		kind = xpScopeCustom
+	}
+
+	switch kind {
+	case "", xpScopeAll:
+		return xpScope{Kind: xpScopeAll, Label: "All time"}, nil
+	case xpScopeWeek:
+		return xpScope{Kind: xpScopeWeek, From: startOfRange(now, "weekly").Unix(), To: now.Unix() + 1, Label: "This week"}, nil
+	case xpScopeMonth:
+		return xpScope{Kind: xpScopeMonth, From: startOfRange(now, "monthly").Unix(), To: now.Unix() + 1, Label: "This month"}, nil
+	case xpScopeCustom:
+		if strings.TrimSpace(from) == "" {
+			return xpScope{}, errors.New("a custom range needs `from` (YYYY-MM-DD)")
+		}
+		start, err := time.ParseInLocation(xpScopeDateLayout, strings.TrimSpace(from), now.Location())
+		if err != nil {
+			return xpScope{}, fmt.Errorf("`from` must look like 2025-01-31")
+		}
+		end := ukDayStart(now)
+		if strings.TrimSpace(to) != "" {
+			end, err = time.ParseInLocation(xpScopeDateLayout, strings.TrimSpace(to), now.Location())
+			if err != nil {
+				return xpScope{}, fmt.Errorf("`to` must look like 2025-01-31")
+			}
+		}
+		end = end.AddDate(0, 0, 1)
+		if !end.After(start) {
+			return xpScope{}, errors.New("`to` must be on or after `from`")
+		}
+		return xpScope{
+			Kind:  xpScopeCustom,
+			From:  start.Unix(),
+			To:    end.Unix(),
+			Label: start.Format("2 Jan 2006") + " – " + end.AddDate(0, 0, -1).Format("2 Jan 2006"),
+		}, nil
+	}
+	return xpScope{}, fmt.Errorf("unknown scope %q", kind)
+}
+
+// xpScopeFromKey rebuilds a scope from a leaderboard button ID. Week/month move with the clock.
+func (m *Module) xpScopeFromKey(key string, now time.Time) xpScope {
+	if rest, ok := strings.CutPrefix(key, "s"); ok {
+		if id, err := strconv.ParseInt(rest, 10, 64); err == nil {
+			if sc, err := m.seasonScope(id); err == nil {
+				return sc
+			}
+		}
+	}
+	if rest, ok := strings.CutPrefix(key, "c"); ok {
+		a, b, _ := strings.Cut(rest, "-")
+		from, err1 := strconv.ParseInt(a, 10, 64)
+		to, err2 := strconv.ParseInt(b, 10, 64)
+		if err1 == nil && err2 == nil && to > from {
+			loc := ukLocation()
+			return xpScope{
+				Kind:  xpScopeCustom,
+				From:  from,
+				To:    to,
+				Label: time.Unix(from, 0).In(loc).Format("2 Jan 2006") + " – " + time.Unix(to, 0).In(loc).AddDate(0, 0, -1).Format("2 Jan 2006"),
+			}
+		}
+	}
+	sc, err := parseXPScope(key, "", "", now)
+	if err != nil {
+		sc, _ = parseXPScope(xpScopeAll, "", "", now)
+	}
+	return sc
+}
+
+/* =========================
+   Recording
+   ========================= */
+
+// txRecordXPEvent logs an XP change (negative for /xp take) alongside the user_xp update.
+func txRecordXPEvent(tx *sql.Tx, userID string, amount int64, channelID, source string, at int64) error {
+	if amount == 0 {
+		return nil
+	}
+	_, err := tx.Exec(
+		`INSERT INTO xp_events(user_id, amount, channel_id, source, created_at) VALUES(?,?,?,?,?)`,
+		userID, amount, channelID, source, at,
+	)
+	return err
+}
+
+/* =========================
+   Rollups
+   ========================= */
+
+// rollupXPEvents folds raw events older than xpEventsKeepDays into xp_daily, one UK day at a time.
+func (m *Module) rollupXPEvents() error {
+	if m.db == nil {
+		return nil
+	}
+	cutoff := ukDayStart(time.Now().AddDate(0, 0, -xpEventsKeepDays))
+
+	var oldest sql.NullInt64
+	if err := m.db.QueryRow(`SELECT MIN(created_at) FROM xp_events WHERE created_at < ?`, cutoff.Unix()).Scan(&oldest); err != nil {
+		return err
+	}
+	if !oldest.Valid {
+		return nil
+	}
+
+	days := 0
+	for day := ukDayStart(time.Unix(oldest.Int64, 0)); day.Before(cutoff); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		if err := m.rollupXPDay(day.Unix(), next.Unix()); err != nil {
+			return err
+		}
+		days++
+	}
+	log.Printf("[levelling] rolled up %d day(s) of xp_events", days)
+	return nil
+}
+
+func (m *Module) rollupXPDay(from, to int64) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.Exec(
+		`INSERT INTO xp_daily(day, user_id, xp)
+		 SELECT ?, user_id, SUM(amount) FROM xp_events
+		 WHERE created_at >= ? AND created_at < ?
+		 GROUP BY user_id
+		 ON CONFLICT(day, user_id) DO UPDATE SET xp = xp_daily.xp + excluded.xp`,
+		from, from, to,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM xp_events WHERE created_at >= ? AND created_at < ?`, from, to); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Module) runXPRollups(ctx context.Context) {
+	t := time.NewTicker(xpRollupInterval)
+	defer t.Stop()
+
+	for {
+		if err := m.rollupXPEvents(); err != nil {
+			log.Printf("[levelling] xp rollup failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+/* =========================
+   Windowed queries
+   ========================= */
+
+// Rolled-up days and raw events never overlap (events are deleted as they're rolled up),
+// so a window is simply the sum of both. Windows start on UK midnights, matching xp_daily.
+const xpWindowSQL = `
+	SELECT user_id, SUM(x) AS gained FROM (
+		SELECT user_id, xp AS x FROM xp_daily WHERE day >= ? AND day < ?
+		UNION ALL
+		SELECT user_id, amount AS x FROM xp_events WHERE created_at >= ? AND created_at < ?
+	)`
+
+// listXPGained returns everyone who gained XP in the window, best first.
+func (m *Module) listXPGained(sc xpScope) ([]xpRow, error) {
+	rows, err := m.db.Query(
+		xpWindowSQL+` GROUP BY user_id HAVING gained > 0 ORDER BY gained DESC, user_id`,
+		sc.From, sc.To, sc.From, sc.To,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []xpRow
+	for rows.Next() {
+		var r xpRow
+		if err := rows.Scan(&r.UserID, &r.XP); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// getXPGained returns one user's XP gained in the window and their position (0 if they gained nothing).
+func (m *Module) getXPGained(userID string, sc xpScope) (gained int64, pos int64, err error) {
+	var g sql.NullInt64
+	err = m.db.QueryRow(
+		`SELECT SUM(x) FROM (
+			SELECT xp AS x FROM xp_daily WHERE user_id = ? AND day >= ? AND day < ?
+			UNION ALL
+			SELECT amount AS x FROM xp_events WHERE user_id = ? AND created_at >= ? AND created_at < ?
+		)`,
+		userID, sc.From, sc.To, userID, sc.From, sc.To,
+	).Scan(&g)
+	if err != nil || g.Int64 <= 0 {
+		return 0, 0, err
+	}
+
+	err = m.db.QueryRow(
+		`SELECT COUNT(*) FROM (`+xpWindowSQL+` GROUP BY user_id HAVING gained > ?)`,
+		sc.From, sc.To, sc.From, sc.To, g.Int64,
+	).Scan(&pos)
+	return g.Int64, pos + 1, err
+}
+
+// weekGainedField is the "XP gained this week" line on /rank.
+func (m *Module) weekGainedField(userID string) *discordgo.MessageEmbedField {
+	sc, _ := parseXPScope(xpScopeWeek, "", "", time.Now())
+	gained, _, err := m.getXPGained(userID, sc)
+	if err != nil {
+		log.Printf("[levelling] read weekly xp failed: %v", err)
+	}
+	return &discordgo.MessageEmbedField{Name: "XP this week", Value: fmt.Sprintf("**%d**", gained), Inline: true}
+}
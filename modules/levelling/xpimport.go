@@ -0,0 +1,900 @@
+package levelling
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	xpActionImport         = "import"
+	xpActionImportRollback = "import-rollback"
+
+	// How imported XP combines with what we already have
+	xpImportModeMax     = "max"
+	xpImportModeSum     = "sum"
+	xpImportModeReplace = "replace"
+
+	// MEE6 and Arcane share the 5L² + 50L + 100 curve; other sources map levels onto ours
+	xpImportSourceMEE6    = "mee6"
+	xpImportSourceArcane  = "arcane"
+	xpImportSourceTatsu   = "tatsu"
+	xpImportSourceGeneric = "generic"
+
+	xpImportMaxFile     = 10 << 20
+	xpImportPendingTTL  = 15 * time.Minute
+	xpImportPreviewRows = 10
+
+	// xpi:<ownerID>:<imp|snapshot id>:<ok|no>
+	xpImportCustomBase = "xpi"
+	xpImportKindApply  = "imp"
+)
+
+var mee6Curve = Curve{Kind: CurvePolynomial, Coeffs: []float64{100, 50, 5}}
+
+// Column / JSON key names we recognise (lowercase)
+var (
+	xpImportIDKeys    = []string{"id", "user_id", "userid", "discord_id", "member_id", "user"}
+	xpImportXPKeys    = []string{"xp", "exp", "experience", "total_xp", "totalxp", "score", "points"}
+	xpImportLevelKeys = []string{"level", "lvl"}
+	xpImportNameKeys  = []string{"username", "name", "user_name", "tag"}
+	xpImportListKeys  = []string{"players", "users", "members", "leaderboard", "rankings", "data"}
+)
+
+// xpImportRecord is one row of an uploaded file.
+type xpImportRecord struct {
+	UserID   string
+	Username string
+	XP       int64
+	Level    int
+	HasXP    bool
+	HasLevel bool
+}
+
+// xpImportChange is one user whose XP the import changes. Imported is the value after
+// mapping onto our curve; After is recomputed against fresh XP when it's applied.
+type xpImportChange struct {
+	UserID   string
+	Username string
+	Imported int64
+	Before   int64
+	After    int64
+	Existing bool
+}
+
+type xpImportPlan struct {
+	OwnerID  string
+	Source   string
+	Mode     string
+	FileName string
+	Parsed   int
+	Skipped  int
+	Changes  []xpImportChange
+	Created  time.Time
+}
+
+// pendingImports holds previews waiting for their confirm button (one per admin).
+type pendingImports struct {
+	mu    sync.Mutex
+	plans map[string]*xpImportPlan
+}
+
+func (p *pendingImports) put(plan *xpImportPlan) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.plans == nil {
+		p.plans = map[string]*xpImportPlan{}
+	}
+	p.plans[plan.OwnerID] = plan
+}
+
+// take removes and returns the owner's plan if it hasn't expired.
+func (p *pendingImports) take(ownerID string) *xpImportPlan {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	plan := p.plans[ownerID]
+	delete(p.plans, ownerID)
+	if plan == nil || time.Since(plan.Created) > xpImportPendingTTL {
+		return nil
+	}
+	return plan
+}
+
+type xpImportSnapshot struct {
+	ID        int64
+	CreatedAt int64
+	ActorID   string
+	Source    string
+	Mode      string
+	FileName  string
+	Users     int
+}
+
+/* =========================
+   Parsing
+   ========================= */
+
+// parseXPImport reads a JSON or CSV export. Rows without a valid user ID or any XP/level are skipped;
+// if a user appears twice the higher value wins.
+func parseXPImport(raw []byte) (records []xpImportRecord, skipped int, err error) {
+	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return nil, 0, errors.New("the file is empty")
+	}
+
+	var rows []map[string]any
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		rows, err = xpImportJSONRows(trimmed)
+	} else {
+		rows, err = xpImportCSVRows(trimmed)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	byUser := map[string]int{}
+	for _, row := range rows {
+		rec, ok := xpImportRecordFrom(row)
+		if !ok {
+			skipped++
+			continue
+		}
+		if idx, dup := byUser[rec.UserID]; dup {
+			prev := records[idx]
+			if rec.XP > prev.XP || rec.Level > prev.Level {
+				records[idx] = rec
+			}
+			skipped++
+			continue
+		}
+		byUser[rec.UserID] = len(records)
+		records = append(records, rec)
+	}
+	if len(records) == 0 {
+		return nil, skipped, errors.New("no usable rows (need a user ID column plus xp or level)")
+	}
+	return records, skipped, nil
+}
+
+func xpImportJSONRows(raw []byte) ([]map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	var list []any
+	switch t := v.(type) {
+	case []any:
+		list = t
+	case map[string]any:
+		lower := lowerKeys(t)
+		for _, k := range xpImportListKeys {
+			if l, ok := lower[k].([]any); ok {
+				list = l
+				break
+			}
+		}
+		if list == nil {
+			// {"<user id>": xp} or {"<user id>": {...}}
+			for id, val := range t {
+				row := map[string]any{"id": id}
+				if obj, ok := val.(map[string]any); ok {
+					for k, v := range obj {
+						row[k] = v
+					}
+				} else {
+					row["xp"] = val
+				}
+				list = append(list, row)
+			}
+		}
+	default:
+		return nil, errors.New("expected a JSON list of users")
+	}
+
+	out := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			out = append(out, nil)
+			continue
+		}
+		out = append(out, lowerKeys(obj))
+	}
+	return out, nil
+}
+
+func xpImportCSVRows(raw []byte) ([]map[string]any, error) {
+	r := csv.NewReader(bytes.NewReader(raw))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+	for idx := range header {
+		header[idx] = importKey(header[idx])
+	}
+
+	var out []map[string]any
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		row := map[string]any{}
+		for idx, val := range rec {
+			if idx < len(header) {
+				row[header[idx]] = val
+			}
+		}
+		out = append(out, row)
+	}
+	return out, nil
+}
+
+// importKey normalises a column / JSON key: "User ID" and "user-id" both become "user_id".
+func importKey(k string) string {
+	k = strings.ToLower(strings.TrimSpace(k))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(k)
+}
+
+func lowerKeys(in map[string]any) map[string]any {
+	out := make(map[string]any, len(in))
+	for k, v := range in {
+		out[importKey(k)] = v
+	}
+	return out
+}
+
+func xpImportRecordFrom(row map[string]any) (xpImportRecord, bool) {
+	var rec xpImportRecord
+	if row == nil {
+		return rec, false
+	}
+
+	for _, k := range xpImportIDKeys {
+		v, ok := row[k]
+		if !ok {
+			continue
+		}
+		// Some exports nest the user: {"user": {"id": "...", "username": "..."}}
+		if obj, isObj := v.(map[string]any); isObj {
+			obj = lowerKeys(obj)
+			v = obj["id"]
+			if name, ok := obj["username"].(string); ok {
+				rec.Username = name
+			}
+		}
+		if id := strings.TrimSpace(fmt.Sprint(v)); isSnowflake(id) {
+			rec.UserID = id
+			break
+		}
+	}
+	if rec.UserID == "" {
+		return rec, false
+	}
+
+	for _, k := range xpImportNameKeys {
+		if v, ok := row[k].(string); ok && rec.Username == "" {
+			rec.Username = strings.TrimSpace(v)
+		}
+	}
+	for _, k := range xpImportXPKeys {
+		if n, ok := xpImportNumber(row[k]); ok {
+			rec.XP, rec.HasXP = n, true
+			break
+		}
+	}
+	for _, k := range xpImportLevelKeys {
+		if n, ok := xpImportNumber(row[k]); ok {
+			rec.Level, rec.HasLevel = int(min(n, curveMaxLevel)), true
+			break
+		}
+	}
+	return rec, rec.HasXP || rec.HasLevel
+}
+
+func xpImportNumber(v any) (int64, bool) {
+	var s string
+	switch t := v.(type) {
+	case json.Number:
+		s = t.String()
+	case string:
+		s = t
+	case float64:
+		s = strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return 0, false
+	}
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 || math.IsInf(f, 0) || math.IsNaN(f) || f > math.MaxInt64/4 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+func isSnowflake(s string) bool {
+	if len(s) < 15 || len(s) > 21 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+/* =========================
+   Mapping + planning
+   ========================= */
+
+// importedXP maps a record onto our curve. MEE6/Arcane XP keeps the same level and
+// progress through it; elsewhere a level maps to the start of that level on our curve,
+// and plain XP is taken as-is.
+func (m *Module) importedXP(source string, rec xpImportRecord, src *levelCurve) int64 {
+	lc := m.levelCurve()
+	switch {
+	case src != nil && rec.HasXP:
+		lvl, into, need := src.breakdown(rec.XP)
+		ours := lc.totalFor(lvl)
+		if need > 0 {
+			ours += into * (lc.totalFor(lvl+1) - ours) / need
+		}
+		return ours
+	case rec.HasLevel:
+		return lc.totalFor(rec.Level)
+	}
+	return rec.XP
+}
+
+func combineImportedXP(mode string, current, imported int64) int64 {
+	switch mode {
+	case xpImportModeSum:
+		return current + imported
+	case xpImportModeReplace:
+		return imported
+	}
+	return max(current, imported)
+}
+
+func (m *Module) planXPImport(ownerID, source, mode, fileName string, records []xpImportRecord, skipped int) (*xpImportPlan, error) {
+	var src *levelCurve
+	if source == xpImportSourceMEE6 || source == xpImportSourceArcane {
+		src, _ = newLevelCurve(mee6Curve)
+	}
+
+	current := map[string]int64{}
+	rows, err := m.db.Query(`SELECT user_id, xp FROM user_xp`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id string
+		var xp int64
+		if err := rows.Scan(&id, &xp); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		current[id] = xp
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	plan := &xpImportPlan{
+		OwnerID:  ownerID,
+		Source:   source,
+		Mode:     mode,
+		FileName: fileName,
+		Parsed:   len(records),
+		Skipped:  skipped,
+		Created:  time.Now(),
+	}
+	for _, rec := range records {
+		imported := m.importedXP(source, rec, src)
+		before, existing := current[rec.UserID]
+		after := combineImportedXP(mode, before, imported)
+		if after == before && existing {
+			continue
+		}
+		plan.Changes = append(plan.Changes, xpImportChange{
+			UserID:   rec.UserID,
+			Username: rec.Username,
+			Imported: imported,
+			Before:   before,
+			After:    after,
+			Existing: existing,
+		})
+	}
+
+	// Biggest changes first (what the preview shows)
+	sort.SliceStable(plan.Changes, func(a, b int) bool {
+		da := plan.Changes[a].After - plan.Changes[a].Before
+		db := plan.Changes[b].After - plan.Changes[b].Before
+		if da < 0 {
+			da = -da
+		}
+		if db < 0 {
+			db = -db
+		}
+		return da > db
+	})
+	return plan, nil
+}
+
+/* =========================
+   Applying + rollback (DB)
+   ========================= */
+
+// applyXPImport snapshots every affected user_xp row, then writes the import, all in one transaction.
+// Before/After on the returned changes reflect the XP at the moment it was applied.
+// Imported XP isn't an xp_event, so it doesn't show up on weekly/monthly boards.
+func (m *Module) applyXPImport(plan *xpImportPlan) (int64, []xpImportChange, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now().Unix()
+	res, err := tx.Exec(
+		`INSERT INTO xp_import_snapshots(created_at, actor_id, source, mode, file_name, users) VALUES(?,?,?,?,?,?)`,
+		now, plan.OwnerID, plan.Source, plan.Mode, plan.FileName, len(plan.Changes),
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+	snapshotID, err := res.LastInsertId()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	reason := fmt.Sprintf("/xpimport %s (%s)", plan.Source, plan.Mode)
+	applied := make([]xpImportChange, 0, len(plan.Changes))
+	for _, c := range plan.Changes {
+		var (
+			hadRow   = true
+			username string
+			xp       int64
+			lastXPAt int64
+		)
+		err := tx.QueryRow(`SELECT username, xp, last_xp_at FROM user_xp WHERE user_id = ?`, c.UserID).Scan(&username, &xp, &lastXPAt)
+		if err == sql.ErrNoRows {
+			hadRow = false
+		} else if err != nil {
+			return 0, nil, err
+		}
+
+		if _, err := tx.Exec(
+			`INSERT INTO xp_import_snapshot_rows(snapshot_id, user_id, had_row, username, xp, last_xp_at) VALUES(?,?,?,?,?,?)`,
+			snapshotID, c.UserID, hadRow, username, xp, lastXPAt,
+		); err != nil {
+			return 0, nil, err
+		}
+
+		c.Before = xp
+		c.After = combineImportedXP(plan.Mode, xp, c.Imported)
+		if _, err := tx.Exec(
+			`INSERT INTO user_xp(user_id, username, xp, last_xp_at)
+			 VALUES(?,?,?,0)
+			 ON CONFLICT(user_id) DO UPDATE SET
+			   username = CASE WHEN user_xp.username = '' THEN excluded.username ELSE user_xp.username END,
+			   xp = excluded.xp`,
+			c.UserID, c.Username, c.After,
+		); err != nil {
+			return 0, nil, err
+		}
+		if err := txInsertXPHistory(tx, xpHistoryRow{
+			UserID:    c.UserID,
+			Action:    xpActionImport,
+			XPBefore:  c.Before,
+			XPAfter:   c.After,
+			ActorID:   plan.OwnerID,
+			Reason:    reason,
+			CreatedAt: now,
+		}); err != nil {
+			return 0, nil, err
+		}
+		applied = append(applied, c)
+	}
+
+	return snapshotID, applied, tx.Commit()
+}
+
+// latestXPImport is the most recent import that hasn't been rolled back.
+func (m *Module) latestXPImport() (xpImportSnapshot, bool, error) {
+	var sn xpImportSnapshot
+	err := m.db.QueryRow(
+		`SELECT id, created_at, actor_id, source, mode, file_name, users
+		 FROM xp_import_snapshots WHERE rolled_back_at = 0 ORDER BY id DESC LIMIT 1`,
+	).Scan(&sn.ID, &sn.CreatedAt, &sn.ActorID, &sn.Source, &sn.Mode, &sn.FileName, &sn.Users)
+	if err == sql.ErrNoRows {
+		return sn, false, nil
+	}
+	if err != nil {
+		return sn, false, err
+	}
+	return sn, true, nil
+}
+
+// rollbackXPImport puts every row the import touched back the way it was (users the import
+// created are removed). XP those users earned since the import is lost.
+func (m *Module) rollbackXPImport(snapshotID int64, actorID string) ([]xpImportChange, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now().Unix()
+	res, err := tx.Exec(`UPDATE xp_import_snapshots SET rolled_back_at = ? WHERE id = ? AND rolled_back_at = 0`, now, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("import %d was already rolled back", snapshotID)
+	}
+
+	type snapRow struct {
+		userID, username string
+		hadRow           bool
+		xp, lastXPAt     int64
+	}
+	rows, err := tx.Query(`SELECT user_id, had_row, username, xp, last_xp_at FROM xp_import_snapshot_rows WHERE snapshot_id = ?`, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	var snap []snapRow
+	for rows.Next() {
+		var r snapRow
+		if err := rows.Scan(&r.userID, &r.hadRow, &r.username, &r.xp, &r.lastXPAt); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		snap = append(snap, r)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	reason := fmt.Sprintf("/xpimport rollback #%d", snapshotID)
+	changes := make([]xpImportChange, 0, len(snap))
+	for _, r := range snap {
+		cur, _, err := m.txGetUserXPAndLast(tx, r.userID)
+		if err != nil {
+			return nil, err
+		}
+		if r.hadRow {
+			_, err = tx.Exec(
+				`INSERT INTO user_xp(user_id, username, xp, last_xp_at)
+				 VALUES(?,?,?,?)
+				 ON CONFLICT(user_id) DO UPDATE SET xp = excluded.xp, last_xp_at = excluded.last_xp_at`,
+				r.userID, r.username, r.xp, r.lastXPAt,
+			)
+		} else {
+			_, err = tx.Exec(`DELETE FROM user_xp WHERE user_id = ?`, r.userID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := txInsertXPHistory(tx, xpHistoryRow{
+			UserID:    r.userID,
+			Action:    xpActionImportRollback,
+			XPBefore:  cur,
+			XPAfter:   r.xp,
+			ActorID:   actorID,
+			Reason:    reason,
+			CreatedAt: now,
+		}); err != nil {
+			return nil, err
+		}
+		changes = append(changes, xpImportChange{UserID: r.userID, Before: cur, After: r.xp})
+	}
+	return changes, tx.Commit()
+}
+
+// syncImportRoles updates milestone roles for everyone whose level moved.
+func (m *Module) syncImportRoles(s *discordgo.Session, guildID string, changes []xpImportChange) {
+	if len(m.levelRoles) == 0 {
+		return
+	}
+	touched := 0
+	for _, c := range changes {
+		a, b := m.levelForXP(c.Before), m.levelForXP(c.After)
+		switch {
+		case b > a:
+			m.applyMilestoneRoles(s, guildID, c.UserID, a, b)
+		case b < a:
+			if !m.applyMilestoneDrop(s, guildID, c.UserID, b, false) {
+				continue
+			}
+		default:
+			continue
+		}
+		touched++
+		if touched%milestoneSyncBatch == 0 {
+			time.Sleep(milestoneSyncPause)
+		}
+	}
+}
+
+/* =========================
+   /xpimport file|rollback
+   ========================= */
+
+func (m *Module) handleXPImport(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isLevellingAdmin(i) {
+		m.respondEphemeral(s, i, "You need **Manage Server** (or Administrator) to use this.")
+		return
+	}
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 || data.Options[0] == nil {
+		m.respondEphemeral(s, i, "Missing subcommand.")
+		return
+	}
+	sub := data.Options[0]
+
+	switch sub.Name {
+	case "file":
+		m.handleXPImportFile(s, i, sub.Options)
+	case "rollback":
+		m.respondXPImportRollbackConfirm(s, i)
+	default:
+		m.respondEphemeral(s, i, "Unknown subcommand.")
+	}
+}
+
+func (m *Module) handleXPImportFile(s *discordgo.Session, i *discordgo.InteractionCreate, opts []*discordgo.ApplicationCommandInteractionDataOption) {
+	source, mode := xpImportSourceGeneric, xpImportModeMax
+	var file *discordgo.MessageAttachment
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		switch opt.Name {
+		case "file":
+			id, _ := opt.Value.(string)
+			if r := i.ApplicationCommandData().Resolved; r != nil {
+				file = r.Attachments[id]
+			}
+		case "source":
+			source = opt.StringValue()
+		case "mode":
+			mode = opt.StringValue()
+		}
+	}
+	if file == nil {
+		m.respondEphemeral(s, i, "Attach the exported JSON or CSV file.")
+		return
+	}
+	if int64(file.Size) > xpImportMaxFile {
+		m.respondEphemeral(s, i, fmt.Sprintf("That file is too large (max %d MB).", xpImportMaxFile>>20))
+		return
+	}
+
+	// Downloading + parsing can take a moment
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	}); err != nil {
+		return
+	}
+	editText := func(msg string) {
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+	}
+
+	raw, err := fetchBytes(file.URL, xpImportMaxFile)
+	if err != nil {
+		log.Printf("[levelling] fetch xp import failed: %v", err)
+		editText("Couldn't download that file.")
+		return
+	}
+	records, skipped, err := parseXPImport(raw)
+	if err != nil {
+		editText("Couldn't read that file: " + err.Error())
+		return
+	}
+
+	ownerID := interactionUserID(i)
+	plan, err := m.planXPImport(ownerID, source, mode, file.Filename, records, skipped)
+	if err != nil {
+		log.Printf("[levelling] plan xp import failed: %v", err)
+		editText("DB error reading current XP.")
+		return
+	}
+	if len(plan.Changes) == 0 {
+		editText(fmt.Sprintf("Read **%d** user(s) (skipped **%d** rows), but nobody's XP would change in **%s** mode.", plan.Parsed, plan.Skipped, mode))
+		return
+	}
+	m.imports.put(plan)
+
+	embeds := []*discordgo.MessageEmbed{m.xpImportPreviewEmbed(plan)}
+	components := []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: fmt.Sprintf("Import %d user(s)", len(plan.Changes)), Style: discordgo.DangerButton, CustomID: xpImportCustomID(ownerID, xpImportKindApply, "ok")},
+		discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: xpImportCustomID(ownerID, xpImportKindApply, "no")},
+	}}}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:          &embeds,
+		Components:      &components,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		log.Printf("[levelling] xp import preview failed: %v", err)
+	}
+}
+
+func xpImportCustomID(ownerID, kind, action string) string {
+	return strings.Join([]string{xpImportCustomBase, ownerID, kind, action}, ":")
+}
+
+func (m *Module) xpImportPreviewEmbed(plan *xpImportPlan) *discordgo.MessageEmbed {
+	newUsers, up, down := 0, 0, 0
+	var delta int64
+	for _, c := range plan.Changes {
+		if !c.Existing {
+			newUsers++
+		}
+		switch a, b := m.levelForXP(c.Before), m.levelForXP(c.After); {
+		case b > a:
+			up++
+		case b < a:
+			down++
+		}
+		delta += c.After - c.Before
+	}
+
+	var b strings.Builder
+	for idx, c := range plan.Changes {
+		if idx >= xpImportPreviewRows {
+			break
+		}
+		fmt.Fprintf(&b, "<@%s>: %d → **%d** XP (Lvl %d → **%d**)\n", c.UserID, c.Before, c.After, m.levelForXP(c.Before), m.levelForXP(c.After))
+	}
+
+	sign := "+"
+	if delta < 0 {
+		sign = ""
+	}
+	return &discordgo.MessageEmbed{
+		Title: "XP import preview",
+		Description: fmt.Sprintf(
+			"File: `%s` — source **%s**, mode **%s**\nRows read: **%d** (skipped **%d**)\nUsers changing: **%d** (new: **%d**, level up: **%d**, level down: **%d**)\nTotal XP change: **%s%d**",
+			plan.FileName, plan.Source, plan.Mode, plan.Parsed, plan.Skipped, len(plan.Changes), newUsers, up, down, sign, delta,
+		),
+		Color:  0xFEE75C,
+		Fields: []*discordgo.MessageEmbedField{{Name: "Biggest changes", Value: b.String()}},
+		Footer: &discordgo.MessageEmbedFooter{Text: "Nothing is written until you confirm • /xpimport rollback undoes the last import"},
+	}
+}
+
+func (m *Module) respondXPImportRollbackConfirm(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	sn, ok, err := m.latestXPImport()
+	if err != nil {
+		m.respondEphemeral(s, i, "DB error reading imports.")
+		return
+	}
+	if !ok {
+		m.respondEphemeral(s, i, "There's no import to roll back.")
+		return
+	}
+
+	ownerID := interactionUserID(i)
+	kind := strconv.FormatInt(sn.ID, 10)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title: "Roll back XP import?",
+				Description: fmt.Sprintf(
+					"Import **#%d** (`%s`, %s / %s) by <@%s> <t:%d:R> changed **%d** user(s).\nTheir XP goes back to what it was before the import; XP they earned since then is lost.",
+					sn.ID, sn.FileName, sn.Source, sn.Mode, sn.ActorID, sn.CreatedAt, sn.Users,
+				),
+				Color: 0xED4245,
+			}},
+			Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Roll back", Style: discordgo.DangerButton, CustomID: xpImportCustomID(ownerID, kind, "ok")},
+				discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: xpImportCustomID(ownerID, kind, "no")},
+			}}},
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+func (m *Module) handleXPImportComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) != 4 || parts[0] != xpImportCustomBase {
+		return
+	}
+	ownerID, kind, action := parts[1], parts[2], parts[3]
+	if interactionUserID(i) != ownerID {
+		m.respondEphemeral(s, i, "Only the person who ran this command can use these buttons.")
+		return
+	}
+
+	update := func(content string) {
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:    content,
+				Embeds:     []*discordgo.MessageEmbed{},
+				Components: []discordgo.MessageComponent{},
+			},
+		})
+	}
+	editText := func(msg string) {
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+	}
+
+	if kind == xpImportKindApply {
+		plan := m.imports.take(ownerID)
+		if action != "ok" {
+			update("Cancelled, nothing was imported.")
+			return
+		}
+		if plan == nil {
+			update("This preview expired. Run `/xpimport file` again.")
+			return
+		}
+
+		update("Importing…")
+		id, applied, err := m.applyXPImport(plan)
+		if err != nil {
+			log.Printf("[levelling] xp import failed: %v", err)
+			editText("DB error importing; nothing was changed.")
+			return
+		}
+		log.Printf("[levelling] xp import #%d: %d users (%s/%s) by %s", id, len(applied), plan.Source, plan.Mode, ownerID)
+		m.syncImportRoles(s, i.GuildID, applied)
+		editText(fmt.Sprintf("✅ Imported XP for **%d** user(s) (import **#%d**).\nUndo with `/xpimport rollback`.", len(applied), id))
+		return
+	}
+
+	if action != "ok" {
+		update("Cancelled, nothing was rolled back.")
+		return
+	}
+	snapshotID, err := strconv.ParseInt(kind, 10, 64)
+	if err != nil {
+		return
+	}
+	if sn, ok, err := m.latestXPImport(); err != nil || !ok || sn.ID != snapshotID {
+		update("That's no longer the latest import; run `/xpimport rollback` again.")
+		return
+	}
+
+	update("Rolling back…")
+	changes, err := m.rollbackXPImport(snapshotID, ownerID)
+	if err != nil {
+		log.Printf("[levelling] xp import rollback failed: %v", err)
+		editText("Rollback failed; nothing was changed.")
+		return
+	}
+	m.syncImportRoles(s, i.GuildID, changes)
+	editText(fmt.Sprintf("✅ Rolled back import **#%d** (**%d** user(s) restored).", snapshotID, len(changes)))
+}
@@ -0,0 +1,150 @@
+package members
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// Join is one stay in the server: from a join until they left (LeftAt 0 = still here).
+type Join struct {
+	UserID     string
+	Username   string
+	JoinedAt   int64
+	LeftAt     int64
+	VerifiedAt int64
+
+	// 1 for their first join the bot saw, 2+ for rejoins
+	Number int
+}
+
+// recordJoin opens a join row, closing any row left open by a leave we missed.
+func (t *Tracker) recordJoin(userID string, joinedAt int64) error {
+	if t.db == nil {
+		return nil
+	}
+	tx, err := t.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`UPDATE member_joins SET left_at = ? WHERE user_id = ? AND left_at = 0`,
+		joinedAt, userID,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO member_joins(user_id, joined_at) VALUES(?,?)`,
+		userID, joinedAt,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// recordMissedJoins opens join rows for members found by a seed who have no open row
+// (they joined while the bot was offline).
+func (t *Tracker) recordMissedJoins(list []*Member, now int64) error {
+	if t.db == nil || len(list) == 0 {
+		return nil
+	}
+	tx, err := t.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, mem := range list {
+		joinedAt := mem.JoinedAt
+		if joinedAt == 0 {
+			joinedAt = now
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO member_joins(user_id, joined_at)
+			 SELECT ?, ?
+			 WHERE NOT EXISTS (SELECT 1 FROM member_joins WHERE user_id = ? AND left_at = 0)`,
+			mem.ID, joinedAt, mem.ID,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// MarkVerified records when userID finished onboarding, once per join. Joins from before the
+// tracker existed have no row and are left alone.
+func (t *Tracker) MarkVerified(userID string, at int64) error {
+	if t.db == nil || userID == "" {
+		return nil
+	}
+	_, err := t.db.Exec(
+		`UPDATE member_joins SET verified_at = ?
+		 WHERE id = (SELECT MAX(id) FROM member_joins WHERE user_id = ? AND left_at = 0)
+		   AND verified_at = 0`,
+		at, userID,
+	)
+	return err
+}
+
+// JoinsBetween lists joins with joined_at in [from, to), oldest first.
+func (t *Tracker) JoinsBetween(from, to int64) ([]Join, error) {
+	if t.db == nil {
+		return nil, sql.ErrConnDone
+	}
+	rows, err := t.db.Query(
+		`SELECT j.user_id, COALESCE(mb.username, ''), j.joined_at, j.left_at, j.verified_at,
+		        (SELECT COUNT(*) FROM member_joins p WHERE p.user_id = j.user_id AND p.id <= j.id)
+		 FROM member_joins j
+		 LEFT JOIN members mb ON mb.id = j.user_id
+		 WHERE j.joined_at >= ? AND j.joined_at < ?
+		 ORDER BY j.joined_at, j.id`,
+		from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Join
+	for rows.Next() {
+		var j Join
+		if err := rows.Scan(&j.UserID, &j.Username, &j.JoinedAt, &j.LeftAt, &j.VerifiedAt, &j.Number); err != nil {
+			return nil, err
+		}
+		out = append(out, j)
+	}
+	return out, rows.Err()
+}
+
+// JoinCounts returns how many times each of ids has joined (users never seen are missing).
+func (t *Tracker) JoinCounts(ids []string) (map[string]int, error) {
+	out := make(map[string]int, len(ids))
+	if t.db == nil || len(ids) == 0 {
+		return out, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := t.db.Query(
+		`SELECT user_id, COUNT(*) FROM member_joins
+		 WHERE user_id IN (?`+strings.Repeat(",?", len(ids)-1)+`)
+		 GROUP BY user_id`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		out[id] = n
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,110 @@
+package members
+
+import (
+	"strings"
+)
+
+// load fills the in-memory list from the members table (everyone who hasn't left).
+func (t *Tracker) load() error {
+	if t.db == nil {
+		return nil
+	}
+	rows, err := t.db.Query(
+		`SELECT id, username, nick, roles, joined_at
+		 FROM members
+		 WHERE left_at = 0`,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	loaded := map[string]*Member{}
+	for rows.Next() {
+		var mem Member
+		var roles string
+		if err := rows.Scan(&mem.ID, &mem.Username, &mem.Nick, &roles, &mem.JoinedAt); err != nil {
+			return err
+		}
+		mem.Roles = splitRoles(roles)
+		loaded[mem.ID] = &mem
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, mem := range loaded {
+		if _, ok := t.current[id]; !ok {
+			t.current[id] = mem
+		}
+	}
+	if len(loaded) > 0 {
+		t.ready = true
+	}
+	return nil
+}
+
+// saveMembers upserts members as present (clearing left_at). A zero joined_at keeps the stored one.
+func (t *Tracker) saveMembers(list []*Member) error {
+	if t.db == nil || len(list) == 0 {
+		return nil
+	}
+	tx, err := t.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(
+		`INSERT INTO members(id, username, nick, roles, joined_at, left_at)
+		 VALUES(?,?,?,?,?,0)
+		 ON CONFLICT(id) DO UPDATE SET
+		   username  = excluded.username,
+		   nick      = excluded.nick,
+		   roles     = excluded.roles,
+		   joined_at = CASE WHEN excluded.joined_at > 0 THEN excluded.joined_at ELSE members.joined_at END,
+		   left_at   = 0`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, mem := range list {
+		if _, err := stmt.Exec(mem.ID, mem.Username, mem.Nick, strings.Join(mem.Roles, ","), mem.JoinedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// markLeft stamps left_at (members + their open join) for members who are no longer in the server.
+func (t *Tracker) markLeft(ids []string, at int64) error {
+	if t.db == nil || len(ids) == 0 {
+		return nil
+	}
+	tx, err := t.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		if _, err := tx.Exec(`UPDATE members SET left_at = ? WHERE id = ? AND left_at = 0`, at, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE member_joins SET left_at = ? WHERE user_id = ? AND left_at = 0`, at, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func splitRoles(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,309 @@
+package members
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Member is a tracked guild member.
+type Member struct {
+	ID       string
+	Username string
+	Nick     string
+	Roles    []string
+	JoinedAt int64
+}
+
+// DisplayName is the server nickname, falling back to the username.
+func (mem Member) DisplayName() string {
+	if mem.Nick != "" {
+		return mem.Nick
+	}
+	return mem.Username
+}
+
+// HasRole reports whether the member holds roleID.
+func (mem Member) HasRole(roleID string) bool {
+	for _, id := range mem.Roles {
+		if id == roleID {
+			return true
+		}
+	}
+	return false
+}
+
+// Tracker keeps the guild's member list in memory and in the members table, so modules
+// don't have to page through GuildMembers. It's seeded by requesting member chunks over the
+// gateway when the guild becomes available, then kept current by member add/update/remove events.
+// Bots aren't tracked.
+type Tracker struct {
+	db      *sql.DB
+	guildID string
+
+	mu      sync.RWMutex
+	current map[string]*Member // members in the server right now
+
+	// Loaded from the DB or seeded at least once
+	ready bool
+
+	// The running seed: chunks are tagged with our nonce and may be handled out of order.
+	// seedPrev is who we thought was here when it started (nil on the very first seed).
+	seedNonce  string
+	seedSeen   map[string]struct{}
+	seedPrev   map[string]struct{}
+	seedChunks int
+}
+
+func New(guildID string, db *sql.DB) *Tracker {
+	return &Tracker{
+		db:      db,
+		guildID: strings.TrimSpace(guildID),
+		current: map[string]*Member{},
+	}
+}
+
+func (t *Tracker) Name() string { return "members" }
+
+func (t *Tracker) Register(s *discordgo.Session) error {
+	// Last run's member list, so lookups work before the seed finishes
+	if err := t.load(); err != nil {
+		log.Printf("[members] load failed: %v", err)
+	}
+
+	s.AddHandler(t.onGuildCreate)
+	s.AddHandler(t.onGuildMembersChunk)
+	s.AddHandler(t.onGuildMemberAdd)
+	s.AddHandler(t.onGuildMemberUpdate)
+	s.AddHandler(t.onGuildMemberRemove)
+	return nil
+}
+
+func (t *Tracker) Start(ctx context.Context, s *discordgo.Session) error { return nil }
+
+/* =========================
+   Lookups
+   ========================= */
+
+// Ready reports whether the tracker has a member list (from the DB or a finished seed).
+func (t *Tracker) Ready() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.ready
+}
+
+// Count is the number of (non-bot) members in the server.
+func (t *Tracker) Count() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.current)
+}
+
+// IsMember reports whether userID is in the server.
+func (t *Tracker) IsMember(userID string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, ok := t.current[userID]
+	return ok
+}
+
+// Get returns a current member.
+func (t *Tracker) Get(userID string) (Member, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	mem, ok := t.current[userID]
+	if !ok {
+		return Member{}, false
+	}
+	return *mem, true
+}
+
+// IDs returns the set of current member IDs (a copy).
+func (t *Tracker) IDs() map[string]struct{} {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make(map[string]struct{}, len(t.current))
+	for id := range t.current {
+		out[id] = struct{}{}
+	}
+	return out
+}
+
+// Members returns every current member (copies, in no particular order).
+func (t *Tracker) Members() []Member {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make([]Member, 0, len(t.current))
+	for _, mem := range t.current {
+		out = append(out, *mem)
+	}
+	return out
+}
+
+/* =========================
+   Seeding (GUILD_MEMBERS chunks)
+   ========================= */
+
+func (t *Tracker) onGuildCreate(s *discordgo.Session, e *discordgo.GuildCreate) {
+	if e == nil || e.Guild == nil || e.Guild.ID != t.guildID || e.Guild.Unavailable {
+		return
+	}
+
+	nonce := "members-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	t.mu.Lock()
+	t.seedNonce = nonce
+	t.seedSeen = map[string]struct{}{}
+	t.seedPrev = nil
+	if t.ready {
+		t.seedPrev = make(map[string]struct{}, len(t.current))
+		for id := range t.current {
+			t.seedPrev[id] = struct{}{}
+		}
+	}
+	t.seedChunks = 0
+	t.mu.Unlock()
+
+	// Empty query + limit 0 = every member, delivered as GuildMembersChunk events
+	if err := s.RequestGuildMembers(t.guildID, "", 0, nonce, false); err != nil {
+		log.Printf("[members] request member chunks failed: %v", err)
+	}
+}
+
+func (t *Tracker) onGuildMembersChunk(s *discordgo.Session, e *discordgo.GuildMembersChunk) {
+	if e == nil || e.GuildID != t.guildID {
+		return
+	}
+
+	t.mu.Lock()
+	if e.Nonce == "" || e.Nonce != t.seedNonce {
+		t.mu.Unlock()
+		return
+	}
+	batch := make([]*Member, 0, len(e.Members))
+	var arrived []*Member
+	for _, dm := range e.Members {
+		mem := fromDiscord(dm)
+		if mem == nil {
+			continue
+		}
+		t.seedSeen[mem.ID] = struct{}{}
+		t.current[mem.ID] = mem
+		batch = append(batch, mem)
+		if _, known := t.seedPrev[mem.ID]; t.seedPrev != nil && !known {
+			arrived = append(arrived, mem)
+		}
+	}
+	t.seedChunks++
+	last := t.seedChunks >= e.ChunkCount
+	var gone []string
+	if last {
+		// Anyone we thought was here but isn't in the chunks left while we were offline
+		for id := range t.current {
+			if _, ok := t.seedSeen[id]; !ok {
+				gone = append(gone, id)
+				delete(t.current, id)
+			}
+		}
+		t.seedNonce = ""
+		t.seedSeen = nil
+		t.seedPrev = nil
+		t.ready = true
+	}
+	t.mu.Unlock()
+
+	if err := t.saveMembers(batch); err != nil {
+		log.Printf("[members] save chunk %d/%d failed: %v", e.ChunkIndex+1, e.ChunkCount, err)
+	}
+	if err := t.recordMissedJoins(arrived, time.Now().Unix()); err != nil {
+		log.Printf("[members] record missed joins failed: %v", err)
+	}
+	if last {
+		if err := t.markLeft(gone, time.Now().Unix()); err != nil {
+			log.Printf("[members] mark departed members failed: %v", err)
+		}
+		log.Printf("[members] seeded %d members (%d left while offline)", t.Count(), len(gone))
+	}
+}
+
+/* =========================
+   Live updates
+   ========================= */
+
+func (t *Tracker) onGuildMemberAdd(s *discordgo.Session, e *discordgo.GuildMemberAdd) {
+	if e == nil || e.Member == nil || e.GuildID != t.guildID {
+		return
+	}
+	mem := t.upsert(e.Member)
+	if mem == nil {
+		return
+	}
+	joinedAt := mem.JoinedAt
+	if joinedAt == 0 {
+		joinedAt = time.Now().Unix()
+	}
+	if err := t.recordJoin(mem.ID, joinedAt); err != nil {
+		log.Printf("[members] record join failed (user=%s): %v", mem.ID, err)
+	}
+}
+
+func (t *Tracker) onGuildMemberUpdate(s *discordgo.Session, e *discordgo.GuildMemberUpdate) {
+	if e == nil || e.Member == nil || e.GuildID != t.guildID {
+		return
+	}
+	t.upsert(e.Member)
+}
+
+func (t *Tracker) onGuildMemberRemove(s *discordgo.Session, e *discordgo.GuildMemberRemove) {
+	if e == nil || e.Member == nil || e.User == nil || e.GuildID != t.guildID {
+		return
+	}
+	t.mu.Lock()
+	delete(t.current, e.User.ID)
+	t.mu.Unlock()
+
+	if err := t.markLeft([]string{e.User.ID}, time.Now().Unix()); err != nil {
+		log.Printf("[members] mark left failed (user=%s): %v", e.User.ID, err)
+	}
+}
+
+func (t *Tracker) upsert(dm *discordgo.Member) *Member {
+	mem := fromDiscord(dm)
+	if mem == nil {
+		return nil
+	}
+	t.mu.Lock()
+	t.current[mem.ID] = mem
+	if t.seedSeen != nil {
+		// Joined mid-seed: don't count them as gone when the last chunk lands
+		t.seedSeen[mem.ID] = struct{}{}
+	}
+	t.mu.Unlock()
+
+	if err := t.saveMembers([]*Member{mem}); err != nil {
+		log.Printf("[members] save failed (user=%s): %v", mem.ID, err)
+	}
+	return mem
+}
+
+// fromDiscord converts a gateway member; nil for bots and partial entries.
+func fromDiscord(dm *discordgo.Member) *Member {
+	if dm == nil || dm.User == nil || dm.User.ID == "" || dm.User.Bot {
+		return nil
+	}
+	mem := &Member{
+		ID:       dm.User.ID,
+		Username: dm.User.Username,
+		Nick:     dm.Nick,
+		Roles:    append([]string(nil), dm.Roles...),
+	}
+	if !dm.JoinedAt.IsZero() {
+		mem.JoinedAt = dm.JoinedAt.Unix()
+	}
+	return mem
+}
@@ -0,0 +1,44 @@
+package starboard
+
+// TopUser is an author ranked by starboard posts, for the web leaderboard (modules/web).
+type TopUser struct {
+	UserID string `json:"user_id"`
+	Posts  int    `json:"posts"`
+}
+
+// TopPost is a starboarded message ranked by stars.
+type TopPost struct {
+	AuthorID string `json:"author_id"`
+	Stars    int    `json:"stars"`
+	JumpURL  string `json:"jump_url,omitempty"`
+}
+
+// TopUsers is the /topstars users board.
+func (m *TopStarsModule) TopUsers() ([]TopUser, error) {
+	rows, err := m.queryAllTopUsers()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]TopUser, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, TopUser{UserID: r.AuthorID, Posts: r.Count})
+	}
+	return out, nil
+}
+
+// TopPosts is the /topstars posts board. Jump links need the guild ID (it isn't stored).
+func (m *TopStarsModule) TopPosts(guildID string) ([]TopPost, error) {
+	rows, err := m.queryAllTopPosts()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]TopPost, 0, len(rows))
+	for _, r := range rows {
+		p := TopPost{AuthorID: r.AuthorID, Stars: r.StarsCount}
+		if guildID != "" && r.OriginalChannelID != "" && r.OriginalMessageID != "" {
+			p.JumpURL = makeJumpURL(guildID, r.OriginalChannelID, r.OriginalMessageID)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
@@ -0,0 +1,262 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Sentinaut/AuraBot/modules/levelling"
+	"github.com/Sentinaut/AuraBot/modules/members"
+)
+
+const (
+	boardXP        = "xp"
+	boardCounting  = "counting"
+	boardStarboard = "starboard"
+)
+
+// badRequest is an error caused by the query string (HTTP 400) rather than the DB.
+type badRequest string
+
+func (e badRequest) Error() string { return string(e) }
+
+// entry is one ranked row on any board. Value is XP, counts, starboard posts or stars.
+type entry struct {
+	Rank    int    `json:"rank"`
+	UserID  string `json:"user_id"`
+	Name    string `json:"name"`
+	Value   int64  `json:"value"`
+	Level   int    `json:"level,omitempty"`
+	JumpURL string `json:"jump_url,omitempty"`
+}
+
+// board is a page of one leaderboard, as served by the API and rendered by the HTML page.
+type board struct {
+	Board   string  `json:"board"`
+	Scope   string  `json:"scope"`
+	Title   string  `json:"title"`
+	Unit    string  `json:"unit"`
+	Page    int     `json:"page"`
+	Pages   int     `json:"pages"`
+	Limit   int     `json:"limit"`
+	Total   int     `json:"total"`
+	Note    string  `json:"note,omitempty"`
+	Entries []entry `json:"entries"`
+}
+
+// boardOptions is the parsed query string shared by the API and the page.
+type boardOptions struct {
+	Scope    string
+	From     string
+	To       string
+	Season   int64
+	Page     int
+	Limit    int
+	Everyone bool
+}
+
+func parseBoardOptions(q url.Values) (boardOptions, error) {
+	o := boardOptions{
+		Scope:    strings.ToLower(strings.TrimSpace(q.Get("scope"))),
+		From:     strings.TrimSpace(q.Get("from")),
+		To:       strings.TrimSpace(q.Get("to")),
+		Page:     1,
+		Limit:    defaultLimit,
+		Everyone: q.Get("everyone") == "1" || q.Get("everyone") == "true",
+	}
+	if kind := strings.ToLower(strings.TrimSpace(q.Get("kind"))); kind != "" {
+		o.Scope = kind
+	}
+
+	var err error
+	if v := q.Get("season"); v != "" {
+		if o.Season, err = strconv.ParseInt(v, 10, 64); err != nil || o.Season <= 0 {
+			return o, badRequest("season must be a positive number")
+		}
+	}
+	if v := q.Get("page"); v != "" {
+		if o.Page, err = strconv.Atoi(v); err != nil || o.Page < 1 {
+			return o, badRequest("page must be 1 or more")
+		}
+		o.Page = min(o.Page, maxPage)
+	}
+	if v := q.Get("limit"); v != "" {
+		if o.Limit, err = strconv.Atoi(v); err != nil || o.Limit < 1 {
+			return o, badRequest("limit must be 1 or more")
+		}
+		if o.Limit > maxLimit {
+			o.Limit = maxLimit
+		}
+	}
+	return o, nil
+}
+
+func (o boardOptions) offset() int { return max(0, (o.Page-1)*o.Limit) }
+
+// loadBoard reads one page of a board. Counting and starboard rows are filtered to current members.
+func (m *Module) loadBoard(name string, o boardOptions) (board, error) {
+	switch name {
+	case boardXP:
+		if m.xp != nil {
+			return m.loadXP(o)
+		}
+	case boardCounting:
+		if m.counting != nil {
+			return m.loadCounting(o)
+		}
+	case boardStarboard:
+		if m.stars != nil {
+			return m.loadStarboard(o)
+		}
+	}
+	return board{}, badRequest(fmt.Sprintf("the %s board isn't enabled", name))
+}
+
+func (m *Module) loadXP(o boardOptions) (board, error) {
+	scope := o.Scope
+	if scope == "" {
+		scope = "all"
+	}
+	p, err := m.xp.Leaderboard(m.getSession(), levelling.LeaderboardQuery{
+		Scope:    scope,
+		From:     o.From,
+		To:       o.To,
+		Season:   o.Season,
+		Everyone: o.Everyone,
+		Limit:    o.Limit,
+		Offset:   o.offset(),
+	})
+	if errors.Is(err, levelling.ErrBadScope) {
+		return board{}, badRequest(err.Error())
+	}
+	if err != nil {
+		return board{}, err
+	}
+
+	b := board{Board: boardXP, Scope: scope, Title: "XP — " + p.Label, Unit: "XP", Note: p.Note, Total: p.Total}
+	if o.Season > 0 {
+		b.Scope = "season"
+	}
+	for _, e := range p.Entries {
+		b.Entries = append(b.Entries, entry{Rank: e.Rank, UserID: e.UserID, Name: e.Name, Value: e.XP, Level: e.Level})
+	}
+	return b.paged(o), nil
+}
+
+func (m *Module) loadCounting(o boardOptions) (board, error) {
+	scope := o.Scope
+	if scope == "" {
+		scope = "normal"
+	}
+	titles := map[string]string{"normal": "Counting", "trios": "Counting — Trios", "total": "Counting — Total"}
+	title, ok := titles[scope]
+	if !ok {
+		return board{}, badRequest("counting scope must be normal, trios or total")
+	}
+
+	rows, err := m.counting.Leaderboard(scope)
+	if err != nil {
+		return board{}, err
+	}
+
+	filter, note := m.memberFilter(o)
+	b := board{Board: boardCounting, Scope: scope, Title: title, Unit: "counts", Note: note}
+	for _, r := range rows {
+		name, ok := filter.name(r.UserID)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			name = r.Username
+		}
+		b.Entries = append(b.Entries, entry{UserID: r.UserID, Name: name, Value: r.Counts})
+	}
+	return b.ranked(o), nil
+}
+
+func (m *Module) loadStarboard(o boardOptions) (board, error) {
+	scope := o.Scope
+	if scope == "" {
+		scope = "users"
+	}
+	filter, note := m.memberFilter(o)
+
+	b := board{Board: boardStarboard, Scope: scope, Note: note}
+	switch scope {
+	case "users":
+		b.Title, b.Unit = "Starboard — Top users", "posts"
+		rows, err := m.stars.TopUsers()
+		if err != nil {
+			return board{}, err
+		}
+		for _, r := range rows {
+			if name, ok := filter.name(r.UserID); ok {
+				b.Entries = append(b.Entries, entry{UserID: r.UserID, Name: name, Value: int64(r.Posts)})
+			}
+		}
+	case "posts":
+		b.Title, b.Unit = "Starboard — Top posts", "stars"
+		rows, err := m.stars.TopPosts(m.guildID)
+		if err != nil {
+			return board{}, err
+		}
+		for _, r := range rows {
+			if name, ok := filter.name(r.AuthorID); ok {
+				b.Entries = append(b.Entries, entry{UserID: r.AuthorID, Name: name, Value: int64(r.Stars), JumpURL: r.JumpURL})
+			}
+		}
+	default:
+		return board{}, badRequest("starboard kind must be users or posts")
+	}
+	return b.ranked(o), nil
+}
+
+// memberFilter limits counting/starboard rows to current members, using the member tracker
+// (the same list /leaderboard filters with). Off for ?everyone=1 or while the tracker is still loading,
+// in which case note says so, like /leaderboard.
+type memberFilter struct {
+	tracker *members.Tracker
+}
+
+func (m *Module) memberFilter(o boardOptions) (memberFilter, string) {
+	if o.Everyone || m.tracker == nil {
+		return memberFilter{}, ""
+	}
+	if !m.tracker.Ready() {
+		return memberFilter{}, "⚠️ The server member list is still loading, so this includes people who have left."
+	}
+	return memberFilter{tracker: m.tracker}, ""
+}
+
+// name reports whether userID passes the filter, with their display name if known.
+func (f memberFilter) name(userID string) (string, bool) {
+	if f.tracker == nil {
+		return "", true
+	}
+	mem, ok := f.tracker.Get(userID)
+	return mem.DisplayName(), ok
+}
+
+// ranked numbers the full (filtered) board and cuts out the requested page.
+func (b board) ranked(o boardOptions) board {
+	b.Total = len(b.Entries)
+	for idx := range b.Entries {
+		b.Entries[idx].Rank = idx + 1
+	}
+	start := min(o.offset(), len(b.Entries))
+	end := min(start+o.Limit, len(b.Entries))
+	b.Entries = b.Entries[start:end]
+	return b.paged(o)
+}
+
+func (b board) paged(o boardOptions) board {
+	b.Page = o.Page
+	b.Limit = o.Limit
+	b.Pages = max(1, (b.Total+o.Limit-1)/o.Limit)
+	if b.Entries == nil {
+		b.Entries = []entry{}
+	}
+	return b
+}
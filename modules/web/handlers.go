@@ -0,0 +1,229 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func (m *Module) handleAPI(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		o, err := parseBoardOptions(r.URL.Query())
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		b, err := m.loadBoard(name, o)
+		if err != nil {
+			status := statusFor(err)
+			if status == http.StatusInternalServerError {
+				log.Printf("[web] %s board failed: %v", name, err)
+				err = errors.New("couldn't read the leaderboard")
+			}
+			writeJSON(w, status, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, b)
+	}
+}
+
+func (m *Module) handlePage(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	name := q.Get("board")
+	if name == "" {
+		name = boardXP
+	}
+
+	view := pageView{Title: m.cfg.Title, Board: name, Tabs: m.tabs(name)}
+
+	o, err := parseBoardOptions(q)
+	if err == nil {
+		view.Data, err = m.loadBoard(name, o)
+	}
+	status := http.StatusOK
+	if err != nil {
+		status = statusFor(err)
+		if status == http.StatusInternalServerError {
+			log.Printf("[web] %s page failed: %v", name, err)
+			view.Error = "Couldn't read the leaderboard. Try again in a moment."
+		} else {
+			view.Error = err.Error()
+		}
+	} else {
+		view.Scopes = scopeLinks(name, view.Data.Scope, o)
+		view.ShowLevel = name == boardXP && (view.Data.Scope == "all" || view.Data.Scope == "season")
+		if o.Page > 1 {
+			view.PrevURL = pageURL(q, o.Page-1)
+		}
+		if o.Page < view.Data.Pages {
+			view.NextURL = pageURL(q, o.Page+1)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pageTmpl.Execute(&buf, view); err != nil {
+		log.Printf("[web] render failed: %v", err)
+		http.Error(w, "render failed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func statusFor(err error) int {
+	var bad badRequest
+	if errors.As(err, &bad) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Printf("[web] write json failed: %v", err)
+	}
+}
+
+/* =========================
+   HTML page
+   ========================= */
+
+type link struct {
+	Label  string
+	URL    string
+	Active bool
+}
+
+type pageView struct {
+	Title  string
+	Board  string
+	Tabs   []link
+	Scopes []link
+	Data   board
+	Error  string
+
+	// Totals boards (not XP gained in a window) show levels
+	ShowLevel bool
+
+	PrevURL string
+	NextURL string
+}
+
+func (m *Module) tabs(active string) []link {
+	var out []link
+	add := func(name, label string, enabled bool) {
+		if enabled {
+			out = append(out, link{Label: label, URL: "/?board=" + name, Active: name == active})
+		}
+	}
+	add(boardXP, "XP", m.xp != nil)
+	add(boardCounting, "Counting", m.counting != nil)
+	add(boardStarboard, "Starboard", m.stars != nil)
+	return out
+}
+
+// scopeLinks are the per-board filters shown above the table (keeping ?everyone and ?limit).
+func scopeLinks(name, active string, o boardOptions) []link {
+	var scopes [][2]string
+	param := "scope"
+	switch name {
+	case boardXP:
+		scopes = [][2]string{{"all", "All time"}, {"week", "This week"}, {"month", "This month"}}
+	case boardCounting:
+		scopes = [][2]string{{"normal", "Normal"}, {"trios", "Trios"}, {"total", "Total"}}
+	case boardStarboard:
+		param = "kind"
+		scopes = [][2]string{{"users", "Top users"}, {"posts", "Top posts"}}
+	}
+
+	out := make([]link, 0, len(scopes))
+	for _, sc := range scopes {
+		v := url.Values{"board": {name}, param: {sc[0]}}
+		if o.Everyone {
+			v.Set("everyone", "1")
+		}
+		if o.Limit != defaultLimit {
+			v.Set("limit", strconv.Itoa(o.Limit))
+		}
+		out = append(out, link{Label: sc[1], URL: "/?" + v.Encode(), Active: sc[0] == active})
+	}
+	return out
+}
+
+func pageURL(q url.Values, page int) string {
+	v := url.Values{}
+	for k, vals := range q {
+		v[k] = vals
+	}
+	v.Set("page", strconv.Itoa(page))
+	return "/?" + v.Encode()
+}
+
+var pageTmpl = template.Must(template.New("page").Parse(`<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}{{if .Data.Title}} — {{.Data.Title}}{{end}}</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #1e1f22; color: #dbdee1; margin: 0; padding: 2rem 1rem; }
+main { max-width: 760px; margin: 0 auto; }
+a { color: #00a8fc; text-decoration: none; }
+nav a, .scopes a { display: inline-block; padding: .35rem .8rem; margin: 0 .25rem .5rem 0; border-radius: 6px; background: #2b2d31; }
+nav a.active, .scopes a.active { background: #5865f2; color: #fff; }
+.scopes a { font-size: .9rem; }
+table { width: 100%; border-collapse: collapse; margin-top: .5rem; }
+th, td { text-align: left; padding: .5rem; border-bottom: 1px solid #2b2d31; }
+td.num, th.num { text-align: right; font-variant-numeric: tabular-nums; }
+.note { color: #f0b232; }
+.error { color: #f23f43; }
+.muted { color: #949ba4; font-size: .9rem; }
+.pager { display: flex; justify-content: space-between; margin-top: 1rem; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+<nav>{{range .Tabs}}<a href="{{.URL}}"{{if .Active}} class="active"{{end}}>{{.Label}}</a>{{end}}</nav>
+<div class="scopes">{{range .Scopes}}<a href="{{.URL}}"{{if .Active}} class="active"{{end}}>{{.Label}}</a>{{end}}</div>
+{{if .Error}}
+<p class="error">{{.Error}}</p>
+{{else}}
+<h2>{{.Data.Title}}</h2>
+{{if .Data.Note}}<p class="note">{{.Data.Note}}</p>{{end}}
+{{if .Data.Entries}}
+<table>
+<thead><tr><th class="num">#</th><th>Member</th>{{if .ShowLevel}}<th class="num">Level</th>{{end}}<th class="num">{{.Data.Unit}}</th>{{if eq .Data.Scope "posts"}}<th></th>{{end}}</tr></thead>
+<tbody>
+{{range .Data.Entries}}<tr>
+<td class="num">{{.Rank}}</td>
+<td>{{if .Name}}{{.Name}}{{else}}<span class="muted">{{.UserID}}</span>{{end}}</td>
+{{if $.ShowLevel}}<td class="num">{{.Level}}</td>{{end}}
+<td class="num">{{.Value}}</td>
+{{if eq $.Data.Scope "posts"}}<td>{{if .JumpURL}}<a href="{{.JumpURL}}">Jump</a>{{end}}</td>{{end}}
+</tr>
+{{end}}</tbody>
+</table>
+<div class="pager">
+<span>{{if .PrevURL}}<a href="{{.PrevURL}}">← Previous</a>{{end}}</span>
+<span class="muted">Page {{.Data.Page}} of {{.Data.Pages}} · {{.Data.Total}} total</span>
+<span>{{if .NextURL}}<a href="{{.NextURL}}">Next →</a>{{end}}</span>
+</div>
+{{else}}
+<p class="muted">Nothing here yet.</p>
+{{end}}
+{{end}}
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,134 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sentinaut/AuraBot/modules/counting"
+	"github.com/Sentinaut/AuraBot/modules/levelling"
+	"github.com/Sentinaut/AuraBot/modules/members"
+	"github.com/Sentinaut/AuraBot/modules/starboard"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Config is the optional read-only web leaderboard (main.go). Off unless Enabled.
+type Config struct {
+	Enabled bool
+
+	// Listen address, e.g. "127.0.0.1:8080" (put a reverse proxy in front to expose it)
+	Addr string
+
+	// Page heading; defaults to "Leaderboards"
+	Title string
+}
+
+const (
+	defaultAddr  = "127.0.0.1:8080"
+	defaultLimit = 25
+	maxLimit     = 100
+
+	// Past any real board; keeps (page-1)*limit from overflowing
+	maxPage = 100_000
+)
+
+// Module serves the XP, counting and starboard leaderboards over HTTP:
+//
+//	GET /                      HTML page (?board=xp|counting|starboard, same options as the API)
+//	GET /api/xp                ?scope=all|week|month|custom&from=&to=&season=
+//	GET /api/counting          ?scope=normal|trios|total
+//	GET /api/starboard         ?kind=users|posts
+//
+// Every board takes ?page= (from 1), ?limit= (max 100) and ?everyone=1 to include people who left.
+type Module struct {
+	cfg     Config
+	guildID string
+
+	// Filters boards to current members and supplies display names
+	tracker *members.Tracker
+
+	xp       *levelling.Module
+	counting *counting.Module
+	stars    *starboard.TopStarsModule
+
+	// Set in Start; levelling falls back to fetching members with it when there is no tracker
+	mu      sync.RWMutex
+	session *discordgo.Session
+}
+
+// New wires the web leaderboard to the modules it reads from; any of them may be nil to leave that board out.
+func New(cfg Config, guildID string, tracker *members.Tracker, xp *levelling.Module, c *counting.Module, stars *starboard.TopStarsModule) *Module {
+	cfg.Addr = strings.TrimSpace(cfg.Addr)
+	if cfg.Addr == "" {
+		cfg.Addr = defaultAddr
+	}
+	cfg.Title = strings.TrimSpace(cfg.Title)
+	if cfg.Title == "" {
+		cfg.Title = "Leaderboards"
+	}
+	return &Module{
+		cfg:      cfg,
+		guildID:  strings.TrimSpace(guildID),
+		tracker:  tracker,
+		xp:       xp,
+		counting: c,
+		stars:    stars,
+	}
+}
+
+func (m *Module) Name() string { return "web" }
+
+func (m *Module) Register(s *discordgo.Session) error { return nil }
+
+func (m *Module) Start(ctx context.Context, s *discordgo.Session) error {
+	if !m.cfg.Enabled {
+		return nil
+	}
+
+	m.mu.Lock()
+	m.session = s
+	m.mu.Unlock()
+
+	srv := &http.Server{
+		Addr:              m.cfg.Addr,
+		Handler:           m.routes(),
+		ReadHeaderTimeout: 5 * time.Second,
+		// The first request after a restart pages through the whole member list
+		WriteTimeout: 60 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	go func() {
+		log.Printf("[web] leaderboard listening on http://%s", m.cfg.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[web] server stopped: %v", err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	return nil
+}
+
+func (m *Module) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", m.handlePage)
+	mux.HandleFunc("GET /api/xp", m.handleAPI(boardXP))
+	mux.HandleFunc("GET /api/counting", m.handleAPI(boardCounting))
+	mux.HandleFunc("GET /api/starboard", m.handleAPI(boardStarboard))
+	return mux
+}
+
+func (m *Module) getSession() *discordgo.Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.session
+}
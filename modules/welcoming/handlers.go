@@ -181,6 +181,11 @@ func (m *Module) onInteractionCreate(s *discordgo.Session, i *discordgo.Interact
 
 	_ = s.InteractionRespond(i.Interaction, ephemeral("✅ Done! Your nickname has been set to **"+escapeMarkdown(name)+"**."))
 
+	// Onboarding is finished here unless staff still have to verify them (then it's when they get the member role)
+	if autoVerify || m.memberRoleID == "" {
+		m.markVerified(targetUserID)
+	}
+
 	// Cleanup: delete thread + parent message + session
 	m.mu.Lock()
 	delete(m.sessions, targetUserID)
@@ -2,10 +2,15 @@ package welcoming
 
 import (
 	"log"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
+// With no cached member to compare against, a member role on someone who joined longer ago than
+// this is assumed to be old and isn't recorded as a verification.
+const unobservedVerifyWindow = time.Hour
+
 // Registers /toggleautoverify as a GUILD command.
 // Fires on startup and when bot joins a guild.
 func (m *Module) onGuildCreate(s *discordgo.Session, e *discordgo.GuildCreate) {
@@ -116,6 +121,44 @@ func (m *Module) onGuildMemberAdd(s *discordgo.Session, e *discordgo.GuildMember
 	)
 }
 
+// onGuildMemberUpdate records verification when someone gets the member role (covers staff verifying by hand).
+func (m *Module) onGuildMemberUpdate(s *discordgo.Session, e *discordgo.GuildMemberUpdate) {
+	if e == nil || e.Member == nil || e.User == nil || e.User.Bot || m.memberRoleID == "" {
+		return
+	}
+	if !hasRole(e.Roles, m.memberRoleID) {
+		return
+	}
+	if e.BeforeUpdate != nil {
+		if hasRole(e.BeforeUpdate.Roles, m.memberRoleID) {
+			return
+		}
+	} else if e.JoinedAt.IsZero() || time.Since(e.JoinedAt) > unobservedVerifyWindow {
+		// Not cached, so the role may be old news: only a fresh join is likely being verified now
+		return
+	}
+	m.markVerified(e.User.ID)
+}
+
+// markVerified stamps time-to-verify on their current join (used by /retention).
+func (m *Module) markVerified(userID string) {
+	if m.tracker == nil {
+		return
+	}
+	if err := m.tracker.MarkVerified(userID, time.Now().Unix()); err != nil {
+		log.Printf("[welcoming] record verification failed (user=%s): %v", userID, err)
+	}
+}
+
+func hasRole(roles []string, roleID string) bool {
+	for _, r := range roles {
+		if r == roleID {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *Module) onGuildMemberRemove(s *discordgo.Session, e *discordgo.GuildMemberRemove) {
 	if e == nil || e.User == nil || e.User.Bot {
 		return
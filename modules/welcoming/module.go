@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Sentinaut/AuraBot/modules/members"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -28,6 +29,9 @@ type Module struct {
 	// Staff role pinged when auto-verify is OFF
 	staffRoleID string
 
+	// Shared member tracker (member numbers in the welcome embed, time-to-verify); may be nil
+	tracker *members.Tracker
+
 	mu       sync.Mutex
 	sessions map[string]*onboardSession // key = userID
 }
@@ -44,7 +48,7 @@ type onboardSession struct {
 	NotifiedStaff bool
 }
 
-func New(welcomeChannelID, onboardingChannelID, memberRoleID, unverifiedRoleID, joinRoleID, staffRoleID string) *Module {
+func New(welcomeChannelID, onboardingChannelID, memberRoleID, unverifiedRoleID, joinRoleID, staffRoleID string, tracker *members.Tracker) *Module {
 	return &Module{
 		welcomeChannelID:    strings.TrimSpace(welcomeChannelID),
 		onboardingChannelID: strings.TrimSpace(onboardingChannelID),
@@ -54,6 +58,7 @@ func New(welcomeChannelID, onboardingChannelID, memberRoleID, unverifiedRoleID,
 		unverifiedRoleID: strings.TrimSpace(unverifiedRoleID),
 		joinRoleID:       strings.TrimSpace(joinRoleID),
 		staffRoleID:      strings.TrimSpace(staffRoleID),
+		tracker:          tracker,
 
 		autoVerifyEnabled: envBoolDefault("WELCOMING_AUTOVERIFY_DEFAULT", true),
 
@@ -69,6 +74,7 @@ func (m *Module) Register(s *discordgo.Session) error {
 
 	s.AddHandler(m.onGuildMemberAdd)
 	s.AddHandler(m.onGuildMemberRemove) // cleanup if they leave before verify
+	s.AddHandler(m.onGuildMemberUpdate) // member role granted = verified (for /retention)
 	s.AddHandler(m.onMessageCreate)
 	s.AddHandler(m.onInteractionCreate)
 	return nil